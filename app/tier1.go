@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"runtime"
 	"time"
 
 	"github.com/streamingfast/substreams/reqctx"
@@ -18,6 +19,7 @@ import (
 	"github.com/streamingfast/shutter"
 	"github.com/streamingfast/substreams/client"
 	"github.com/streamingfast/substreams/metrics"
+	"github.com/streamingfast/substreams/orchestrator/work"
 	"github.com/streamingfast/substreams/service"
 	"github.com/streamingfast/substreams/wasm"
 	"go.uber.org/atomic"
@@ -53,7 +55,30 @@ type Tier1Config struct {
 	SubrequestsInsecure  bool
 	SubrequestsPlaintext bool
 
-	WASMExtensions wasm.WASMExtensioner
+	// LocalSubrequests processes the subrequests in-process instead of sending them to the
+	// tier2 at SubrequestsEndpoint, at most LocalSubrequestsMaxConcurrent (defaults to the
+	// number of CPUs) at once across all requests.
+	LocalSubrequests              bool
+	LocalSubrequestsMaxConcurrent uint64
+
+	// FairShare shares the subrequests of all the requests between their tenants (user or
+	// API key), with per-tenant weights and limits. Nil sends them as soon as a worker of
+	// the request is free.
+	FairShare *work.FairShareConfig
+
+	// StragglerFactor sends a duplicate of the subrequests running this many times slower
+	// per block than the median of their stage to another worker, 0 to disable.
+	StragglerFactor float64
+
+	// TargetJobDuration sizes the subrequests of each stage from its measured per-block
+	// cost to take about this long, grouping up to MaxJobSegments (defaults to 10) segments
+	// of StateBundleSize blocks. Zero sends single-segment subrequests.
+	TargetJobDuration time.Duration
+	MaxJobSegments    uint64
+
+	WASMExtensions        wasm.WASMExtensioner
+	WASMRuntime           string // name of a registered wasm runtime, defaults to `wasm.DefaultRuntime` when empty
+	WASMInstanceSnapshots bool   // reuse wasm instances across blocks, restoring their memory between executions
 
 	Tracing bool
 }
@@ -155,10 +180,46 @@ func (a *Tier1App) Run() error {
 		opts = append(opts, service.WithWASMExtensioner(a.config.WASMExtensions))
 	}
 
+	if a.config.WASMRuntime != "" {
+		opts = append(opts, service.WithWASMRuntime(a.config.WASMRuntime))
+	}
+
+	if a.config.WASMInstanceSnapshots {
+		opts = append(opts, service.WithWASMInstanceSnapshots())
+	}
+
 	if a.config.Tracing {
 		opts = append(opts, service.WithModuleExecutionTracing())
 	}
 
+	if a.config.FairShare != nil {
+		opts = append(opts, service.WithFairShare(*a.config.FairShare))
+	}
+
+	if a.config.StragglerFactor > 0 {
+		opts = append(opts, service.WithSpeculativeJobs(a.config.StragglerFactor))
+	}
+
+	if a.config.TargetJobDuration > 0 {
+		maxSegments := a.config.MaxJobSegments
+		if maxSegments == 0 {
+			maxSegments = 10
+		}
+		opts = append(opts, service.WithAdaptiveJobSizes(a.config.TargetJobDuration, maxSegments))
+	}
+
+	if a.config.LocalSubrequests {
+		tier2, err := service.NewTier2(a.logger, opts...)
+		if err != nil {
+			return fmt.Errorf("creating local tier2: %w", err)
+		}
+		maxConcurrent := a.config.LocalSubrequestsMaxConcurrent
+		if maxConcurrent == 0 {
+			maxConcurrent = uint64(runtime.NumCPU())
+		}
+		opts = append(opts, service.WithLocalTier2(tier2, maxConcurrent))
+	}
+
 	var wasmModules map[string]string
 	if a.config.WASMExtensions != nil {
 		wasmModules = a.config.WASMExtensions.Params()
@@ -24,6 +24,8 @@ type Tier2Config struct {
 
 	MaximumConcurrentRequests uint64
 	WASMExtensions            wasm.WASMExtensioner
+	WASMRuntime               string // name of a registered wasm runtime, defaults to `wasm.DefaultRuntime` when empty
+	WASMInstanceSnapshots     bool   // reuse wasm instances across blocks, restoring their memory between executions
 
 	Tracing bool
 }
@@ -77,6 +79,14 @@ func (a *Tier2App) Run() error {
 		opts = append(opts, service.WithWASMExtensioner(a.config.WASMExtensions))
 	}
 
+	if a.config.WASMRuntime != "" {
+		opts = append(opts, service.WithWASMRuntime(a.config.WASMRuntime))
+	}
+
+	if a.config.WASMInstanceSnapshots {
+		opts = append(opts, service.WithWASMInstanceSnapshots())
+	}
+
 	svc, err := service.NewTier2(
 		a.logger,
 		opts...,
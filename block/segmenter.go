@@ -19,6 +19,10 @@ func NewSegmenter(interval uint64, initialBlock uint64, exclusiveEndBlock uint64
 	return s
 }
 
+func (s *Segmenter) Interval() uint64 {
+	return s.interval
+}
+
 func (s *Segmenter) InitialBlock() uint64 {
 	return s.initialBlock
 }
@@ -80,6 +84,17 @@ func (s *Segmenter) followingRange(idx int) *Range {
 	return NewRange(baseBlock, min(upperBound, s.exclusiveEndBlock))
 }
 
+// JobRange returns the range covering `segments` segments starting at segment `idx`,
+// stopping at the last segment. It returns nil if `idx` is out of range.
+func (s *Segmenter) JobRange(idx int, segments int) *Range {
+	first := s.Range(idx)
+	if first == nil {
+		return nil
+	}
+	lastIdx := min(idx+max(segments, 1)-1, s.LastIndex())
+	return NewRange(first.StartBlock, s.Range(lastIdx).ExclusiveEndBlock)
+}
+
 func (s *Segmenter) IndexForStartBlock(blockNum uint64) int {
 	return int(blockNum / s.interval)
 }
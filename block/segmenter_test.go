@@ -135,3 +135,13 @@ func TestSegmenter_Range(t *testing.T) {
 	assert.True(t, s.EndsOnInterval(1))
 
 }
+
+func TestSegmenter_JobRange(t *testing.T) {
+	s := NewSegmenter(10, 12, 45)
+	assert.Equal(t, NewRange(12, 20), s.JobRange(1, 1))
+	assert.Equal(t, NewRange(12, 40), s.JobRange(1, 3))
+	assert.Equal(t, NewRange(20, 45), s.JobRange(2, 5), "stops at the last segment")
+	assert.Equal(t, NewRange(30, 40), s.JobRange(3, 0), "at least one segment")
+	assert.Nil(t, s.JobRange(0, 2))
+	assert.Nil(t, s.JobRange(5, 2))
+}
@@ -36,6 +36,8 @@ type SubstreamsClientConfig struct {
 	authType  AuthType
 	insecure  bool
 	plaintext bool
+
+	acceptedOutputEncodings []string
 }
 
 func (c *SubstreamsClientConfig) Endpoint() string {
@@ -58,6 +60,18 @@ func (c *SubstreamsClientConfig) AuthType() AuthType {
 	return c.authType
 }
 
+// AcceptedOutputEncodings returns the output encodings set on the requests not setting any.
+func (c *SubstreamsClientConfig) AcceptedOutputEncodings() []string {
+	return c.acceptedOutputEncodings
+}
+
+// SetAcceptedOutputEncodings opts into compressed outputs: the Blocks requests not setting
+// any accepted output encodings are sent with `encodings`. Compressed outputs are always
+// decoded by the client.
+func (c *SubstreamsClientConfig) SetAcceptedOutputEncodings(encodings []string) {
+	c.acceptedOutputEncodings = encodings
+}
+
 func (c *SubstreamsClientConfig) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
 	encoder.AddString("client_endpoint", c.endpoint)
 	encoder.AddBool("client_plaintext", c.plaintext)
@@ -171,8 +185,32 @@ func NewSubstreamsInternalClient(config *SubstreamsClientConfig) (cli pbssintern
 }
 
 func NewSubstreamsClient(config *SubstreamsClientConfig) (cli pbsubstreamsrpc.StreamClient, closeFunc func() error, callOpts []grpc.CallOption, headers Headers, err error) {
+	conn, callOpts, headers, err := newExternalConn(config)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	zlog.Debug("creating new client", zap.String("endpoint", config.endpoint))
+	cli = &decodingStreamClient{
+		StreamClient:     pbsubstreamsrpc.NewStreamClient(conn),
+		defaultEncodings: config.acceptedOutputEncodings,
+	}
+	zlog.Debug("client created")
+	return cli, conn.Close, callOpts, headers, nil
+}
+
+// NewChecksumsClient returns a client of the `sf.substreams.rpc.v2.Checksums` service.
+func NewChecksumsClient(config *SubstreamsClientConfig) (cli pbsubstreamsrpc.ChecksumsClient, closeFunc func() error, callOpts []grpc.CallOption, headers Headers, err error) {
+	conn, callOpts, headers, err := newExternalConn(config)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return pbsubstreamsrpc.NewChecksumsClient(conn), conn.Close, callOpts, headers, nil
+}
+
+func newExternalConn(config *SubstreamsClientConfig) (conn *grpc.ClientConn, callOpts []grpc.CallOption, headers Headers, err error) {
 	if config == nil {
-		return nil, nil, nil, nil, fmt.Errorf("substreams client config not set")
+		return nil, nil, nil, fmt.Errorf("substreams client config not set")
 	}
 	endpoint := config.endpoint
 	authToken := config.authToken
@@ -181,7 +219,7 @@ func NewSubstreamsClient(config *SubstreamsClientConfig) (cli pbsubstreamsrpc.St
 	useInsecureTLSConnection := config.insecure
 
 	if !portSuffixRegex.MatchString(endpoint) {
-		return nil, nil, nil, nil, fmt.Errorf("invalid endpoint %q: endpoint's suffix must be a valid port in the form ':<port>', port 443 is usually the right one to use", endpoint)
+		return nil, nil, nil, fmt.Errorf("invalid endpoint %q: endpoint's suffix must be a valid port in the form ':<port>', port 443 is usually the right one to use", endpoint)
 	}
 
 	bootStrapFilename := os.Getenv("GRPC_XDS_BOOTSTRAP")
@@ -192,12 +230,12 @@ func NewSubstreamsClient(config *SubstreamsClientConfig) (cli pbsubstreamsrpc.St
 		log.Println("Using xDS credentials...")
 		creds, err := xdscreds.NewClientCredentials(xdscreds.ClientOptions{FallbackCreds: insecure.NewCredentials()})
 		if err != nil {
-			return nil, nil, nil, nil, fmt.Errorf("failed to create xDS credentials: %v", err)
+			return nil, nil, nil, fmt.Errorf("failed to create xDS credentials: %v", err)
 		}
 		dialOptions = append(dialOptions, grpc.WithTransportCredentials(creds))
 	} else {
 		if useInsecureTLSConnection && usePlainTextConnection {
-			return nil, nil, nil, nil, fmt.Errorf("option --insecure and --plaintext are mutually exclusive, they cannot be both specified at the same time")
+			return nil, nil, nil, fmt.Errorf("option --insecure and --plaintext are mutually exclusive, they cannot be both specified at the same time")
 		}
 		switch {
 		case usePlainTextConnection:
@@ -215,11 +253,10 @@ func NewSubstreamsClient(config *SubstreamsClientConfig) (cli pbsubstreamsrpc.St
 	dialOptions = append(dialOptions, grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
 
 	zlog.Debug("getting connection", zap.String("endpoint", endpoint))
-	conn, err := dgrpc.NewExternalClient(endpoint, dialOptions...)
+	conn, err = dgrpc.NewExternalClient(endpoint, dialOptions...)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("unable to create external gRPC client: %w", err)
+		return nil, nil, nil, fmt.Errorf("unable to create external gRPC client: %w", err)
 	}
-	closeFunc = conn.Close
 
 	if !skipAuth {
 		if authType == JWT {
@@ -232,8 +269,5 @@ func NewSubstreamsClient(config *SubstreamsClientConfig) (cli pbsubstreamsrpc.St
 		}
 	}
 
-	zlog.Debug("creating new client", zap.String("endpoint", endpoint))
-	cli = pbsubstreamsrpc.NewStreamClient(conn)
-	zlog.Debug("client created")
-	return
+	return conn, callOpts, headers, nil
 }
@@ -4,6 +4,10 @@ type Headers map[string]string
 
 const ApiKeyHeader = "x-api-key"
 
+// ProfileModulesHeader holds the comma-separated names of the modules to profile,
+// honored by tier1 in development mode only.
+const ProfileModulesHeader = "X-Sf-Substreams-Profile-Modules"
+
 func (h Headers) Append(headers map[string]string) map[string]string {
 	for key, value := range headers {
 		h[key] = value
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/streamingfast/substreams/outputencoding"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+)
+
+// decodingStreamClient accepts the `defaultEncodings` on requests not setting any
+// output encodings, and decodes the outputs received, so callers only see plain outputs.
+type decodingStreamClient struct {
+	pbsubstreamsrpc.StreamClient
+	defaultEncodings []string
+}
+
+func (c *decodingStreamClient) Blocks(ctx context.Context, in *pbsubstreamsrpc.Request, opts ...grpc.CallOption) (pbsubstreamsrpc.Stream_BlocksClient, error) {
+	if len(in.AcceptedOutputEncodings) == 0 && len(c.defaultEncodings) != 0 {
+		in = proto.Clone(in).(*pbsubstreamsrpc.Request)
+		in.AcceptedOutputEncodings = c.defaultEncodings
+	}
+
+	stream, err := c.StreamClient.Blocks(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &decodingBlocksClient{Stream_BlocksClient: stream, decoder: outputencoding.NewDecoder()}, nil
+}
+
+type decodingBlocksClient struct {
+	pbsubstreamsrpc.Stream_BlocksClient
+	decoder *outputencoding.Decoder
+}
+
+func (c *decodingBlocksClient) Recv() (*pbsubstreamsrpc.Response, error) {
+	for {
+		resp, err := c.Stream_BlocksClient.Recv()
+		if err != nil {
+			return nil, err
+		}
+
+		consumed, err := c.decoder.DecodeResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+		if !consumed {
+			return resp, nil
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/streamingfast/substreams/outputencoding"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+)
+
+type recordingStreamClient struct {
+	pbsubstreamsrpc.StreamClient
+	requests []*pbsubstreamsrpc.Request
+}
+
+func (c *recordingStreamClient) Blocks(ctx context.Context, in *pbsubstreamsrpc.Request, opts ...grpc.CallOption) (pbsubstreamsrpc.Stream_BlocksClient, error) {
+	c.requests = append(c.requests, in)
+	return nil, nil
+}
+
+func TestDecodingStreamClient_Blocks(t *testing.T) {
+	tests := []struct {
+		name             string
+		defaultEncodings []string
+		requestEncodings []string
+		expectEncodings  []string
+	}{
+		{"opted out", nil, nil, nil},
+		{"opted in", outputencoding.Supported, nil, outputencoding.Supported},
+		{"request encodings kept", outputencoding.Supported, []string{outputencoding.Gzip}, []string{outputencoding.Gzip}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := &recordingStreamClient{}
+			cli := &decodingStreamClient{StreamClient: recorder, defaultEncodings: test.defaultEncodings}
+
+			req := &pbsubstreamsrpc.Request{StartBlockNum: 10, AcceptedOutputEncodings: test.requestEncodings}
+			_, err := cli.Blocks(context.Background(), req)
+			require.NoError(t, err)
+
+			require.Len(t, recorder.requests, 1)
+			assert.Equal(t, test.expectEncodings, recorder.requests[0].AcceptedOutputEncodings)
+			assert.Equal(t, int64(10), recorder.requests[0].StartBlockNum)
+			assert.Equal(t, test.requestEncodings, req.AcceptedOutputEncodings, "caller's request must not be modified")
+		})
+	}
+}
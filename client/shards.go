@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/streamingfast/substreams/block"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+)
+
+// ShardResponsesBuffer is the number of responses buffered for each shard streaming ahead
+// of the shard being returned. A full buffer pauses the shard's stream.
+const ShardResponsesBuffer = 1000
+
+// SplitShards splits [startBlock, stopBlock) in up to `count` contiguous ranges of about
+// the same size, with boundaries aligned on `alignment` so each shard starts on a store
+// snapshot, except for the first one which starts at `startBlock`.
+func SplitShards(startBlock, stopBlock, alignment uint64, count int) []*block.Range {
+	if count < 1 {
+		count = 1
+	}
+	if alignment == 0 {
+		alignment = 1
+	}
+
+	size := (stopBlock - startBlock + uint64(count) - 1) / uint64(count)
+	size = (size + alignment - 1) / alignment * alignment
+
+	var out []*block.Range
+	start := startBlock
+	for start < stopBlock {
+		end := (start + size) / alignment * alignment
+		if end > stopBlock || len(out) == count-1 {
+			end = stopBlock
+		}
+		out = append(out, block.NewRange(start, end))
+		start = end
+	}
+	return out
+}
+
+// ShardedStream streams the shards of a request concurrently and returns their responses
+// in block order: all the responses of a shard are returned before the ones of the next
+// shard. The shards are spread over the `clients` in turn.
+type ShardedStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	shards []*shardStream
+	next   int
+
+	errLock sync.Mutex
+	err     error
+}
+
+type shardStream struct {
+	responses chan *pbsubstreamsrpc.Response
+}
+
+// NewShardedStream starts streaming `req` split in `shards`, see SplitShards. The request must
+// have a positive start block, a stop block and no cursor. All the shards but the last one
+// only stream final blocks.
+func NewShardedStream(ctx context.Context, clients []pbsubstreamsrpc.StreamClient, req *pbsubstreamsrpc.Request, shards []*block.Range, opts ...grpc.CallOption) (*ShardedStream, error) {
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("no client to stream shards from")
+	}
+	if req.StartCursor != "" {
+		return nil, fmt.Errorf("cannot shard a request starting from a cursor")
+	}
+	if req.StartBlockNum < 0 || req.StopBlockNum == 0 {
+		return nil, fmt.Errorf("cannot shard a request without an absolute start block and a stop block")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &ShardedStream{ctx: ctx, cancel: cancel}
+	for i, shard := range shards {
+		shardReq := proto.Clone(req).(*pbsubstreamsrpc.Request)
+		shardReq.StartBlockNum = int64(shard.StartBlock)
+		shardReq.StopBlockNum = shard.ExclusiveEndBlock
+		if i < len(shards)-1 {
+			shardReq.FinalBlocksOnly = true
+		}
+
+		stream := &shardStream{responses: make(chan *pbsubstreamsrpc.Response, ShardResponsesBuffer)}
+		s.shards = append(s.shards, stream)
+		go s.run(ctx, clients[i%len(clients)], shardReq, stream, i > 0, opts)
+	}
+	return s, nil
+}
+
+func (s *ShardedStream) run(ctx context.Context, client pbsubstreamsrpc.StreamClient, req *pbsubstreamsrpc.Request, stream *shardStream, dropSession bool, opts []grpc.CallOption) {
+	defer close(stream.responses)
+
+	cli, err := client.Blocks(ctx, req, opts...)
+	if err != nil {
+		s.fail(fmt.Errorf("shard [%d, %d): %w", req.StartBlockNum, req.StopBlockNum, err))
+		return
+	}
+	for {
+		resp, err := cli.Recv()
+		if err != nil {
+			if err != io.EOF {
+				s.fail(fmt.Errorf("shard [%d, %d): %w", req.StartBlockNum, req.StopBlockNum, err))
+			}
+			return
+		}
+		if dropSession && resp.GetSession() != nil {
+			continue
+		}
+
+		select {
+		case stream.responses <- resp:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// fail keeps the first error, the cancellation of the other shards causes errors of their own.
+func (s *ShardedStream) fail(err error) {
+	s.errLock.Lock()
+	defer s.errLock.Unlock()
+	if s.err == nil {
+		s.err = err
+		s.cancel()
+	}
+}
+
+func (s *ShardedStream) getErr() error {
+	s.errLock.Lock()
+	defer s.errLock.Unlock()
+	return s.err
+}
+
+// Recv returns the next response in block order, io.EOF once all the shards are streamed, or
+// the first error of any shard.
+func (s *ShardedStream) Recv() (*pbsubstreamsrpc.Response, error) {
+	for s.next < len(s.shards) {
+		resp, ok := <-s.shards[s.next].responses
+		if ok {
+			return resp, nil
+		}
+		if err := s.getErr(); err != nil {
+			return nil, err
+		}
+		if err := s.ctx.Err(); err != nil {
+			return nil, err
+		}
+		s.next++
+	}
+	s.cancel()
+	return nil, io.EOF
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func TestSplitShards(t *testing.T) {
+	tests := []struct {
+		start, stop, alignment uint64
+		count                  int
+		expect                 string
+	}{
+		{0, 100, 10, 2, "[0, 50) [50, 100)"},
+		{5, 100, 10, 3, "[5, 40) [40, 80) [80, 100)"},
+		{0, 100, 10, 3, "[0, 40) [40, 80) [80, 100)"},
+		{0, 15, 10, 4, "[0, 10) [10, 15)"},
+		{0, 100, 1000, 4, "[0, 100)"},
+		{1005, 3500, 1000, 8, "[1005, 2000) [2000, 3000) [3000, 3500)"},
+		{0, 100, 10, 0, "[0, 100)"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d-%d/%d/%d", test.start, test.stop, test.alignment, test.count), func(t *testing.T) {
+			var shards []string
+			for _, shard := range SplitShards(test.start, test.stop, test.alignment, test.count) {
+				shards = append(shards, shard.String())
+			}
+			assert.Equal(t, test.expect, strings.Join(shards, " "))
+		})
+	}
+}
+
+func TestShardedStream(t *testing.T) {
+	clients := []pbsubstreamsrpc.StreamClient{&testShardClient{}, &testShardClient{}}
+	req := &pbsubstreamsrpc.Request{StartBlockNum: 5, StopBlockNum: 40, OutputModule: "map_a"}
+
+	stream, err := NewShardedStream(context.Background(), clients, req, SplitShards(5, 40, 10, 3))
+	require.NoError(t, err)
+
+	var sessions int
+	var blocks []uint64
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if resp.GetSession() != nil {
+			sessions++
+		}
+		if data := resp.GetBlockScopedData(); data != nil {
+			blocks = append(blocks, data.Clock.Number)
+		}
+	}
+
+	assert.Equal(t, 1, sessions)
+	require.Len(t, blocks, 35)
+	for i, num := range blocks {
+		assert.Equal(t, uint64(5+i), num)
+	}
+
+	_, err = NewShardedStream(context.Background(), clients, &pbsubstreamsrpc.Request{StartCursor: "abc", StopBlockNum: 10}, nil)
+	assert.Error(t, err)
+}
+
+func TestShardedStream_Error(t *testing.T) {
+	clients := []pbsubstreamsrpc.StreamClient{&testShardClient{failAt: 25}}
+	req := &pbsubstreamsrpc.Request{StartBlockNum: 0, StopBlockNum: 30}
+
+	stream, err := NewShardedStream(context.Background(), clients, req, SplitShards(0, 30, 10, 3))
+	require.NoError(t, err)
+
+	for {
+		_, err = stream.Recv()
+		if err != nil {
+			break
+		}
+	}
+	assert.ErrorContains(t, err, "shard [20, 30): failed at block 25")
+}
+
+type testShardClient struct {
+	failAt uint64
+}
+
+func (c *testShardClient) Blocks(ctx context.Context, in *pbsubstreamsrpc.Request, opts ...grpc.CallOption) (pbsubstreamsrpc.Stream_BlocksClient, error) {
+	responses := []*pbsubstreamsrpc.Response{{Message: &pbsubstreamsrpc.Response_Session{Session: &pbsubstreamsrpc.SessionInit{}}}}
+	for num := uint64(in.StartBlockNum); num < in.StopBlockNum; num++ {
+		responses = append(responses, &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockScopedData{
+			BlockScopedData: &pbsubstreamsrpc.BlockScopedData{Clock: &pbsubstreams.Clock{Number: num}},
+		}})
+	}
+	return &testShardBlocksClient{responses: responses, failAt: c.failAt}, nil
+}
+
+func (c *testShardClient) Plan(ctx context.Context, in *pbsubstreamsrpc.Request, opts ...grpc.CallOption) (*pbsubstreamsrpc.PlanResponse, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (c *testShardClient) Backfill(ctx context.Context, in *pbsubstreamsrpc.BackfillRequest, opts ...grpc.CallOption) (pbsubstreamsrpc.Stream_BackfillClient, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+type testShardBlocksClient struct {
+	grpc.ClientStream
+	responses []*pbsubstreamsrpc.Response
+	failAt    uint64
+}
+
+func (c *testShardBlocksClient) Recv() (*pbsubstreamsrpc.Response, error) {
+	if len(c.responses) == 0 {
+		return nil, io.EOF
+	}
+	resp := c.responses[0]
+	c.responses = c.responses[1:]
+	if data := resp.GetBlockScopedData(); data != nil && c.failAt != 0 && data.Clock.Number == c.failAt {
+		return nil, fmt.Errorf("failed at block %d", c.failAt)
+	}
+	return resp, nil
+}
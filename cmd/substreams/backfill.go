@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/cli"
+	"github.com/streamingfast/cli/sflags"
+
+	"github.com/streamingfast/substreams/client"
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/tools"
+)
+
+// backfillProgressInterval is the minimal interval between two printed progress reports
+const backfillProgressInterval = 5 * time.Second
+
+func init() {
+	backfillCmd.Flags().StringP("substreams-endpoint", "e", "", "Substreams gRPC endpoint. If empty, will be replaced by the SUBSTREAMS_ENDPOINT_{network_name} environment variable, where `network_name` is determined from the substreams manifest. Some network names have default endpoints.")
+	backfillCmd.Flags().String("substreams-api-token-envvar", "SUBSTREAMS_API_TOKEN", "name of variable containing Substreams Authentication token")
+	backfillCmd.Flags().String("substreams-api-key-envvar", "SUBSTREAMS_API_KEY", "Name of variable containing Substreams Api Key")
+	backfillCmd.Flags().String("network", "", "Specify the network to use for params and initialBlocks, overriding the 'network' field in the substreams package")
+	backfillCmd.Flags().Uint64P("start-block", "s", 0, "Block from which the outputs of the module are cached, defaults to its initial block")
+	backfillCmd.Flags().Uint64P("stop-block", "t", 0, "Exclusive block up to which the stores and outputs are cached, must be final")
+	backfillCmd.Flags().Bool("insecure", false, "Skip certificate validation on GRPC connection")
+	backfillCmd.Flags().Bool("plaintext", false, "Establish GRPC connection in plaintext")
+	backfillCmd.Flags().StringSliceP("header", "H", nil, "Additional headers to be sent in the substreams request")
+	backfillCmd.Flags().Bool("skip-package-validation", false, "Do not perform any validation when reading substreams package")
+	backfillCmd.Flags().StringArrayP("params", "p", nil, "Set a params for parameterizable modules. Can be specified multiple times. Ex: -p module1=valA -p module2=valX&valY")
+
+	rootCmd.AddCommand(backfillCmd)
+}
+
+var backfillCmd = &cobra.Command{
+	Use:   "backfill [<manifest>] <module_name>",
+	Short: "Cache the stores and outputs of a module on a remote endpoint, up to a final block",
+	Long: cli.Dedent(`
+		Cache the stores and outputs of a module on a remote endpoint, up to a final block, without streaming
+		the outputs: the command prints the progress of the processing and returns once it is done. Use it to
+		build the stores of a new module version before switching sinks over to it.
+	`),
+	RunE:         runBackfill,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+}
+
+func runBackfill(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	var manifestPath, outputModule string
+	if len(args) == 1 {
+		outputModule = args[0]
+	} else {
+		manifestPath = args[0]
+		outputModule = args[1]
+	}
+
+	stopBlock := mustGetUint64(cmd, "stop-block")
+	if stopBlock == 0 {
+		return fmt.Errorf("missing --stop-block")
+	}
+
+	params, err := manifest.ParseParams(sflags.MustGetStringArray(cmd, "params"))
+	if err != nil {
+		return fmt.Errorf("parsing params: %w", err)
+	}
+	readerOptions := []manifest.Option{
+		manifest.WithOverrideOutputModule(outputModule),
+		manifest.WithOverrideNetwork(sflags.MustGetString(cmd, "network")),
+		manifest.WithParams(params),
+	}
+	if sflags.MustGetBool(cmd, "skip-package-validation") {
+		readerOptions = append(readerOptions, manifest.SkipPackageValidationReader())
+	}
+
+	manifestReader, err := manifest.NewReader(manifestPath, readerOptions...)
+	if err != nil {
+		return fmt.Errorf("manifest reader: %w", err)
+	}
+	pkg, _, err := manifestReader.Read()
+	if err != nil {
+		return fmt.Errorf("read manifest %q: %w", manifestPath, err)
+	}
+
+	endpoint, err := manifest.ExtractNetworkEndpoint(pkg.Network, mustGetString(cmd, "substreams-endpoint"), zlog)
+	if err != nil {
+		return fmt.Errorf("extracting endpoint: %w", err)
+	}
+
+	authToken, authType := tools.GetAuth(cmd, "substreams-api-key-envvar", "substreams-api-token-envvar")
+	ssClient, connClose, callOpts, headers, err := client.NewSubstreamsClient(client.NewSubstreamsClientConfig(
+		endpoint,
+		authToken,
+		authType,
+		mustGetBool(cmd, "insecure"),
+		mustGetBool(cmd, "plaintext"),
+	))
+	if err != nil {
+		return fmt.Errorf("substreams client setup: %w", err)
+	}
+	defer connClose()
+
+	ctx = withRequestHeaders(ctx, cmd, headers)
+
+	req := &pbsubstreamsrpc.BackfillRequest{
+		Modules:       pkg.Modules,
+		OutputModule:  outputModule,
+		StartBlockNum: mustGetUint64(cmd, "start-block"),
+		StopBlockNum:  stopBlock,
+	}
+	stream, err := ssClient.Backfill(ctx, req, callOpts...)
+	if err != nil {
+		return fmt.Errorf("call sf.substreams.rpc.v2.Stream/Backfill: %w", err)
+	}
+
+	start := time.Now()
+	var lastPrint time.Time
+	var lastProgress *pbsubstreamsrpc.ModulesProgress
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				if lastProgress != nil {
+					printBackfillProgress(lastProgress)
+				}
+				fmt.Printf("Backfill of %s up to block %d completed in %s\n", outputModule, stopBlock, time.Since(start).Round(time.Second))
+				return nil
+			}
+			return err
+		}
+
+		switch msg := resp.Message.(type) {
+		case *pbsubstreamsrpc.Response_Session:
+			fmt.Printf("Backfilling %s from block %d up to block %d (trace ID %s, %d parallel workers)\n", outputModule, msg.Session.ResolvedStartBlock, stopBlock, msg.Session.TraceId, msg.Session.MaxParallelWorkers)
+		case *pbsubstreamsrpc.Response_Progress:
+			lastProgress = msg.Progress
+			if time.Since(lastPrint) >= backfillProgressInterval {
+				printBackfillProgress(msg.Progress)
+				lastPrint = time.Now()
+			}
+		}
+	}
+}
+
+func printBackfillProgress(progress *pbsubstreamsrpc.ModulesProgress) {
+	runningJobs := make(map[uint32]int)
+	for _, job := range progress.RunningJobs {
+		runningJobs[job.Stage]++
+	}
+	for i, stage := range progress.Stages {
+		var completed uint64
+		for _, rng := range stage.CompletedRanges {
+			completed += rng.EndBlock - rng.StartBlock
+		}
+		fmt.Printf("  stage %d (%s): %d blocks completed, %d jobs running\n", i, strings.Join(stage.Modules, ", "), completed, runningJobs[uint32(i)])
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -84,6 +85,13 @@ func mustGetInt64(cmd *cobra.Command, flagName string) int64 {
 	}
 	return val
 }
+func mustGetInt(cmd *cobra.Command, flagName string) int {
+	val, err := cmd.Flags().GetInt(flagName)
+	if err != nil {
+		panic(fmt.Sprintf("flags: couldn't find flag %q", flagName))
+	}
+	return val
+}
 func mustGetUint64(cmd *cobra.Command, flagName string) uint64 {
 	val, err := cmd.Flags().GetUint64(flagName)
 	if err != nil {
@@ -91,6 +99,13 @@ func mustGetUint64(cmd *cobra.Command, flagName string) uint64 {
 	}
 	return val
 }
+func mustGetUint32(cmd *cobra.Command, flagName string) uint32 {
+	val, err := cmd.Flags().GetUint32(flagName)
+	if err != nil {
+		panic(fmt.Sprintf("flags: couldn't find flag %q", flagName))
+	}
+	return val
+}
 func mustGetBool(cmd *cobra.Command, flagName string) bool {
 	val, err := cmd.Flags().GetBool(flagName)
 	if err != nil {
@@ -98,6 +113,13 @@ func mustGetBool(cmd *cobra.Command, flagName string) bool {
 	}
 	return val
 }
+func mustGetDuration(cmd *cobra.Command, flagName string) time.Duration {
+	val, err := cmd.Flags().GetDuration(flagName)
+	if err != nil {
+		panic(fmt.Sprintf("flags: couldn't find flag %q", flagName))
+	}
+	return val
+}
 
 func maybeGetString(cmd *cobra.Command, flagName string) string {
 	val, _ := cmd.Flags().GetString(flagName)
@@ -32,6 +32,7 @@ func init() {
 	guiCmd.Flags().Bool("final-blocks-only", false, "Only process blocks that have pass finality, to prevent any reorg and undo signal by staying further away from the chain HEAD")
 	guiCmd.Flags().StringSlice("debug-modules-initial-snapshot", nil, "List of 'store' modules from which to print the initial data snapshot (Unavailable in Production Mode")
 	guiCmd.Flags().StringSlice("debug-modules-output", nil, "List of extra modules from which to print outputs, deltas and logs (Unavailable in Production Mode)")
+	guiCmd.Flags().String("log-level", "", "Minimum level (trace, debug, info, warn, error) of module logs to display, can be changed in the GUI with 'V'")
 	guiCmd.Flags().Bool("production-mode", false, "Enable Production Mode, with high-speed parallel processing")
 	guiCmd.Flags().StringArrayP("params", "p", nil, "Set a params for parameterizable modules. Can be specified multiple times. Ex: -p module1=valA -p module2=valX&valY")
 	guiCmd.Flags().Bool("replay", false, "Replay saved session into GUI from replay.bin")
@@ -81,6 +82,11 @@ func runGui(cmd *cobra.Command, args []string) error {
 	}
 	debugModulesInitialSnapshot := mustGetStringSlice(cmd, "debug-modules-initial-snapshot")
 
+	minLogLevel, err := readLogLevelFlag(cmd, "log-level")
+	if err != nil {
+		return fmt.Errorf("log level: %w", err)
+	}
+
 	outputModule := args[0]
 	network := sflags.MustGetString(cmd, "network")
 	paramsString := sflags.MustGetStringArray(cmd, "params")
@@ -179,6 +185,7 @@ func runGui(cmd *cobra.Command, args []string) error {
 		FinalBlocksOnly:             mustGetBool(cmd, "final-blocks-only"),
 		Params:                      params,
 		ReaderOptions:               readerOptions,
+		MinLogLevel:                 minLogLevel,
 	}
 
 	ui, err := tui2.New(requestConfig)
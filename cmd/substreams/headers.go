@@ -1,10 +1,28 @@
 package main
 
 import (
+	"context"
 	"log"
 	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/streamingfast/substreams/client"
 )
 
+// withRequestHeaders returns `ctx` sending the authorization `headers` of the client and
+// the additional headers of the `--header` flag of `cmd` along the substreams request.
+func withRequestHeaders(ctx context.Context, cmd *cobra.Command, headers client.Headers) context.Context {
+	if headers.IsSet() {
+		ctx = metadata.AppendToOutgoingContext(ctx, headers.ToArray()...)
+	}
+	for k, v := range parseHeaders(mustGetStringSlice(cmd, "header")) {
+		ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+	}
+	return ctx
+}
+
 // util to parse headers flags
 func parseHeaders(headers []string) map[string]string {
 	if headers == nil {
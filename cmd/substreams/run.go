@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/streamingfast/cli"
 	"github.com/streamingfast/cli/sflags"
 	"github.com/streamingfast/substreams/client"
 	"github.com/streamingfast/substreams/manifest"
+	"github.com/streamingfast/substreams/outputencoding"
 	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 	"github.com/streamingfast/substreams/tools"
 	"github.com/streamingfast/substreams/tools/test"
@@ -30,10 +32,23 @@ func init() {
 	runCmd.Flags().Bool("insecure", false, "Skip certificate validation on GRPC connection")
 	runCmd.Flags().Bool("plaintext", false, "Establish GRPC connection in plaintext")
 	runCmd.Flags().StringP("output", "o", "", "Output mode. Defaults to 'ui' when in a TTY is present, and 'json' otherwise")
+	runCmd.Flags().StringSlice("additional-output-modules", nil, "List of 'map' modules to stream along the output module, in a single request (Available in Production Mode)")
+	runCmd.Flags().String("output-filter", "", "CEL expression evaluated on the output module's output, bound to 'output', only blocks where it is true are sent. Ex: 'size(output.transfers) > 0'")
+	runCmd.Flags().Bool("skip-empty-outputs", false, "Do not send the blocks where the output modules produced no output, heartbeats with the block's cursor are sent instead at '--heartbeat-interval'")
+	runCmd.Flags().Duration("heartbeat-interval", 0, "Interval without any block sent after which a block skipped by '--output-filter' or '--skip-empty-outputs' is sent as a heartbeat, defaults to 10s on the server")
+	runCmd.Flags().Bool("undo-outputs", false, "Signal reorgs with one undo message per reverted block, carrying the outputs of the reverted block")
+	runCmd.Flags().Bool("compress-outputs", false, "Accept the outputs compressed with zstd or gzip, they are decoded by the client. Reduces the bandwidth used for large outputs")
+	runCmd.Flags().Uint32("batch-max-blocks", 0, "Group up to this many final blocks per message when streaming cached outputs (Available in Production Mode)")
 	runCmd.Flags().StringSlice("debug-modules-initial-snapshot", nil, "List of 'store' modules from which to print the initial data snapshot (Unavailable in Production Mode)")
 	runCmd.Flags().StringSlice("debug-modules-output", nil, "List of modules from which to print outputs, deltas and logs (Unavailable in Production Mode)")
+	runCmd.Flags().String("log-level", "", "Minimum level (trace, debug, info, warn, error) of module logs to print. In 'json' and 'jsonl' output modes, module logs are only printed when this flag is set")
 	runCmd.Flags().StringSliceP("header", "H", nil, "Additional headers to be sent in the substreams request")
+	runCmd.Flags().StringSlice("profile-modules", nil, "List of modules of which to profile the WASM execution (Unavailable in Production Mode). Profiles are written to '<module>.pprof' when the stop block is reached, open them with 'go tool pprof'")
+	runCmd.Flags().Int("shards", 1, "Split the block range in this many shards streamed concurrently, and print their outputs in order. Requires a start block and a stop block, shards other than the last one only stream final blocks")
+	runCmd.Flags().StringSlice("shard-endpoints", nil, "Additional endpoints to spread the shards over, along the '--substreams-endpoint', with the same authentication")
+	runCmd.Flags().Uint64("shard-alignment", 1000, "Align the shard boundaries on multiples of this many blocks, match it with the server's segment size so each shard starts on a store snapshot")
 	runCmd.Flags().Bool("production-mode", false, "Enable Production Mode, with high-speed parallel processing")
+	runCmd.Flags().Bool("plan-only", false, "Print the work the request would schedule on the server, from the segments already cached per stage, without processing anything")
 	runCmd.Flags().Bool("skip-package-validation", false, "Do not perform any validation when reading substreams package")
 	runCmd.Flags().StringArrayP("params", "p", nil, "Set a params for parameterizable modules. Can be specified multiple times. Ex: -p module1=valA -p module2=valX&valY")
 	runCmd.Flags().String("test-file", "", "runs a test file")
@@ -127,6 +142,16 @@ func runRun(cmd *cobra.Command, args []string) error {
 
 	debugModulesInitialSnapshot := mustGetStringSlice(cmd, "debug-modules-initial-snapshot")
 
+	profileModules := mustGetStringSlice(cmd, "profile-modules")
+	if profileModules != nil && productionMode {
+		return fmt.Errorf("cannot set 'profile-modules' in 'production-mode'")
+	}
+
+	minLogLevel, err := readLogLevelFlag(cmd, "log-level")
+	if err != nil {
+		return fmt.Errorf("log level: %w", err)
+	}
+
 	startBlock, readFromModule, err := readStartBlockFlag(cmd, "start-block")
 	if err != nil {
 		return fmt.Errorf("stop block: %w", err)
@@ -148,6 +173,9 @@ func runRun(cmd *cobra.Command, args []string) error {
 		mustGetBool(cmd, "insecure"),
 		mustGetBool(cmd, "plaintext"),
 	)
+	if mustGetBool(cmd, "compress-outputs") {
+		substreamsClientConfig.SetAcceptedOutputEncodings(outputencoding.Supported)
+	}
 
 	ssClient, connClose, callOpts, headers, err := client.NewSubstreamsClient(substreamsClientConfig)
 	if err != nil {
@@ -155,6 +183,27 @@ func runRun(cmd *cobra.Command, args []string) error {
 	}
 	defer connClose()
 
+	ssClients := []pbsubstreamsrpc.StreamClient{ssClient}
+	shards := mustGetInt(cmd, "shards")
+	if shards > 1 {
+		for _, shardEndpoint := range mustGetStringSlice(cmd, "shard-endpoints") {
+			shardClientConfig := client.NewSubstreamsClientConfig(
+				shardEndpoint,
+				authToken,
+				authType,
+				mustGetBool(cmd, "insecure"),
+				mustGetBool(cmd, "plaintext"),
+			)
+			shardClientConfig.SetAcceptedOutputEncodings(substreamsClientConfig.AcceptedOutputEncodings())
+			shardClient, shardConnClose, _, _, err := client.NewSubstreamsClient(shardClientConfig)
+			if err != nil {
+				return fmt.Errorf("substreams client setup for shard endpoint %q: %w", shardEndpoint, err)
+			}
+			defer shardConnClose()
+			ssClients = append(ssClients, shardClient)
+		}
+	}
+
 	cursorStr := mustGetString(cmd, "cursor")
 
 	stopBlock, err := readStopBlockFlag(cmd, startBlock, "stop-block", cursorStr != "")
@@ -169,25 +218,54 @@ func runRun(cmd *cobra.Command, args []string) error {
 		FinalBlocksOnly:                     mustGetBool(cmd, "final-blocks-only"),
 		Modules:                             pkg.Modules,
 		OutputModule:                        outputModule,
+		AdditionalOutputModules:             mustGetStringSlice(cmd, "additional-output-modules"),
 		ProductionMode:                      productionMode,
 		DebugInitialStoreSnapshotForModules: debugModulesInitialSnapshot,
+		SkipEmptyOutputs:                    mustGetBool(cmd, "skip-empty-outputs"),
+		HeartbeatIntervalSeconds:            uint32(mustGetDuration(cmd, "heartbeat-interval").Seconds()),
+		BatchMaxBlocks:                      mustGetUint32(cmd, "batch-max-blocks"),
+		UndoOutputs:                         mustGetBool(cmd, "undo-outputs"),
+	}
+
+	if outputFilter := mustGetString(cmd, "output-filter"); outputFilter != "" {
+		req.OutputFilter = &pbsubstreamsrpc.OutputFilter{
+			Expression: outputFilter,
+			ProtoFiles: pkg.ProtoFiles,
+		}
 	}
 
 	if err := req.Validate(); err != nil {
 		return fmt.Errorf("validate request: %w", err)
 	}
+
+	reqCtx := withRequestHeaders(ctx, cmd, headers)
+	if profileModules != nil {
+		reqCtx = metadata.AppendToOutgoingContext(reqCtx, client.ProfileModulesHeader, strings.Join(profileModules, ","))
+	}
+
+	if mustGetBool(cmd, "plan-only") {
+		resp, err := ssClient.Plan(reqCtx, req, callOpts...)
+		if err != nil {
+			return fmt.Errorf("call sf.substreams.rpc.v2.Stream/Plan: %w", err)
+		}
+		printPlan(resp)
+		return nil
+	}
+
 	toPrint := debugModulesOutput
 	if toPrint == nil {
 		toPrint = []string{outputModule}
 	}
+	toPrint = append(toPrint, req.AdditionalOutputModules...)
 
 	ui := tui.New(req, pkg, toPrint)
+	ui.SetMinLogLevel(minLogLevel)
 	if err := ui.Init(outputMode); err != nil {
 		return fmt.Errorf("TUI initialization: %w", err)
 	}
 	defer ui.CleanUpTerminal()
 
-	streamCtx, cancel := context.WithCancel(ctx)
+	streamCtx, cancel := context.WithCancel(reqCtx)
 	ui.OnTerminated(func(err error) {
 		if err != nil {
 			fmt.Printf("UI terminated with error %q\n", err)
@@ -197,26 +275,21 @@ func runRun(cmd *cobra.Command, args []string) error {
 	})
 	defer cancel()
 
-	// add additional authorization headers
-	if headers.IsSet() {
-		streamCtx = metadata.AppendToOutgoingContext(streamCtx, headers.ToArray()...)
-	}
-	//parse additional-headers flag
-	additionalHeaders := mustGetStringSlice(cmd, "header")
-	if additionalHeaders != nil {
-		res := parseHeaders(additionalHeaders)
-		headerArray := make([]string, 0, len(res)*2)
-		for k, v := range res {
-			headerArray = append(headerArray, k, v)
-		}
-		streamCtx = metadata.AppendToOutgoingContext(streamCtx, headerArray...)
-	}
-
 	ui.SetRequest(req)
 	ui.Connecting()
-	cli, err := ssClient.Blocks(streamCtx, req, callOpts...)
-	if err != nil && streamCtx.Err() != context.Canceled {
-		return fmt.Errorf("call sf.substreams.rpc.v2.Stream/Blocks: %w", err)
+	var cli interface {
+		Recv() (*pbsubstreamsrpc.Response, error)
+	}
+	if shards > 1 {
+		cli, err = client.NewShardedStream(streamCtx, ssClients, req, client.SplitShards(uint64(req.StartBlockNum), req.StopBlockNum, mustGetUint64(cmd, "shard-alignment"), shards), callOpts...)
+		if err != nil {
+			return fmt.Errorf("sharding request: %w", err)
+		}
+	} else {
+		cli, err = ssClient.Blocks(streamCtx, req, callOpts...)
+		if err != nil && streamCtx.Err() != context.Canceled {
+			return fmt.Errorf("call sf.substreams.rpc.v2.Stream/Blocks: %w", err)
+		}
 	}
 	ui.Connected()
 
@@ -249,3 +322,30 @@ func runRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 }
+
+func printPlan(resp *pbsubstreamsrpc.PlanResponse) {
+	stopBlock := "live"
+	if resp.StopBlock != 0 {
+		stopBlock = fmt.Sprintf("%d", resp.StopBlock)
+	}
+	fmt.Printf("Resolved start block: %d, linear handoff block: %d, stop block: %s\n", resp.ResolvedStartBlock, resp.LinearHandoffBlock, stopBlock)
+	fmt.Printf("Segment size: %d, max parallel workers: %d\n", resp.SegmentSize, resp.MaxParallelWorkers)
+
+	if len(resp.Stages) == 0 {
+		fmt.Println("No parallel processing needed")
+	}
+	for i, stage := range resp.Stages {
+		kind := "map"
+		if stage.IsStore {
+			kind = "store"
+		}
+		fmt.Printf("Stage %d (%s: %s): %d segments, %d cached, %d partial, %d jobs over %d blocks\n",
+			i, kind, strings.Join(stage.Modules, ", "), stage.Segments, stage.CachedSegments, stage.PartialSegments, stage.Jobs, stage.Blocks)
+	}
+
+	fmt.Printf("Total: %d tier2 jobs over %d blocks", resp.Tier2Jobs, resp.Tier2Blocks)
+	if resp.StopBlock != 0 {
+		fmt.Printf(", then %d blocks processed linearly", resp.LinearBlocks)
+	}
+	fmt.Println()
+}
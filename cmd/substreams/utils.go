@@ -5,8 +5,22 @@ import (
 	"github.com/spf13/cobra"
 	"strconv"
 	"strings"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 )
 
+func readLogLevelFlag(cmd *cobra.Command, flagName string) (pbsubstreamsrpc.LogLevel, error) {
+	val, err := cmd.Flags().GetString(flagName)
+	if err != nil {
+		panic(fmt.Sprintf("flags: couldn't find flag %q", flagName))
+	}
+	if val == "" {
+		return pbsubstreamsrpc.LogLevel_LOG_LEVEL_UNSPECIFIED, nil
+	}
+
+	return pbsubstreamsrpc.ParseLogLevel(val)
+}
+
 func readStartBlockFlag(cmd *cobra.Command, flagName string) (int64, bool, error) {
 	val, err := cmd.Flags().GetString(flagName)
 	if err != nil {
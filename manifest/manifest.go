@@ -94,9 +94,10 @@ type Module struct {
 	ValueType    string `yaml:"valueType,omitempty"`
 	Binary       string `yaml:"binary,omitempty"`
 
-	Inputs []*Input     `yaml:"inputs,omitempty"`
-	Output StreamOutput `yaml:"output,omitempty"`
-	Use    string       `yaml:"use,omitempty"`
+	Inputs  []*Input       `yaml:"inputs,omitempty"`
+	Output  StreamOutput   `yaml:"output,omitempty"`
+	Outputs []*NamedOutput `yaml:"outputs,omitempty"`
+	Use     string         `yaml:"use,omitempty"`
 }
 
 type BlockFilter struct {
@@ -127,6 +128,22 @@ type StreamOutput struct {
 	Type string `yaml:"type,omitempty"`
 }
 
+// NamedOutput is one of the outputs of a map module declaring several of them.
+// It is addressed by downstream modules as `map: <module>.<name>`.
+type NamedOutput struct {
+	Name string `yaml:"name,omitempty"`
+	Type string `yaml:"type,omitempty"`
+}
+
+// MapOutputsType is the output type of map modules declaring named outputs.
+const MapOutputsType = "proto:sf.substreams.v1.MapOutputs"
+
+// NamedOutputModuleName returns the name of the module generated for the named
+// output `outputName` of the multi-output module `moduleName`.
+func NamedOutputModuleName(moduleName, outputName string) string {
+	return moduleName + "." + outputName
+}
+
 func decodeYamlManifestFromFile(yamlFilePath, workingDir string) (out *Manifest, err error) {
 	//if yamlFilePath is a relative path, make it absolute
 	if !filepath.IsAbs(yamlFilePath) {
@@ -404,10 +421,20 @@ const (
 func (m *Module) setKindToProto(pbModule *pbsubstreams.Module) {
 	switch m.Kind {
 	case ModuleKindMap:
+		kindMap := &pbsubstreams.Module_KindMap{
+			OutputType: m.Output.Type,
+		}
+		if len(m.Outputs) != 0 {
+			kindMap.OutputType = MapOutputsType
+			for _, output := range m.Outputs {
+				kindMap.NamedOutputs = append(kindMap.NamedOutputs, &pbsubstreams.Module_KindMap_NamedOutput{
+					Name: output.Name,
+					Type: output.Type,
+				})
+			}
+		}
 		pbModule.Kind = &pbsubstreams.Module_KindMap_{
-			KindMap: &pbsubstreams.Module_KindMap{
-				OutputType: m.Output.Type,
-			},
+			KindMap: kindMap,
 		}
 	case ModuleKindStore:
 		var updatePolicy pbsubstreams.Module_KindStore_UpdatePolicy
@@ -437,9 +464,69 @@ func (m *Module) setKindToProto(pbModule *pbsubstreams.Module) {
 }
 
 func (m *Module) setOutputToProto(pbModule *pbsubstreams.Module) {
+	if len(m.Outputs) != 0 {
+		pbModule.Output = &pbsubstreams.Module_Output{
+			Type: MapOutputsType,
+		}
+		return
+	}
+
 	if m.Output.Type != "" {
 		pbModule.Output = &pbsubstreams.Module_Output{
 			Type: m.Output.Type,
 		}
 	}
 }
+
+// NamedOutputsToProto returns the modules generated for each named output of
+// the multi-output map module `parent`, in declaration order. They share the
+// parent's binary but are never executed through it: their output is extracted
+// from the parent's `MapOutputs`. The entrypoint is set to the generated name so
+// that each of them hashes, and is therefore cached, separately.
+func (m *Module) NamedOutputsToProto(parent *pbsubstreams.Module) []*pbsubstreams.Module {
+	var out []*pbsubstreams.Module
+	for _, output := range m.Outputs {
+		name := NamedOutputModuleName(parent.Name, output.Name)
+		out = append(out, &pbsubstreams.Module{
+			Name:             name,
+			BinaryIndex:      parent.BinaryIndex,
+			BinaryEntrypoint: name,
+			InitialBlock:     parent.InitialBlock,
+			Kind: &pbsubstreams.Module_KindMap_{
+				KindMap: &pbsubstreams.Module_KindMap{
+					OutputType:    output.Type,
+					NamedOutputOf: parent.Name,
+				},
+			},
+			Inputs: []*pbsubstreams.Module_Input{
+				{Input: &pbsubstreams.Module_Input_Map_{Map: &pbsubstreams.Module_Input_Map{ModuleName: parent.Name}}},
+			},
+			Output: &pbsubstreams.Module_Output{
+				Type: output.Type,
+			},
+		})
+	}
+	return out
+}
+
+func validateNamedOutputs(module *Module) error {
+	if module.Output.Type != "" {
+		return fmt.Errorf("'output.type' and 'outputs' cannot be both set")
+	}
+
+	seen := map[string]bool{}
+	for idx, output := range module.Outputs {
+		if !moduleNameRegexp.MatchString(output.Name) {
+			return fmt.Errorf("outputs[%d]: name %q does not match regex %s", idx, output.Name, moduleNameRegexp.String())
+		}
+		if seen[output.Name] {
+			return fmt.Errorf("outputs[%d]: duplicate output name %q", idx, output.Name)
+		}
+		seen[output.Name] = true
+
+		if output.Type == "" {
+			return fmt.Errorf("outputs[%d]: missing 'type' for output %q", idx, output.Name)
+		}
+	}
+	return nil
+}
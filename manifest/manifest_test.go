@@ -211,3 +211,31 @@ func TestManifest_ToProto(t *testing.T) {
 //func (x *testSinkConfig) String() string                     { return "testSinkConfig" }
 //func (*testSinkConfig) ProtoMessage()                        {}
 //func (x *testSinkConfig) ProtoReflect() protoreflect.Message { panic("unimplemented") }
+
+func TestModule_NamedOutputsToProto(t *testing.T) {
+	mod := &Module{
+		Name:    "events",
+		Kind:    ModuleKindMap,
+		Inputs:  []*Input{{Source: "sf.ethereum.type.v2.Block"}},
+		Outputs: []*NamedOutput{{"transfers", "proto:test.Transfers"}, {"approvals", "proto:test.Approvals"}},
+	}
+
+	parent, err := mod.ToProtoWASM(2)
+	require.NoError(t, err)
+	assert.Equal(t, MapOutputsType, parent.Output.Type)
+	assert.Equal(t, MapOutputsType, parent.GetKindMap().OutputType)
+	require.Len(t, parent.GetKindMap().NamedOutputs, 2)
+	assert.Equal(t, "transfers", parent.GetKindMap().NamedOutputs[0].Name)
+
+	generated := mod.NamedOutputsToProto(parent)
+	require.Len(t, generated, 2)
+	assert.Equal(t, "events.transfers", generated[0].Name)
+	assert.Equal(t, "events.transfers", generated[0].BinaryEntrypoint)
+	assert.Equal(t, uint32(2), generated[0].BinaryIndex)
+	assert.Equal(t, "events", generated[0].GetKindMap().NamedOutputOf)
+	assert.Equal(t, "proto:test.Transfers", generated[0].Output.Type)
+	assert.Equal(t, "events", generated[0].Inputs[0].GetMap().ModuleName)
+	assert.Equal(t, "events.approvals", generated[1].Name)
+
+	require.NoError(t, ValidateModules(&pbsubstreams.Modules{Modules: append([]*pbsubstreams.Module{parent}, generated...)}))
+}
@@ -67,7 +67,11 @@ func (r *manifestConverter) validateManifest(manif *Manifest) error {
 
 		switch s.Kind {
 		case ModuleKindMap:
-			if s.Output.Type == "" {
+			if len(s.Outputs) != 0 {
+				if err := validateNamedOutputs(s); err != nil {
+					return fmt.Errorf("stream %q: %w", s.Name, err)
+				}
+			} else if s.Output.Type == "" {
 				return fmt.Errorf("stream %q: missing 'output.type' for kind 'map'", s.Name)
 			}
 			if s.Use != "" {
@@ -371,6 +375,13 @@ func (r *manifestConverter) convertToPkg(m *Manifest) (pkg *pbsubstreams.Package
 
 		pkg.ModuleMeta = append(pkg.ModuleMeta, pbmeta)
 		pkg.Modules.Modules = append(pkg.Modules.Modules, pbmod)
+
+		for _, namedOutputMod := range mod.NamedOutputsToProto(pbmod) {
+			pkg.ModuleMeta = append(pkg.ModuleMeta, &pbsubstreams.ModuleMetadata{
+				Doc: fmt.Sprintf("Named output of module %q", pbmod.Name),
+			})
+			pkg.Modules.Modules = append(pkg.Modules.Modules, namedOutputMod)
+		}
 	}
 
 	for modName, paramValue := range m.Params {
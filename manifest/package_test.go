@@ -242,6 +242,36 @@ func TestValidateManifest(t *testing.T) {
 			},
 			expectedError: "stream \"basic_index\": block index module cannot have block filter",
 		},
+		{
+			name: "map with named outputs",
+			manifest: &Manifest{
+				SpecVersion: "v0.1.0",
+				Modules: []*Module{
+					{Name: "events", Kind: "map", Inputs: []*Input{{Source: "sf.ethereum.type.v2.Block"}}, Outputs: []*NamedOutput{{"transfers", "proto:test.Transfers"}, {"approvals", "proto:test.Approvals"}}},
+				},
+			},
+			expectedError: "",
+		},
+		{
+			name: "map with both output and named outputs",
+			manifest: &Manifest{
+				SpecVersion: "v0.1.0",
+				Modules: []*Module{
+					{Name: "events", Kind: "map", Inputs: []*Input{{Source: "sf.ethereum.type.v2.Block"}}, Output: StreamOutput{"proto:test.Events"}, Outputs: []*NamedOutput{{"transfers", "proto:test.Transfers"}}},
+				},
+			},
+			expectedError: "stream \"events\": 'output.type' and 'outputs' cannot be both set",
+		},
+		{
+			name: "map with duplicate named outputs",
+			manifest: &Manifest{
+				SpecVersion: "v0.1.0",
+				Modules: []*Module{
+					{Name: "events", Kind: "map", Inputs: []*Input{{Source: "sf.ethereum.type.v2.Block"}}, Outputs: []*NamedOutput{{"transfers", "proto:test.Transfers"}, {"transfers", "proto:test.Approvals"}}},
+				},
+			},
+			expectedError: "stream \"events\": outputs[1]: duplicate output name \"transfers\"",
+		},
 	}
 
 	manifestConv := newManifestConverter("test", true)
@@ -383,6 +413,31 @@ func TestValidateModules(t *testing.T) {
 			},
 			expectedError: "checking block filter for module \"test_module\": block filter module \"map_module\" not of 'block_index' kind",
 		},
+		{
+			name:    "named outputs",
+			modules: namedOutputsModules(func(mods []*pbsubstreams.Module) {}),
+		},
+		{
+			name: "named output not declared by parent",
+			modules: namedOutputsModules(func(mods []*pbsubstreams.Module) {
+				mods[1].Name = "events.approvals"
+			}),
+			expectedError: "checking named output module \"events.approvals\": parent module \"events\" does not declare output \"approvals\"",
+		},
+		{
+			name: "named output with other type than declared",
+			modules: namedOutputsModules(func(mods []*pbsubstreams.Module) {
+				mods[1].GetKindMap().OutputType = "proto:test.Approvals"
+			}),
+			expectedError: "checking named output module \"events.transfers\": output type \"proto:test.Approvals\" differs from the one declared by parent module \"events\": \"proto:test.Transfers\"",
+		},
+		{
+			name: "dotted name on regular module",
+			modules: namedOutputsModules(func(mods []*pbsubstreams.Module) {
+				mods[1].GetKindMap().NamedOutputOf = ""
+			}),
+			expectedError: "module \"events.transfers\": segment \"events.transfers\" does not match regex ^([a-zA-Z][a-zA-Z0-9_]{0,63})$",
+		},
 	}
 
 	for _, c := range cases {
@@ -397,3 +452,38 @@ func TestValidateModules(t *testing.T) {
 		})
 	}
 }
+
+func namedOutputsModules(mutate func(mods []*pbsubstreams.Module)) *pbsubstreams.Modules {
+	mods := []*pbsubstreams.Module{
+		{
+			Name:             "events",
+			BinaryEntrypoint: "events",
+			Kind: &pbsubstreams.Module_KindMap_{
+				KindMap: &pbsubstreams.Module_KindMap{
+					OutputType:   MapOutputsType,
+					NamedOutputs: []*pbsubstreams.Module_KindMap_NamedOutput{{Name: "transfers", Type: "proto:test.Transfers"}},
+				},
+			},
+			Inputs: []*pbsubstreams.Module_Input{
+				{Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "sf.database.v1.changes"}}},
+			},
+			Output: &pbsubstreams.Module_Output{Type: MapOutputsType},
+		},
+		{
+			Name:             "events.transfers",
+			BinaryEntrypoint: "events.transfers",
+			Kind: &pbsubstreams.Module_KindMap_{
+				KindMap: &pbsubstreams.Module_KindMap{
+					OutputType:    "proto:test.Transfers",
+					NamedOutputOf: "events",
+				},
+			},
+			Inputs: []*pbsubstreams.Module_Input{
+				{Input: &pbsubstreams.Module_Input_Map_{Map: &pbsubstreams.Module_Input_Map{ModuleName: "events"}}},
+			},
+			Output: &pbsubstreams.Module_Output{Type: "proto:test.Transfers"},
+		},
+	}
+	mutate(mods)
+	return &pbsubstreams.Modules{Modules: mods}
+}
@@ -591,6 +591,48 @@ func checkValidInputs(mod *pbsubstreams.Module, mapModuleKind map[string]pbsubst
 	return nil
 }
 
+// checkValidNamedOutput ensures a module generated for a named output is
+// exactly what the manifest conversion produces, since it is executed natively
+// instead of through its binary.
+func checkValidNamedOutput(mod *pbsubstreams.Module, modulesByName map[string]*pbsubstreams.Module) error {
+	parentName := mod.GetKindMap().NamedOutputOf
+	outputName, found := strings.CutPrefix(mod.Name, parentName+".")
+	if !found {
+		return fmt.Errorf("name must be the parent name %q followed by '.' and the output name", parentName)
+	}
+	if !moduleNameRegexp.MatchString(outputName) {
+		return fmt.Errorf("output name %q does not match regex %s", outputName, moduleNameRegexp.String())
+	}
+
+	parent, found := modulesByName[parentName]
+	if !found {
+		return fmt.Errorf("parent module %q not found", parentName)
+	}
+
+	var declared *pbsubstreams.Module_KindMap_NamedOutput
+	for _, output := range parent.GetKindMap().GetNamedOutputs() {
+		if output.Name == outputName {
+			declared = output
+			break
+		}
+	}
+	if declared == nil {
+		return fmt.Errorf("parent module %q does not declare output %q", parentName, outputName)
+	}
+	if declared.Type != mod.GetKindMap().OutputType {
+		return fmt.Errorf("output type %q differs from the one declared by parent module %q: %q", mod.GetKindMap().OutputType, parentName, declared.Type)
+	}
+
+	if len(mod.Inputs) != 1 || mod.Inputs[0].GetMap().GetModuleName() != parentName {
+		return fmt.Errorf("must have parent module %q as its only input", parentName)
+	}
+	if mod.BlockFilter != nil {
+		return fmt.Errorf("cannot have a block filter")
+	}
+
+	return nil
+}
+
 // ValidateModules is run both by the client _and_ the server.
 func ValidateModules(mods *pbsubstreams.Modules) error {
 	var sumCode int
@@ -610,15 +652,25 @@ func ValidateModules(mods *pbsubstreams.Modules) error {
 	}
 
 	mapModuleKind := make(map[string]pbsubstreams.ModuleKind)
+	modulesByName := make(map[string]*pbsubstreams.Module)
 	for _, mod := range mods.Modules {
 		if _, found := mapModuleKind[mod.Name]; found {
 			return fmt.Errorf("module %q: duplicate module name", mod.Name)
 		}
 		mapModuleKind[mod.Name] = mod.ModuleKind()
+		modulesByName[mod.Name] = mod
 	}
 
 	for _, mod := range mods.Modules {
-		for _, segment := range strings.Split(mod.Name, ":") {
+		name := mod.Name
+		if parent := mod.GetKindMap().GetNamedOutputOf(); parent != "" {
+			if err := checkValidNamedOutput(mod, modulesByName); err != nil {
+				return fmt.Errorf("checking named output module %q: %w", mod.Name, err)
+			}
+			name = parent
+		}
+
+		for _, segment := range strings.Split(name, ":") {
 			if !moduleNameRegexp.MatchString(segment) {
 				return fmt.Errorf("module %q: segment %q does not match regex %s", mod.Name, segment, moduleNameRegexp.String())
 			}
@@ -677,7 +729,11 @@ func LoadManifestFile(inputPath, workingDir string) (*Manifest, error) {
 
 		switch s.Kind {
 		case ModuleKindMap:
-			if s.Output.Type == "" {
+			if len(s.Outputs) != 0 {
+				if err := validateNamedOutputs(s); err != nil {
+					return nil, fmt.Errorf("stream %q: %w", s.Name, err)
+				}
+			} else if s.Output.Type == "" {
 				return nil, fmt.Errorf("stream %q: missing 'output.type' for kind 'map'", s.Name)
 			}
 		case ModuleKindStore:
@@ -763,6 +819,9 @@ func withPrefix(val, prefix string) string {
 func prefixModules(mods []*pbsubstreams.Module, prefix string) {
 	for _, mod := range mods {
 		mod.Name = withPrefix(mod.Name, prefix)
+		if kindMap := mod.GetKindMap(); kindMap != nil && kindMap.NamedOutputOf != "" {
+			kindMap.NamedOutputOf = withPrefix(kindMap.NamedOutputOf, prefix)
+		}
 		for idx, inputIface := range mod.Inputs {
 			switch input := inputIface.Input.(type) {
 			case *pbsubstreams.Module_Input_Source_:
@@ -23,6 +23,11 @@ var Tier1ActiveWorkerRequest = MetricSet.NewGauge("substreams_tier1_active_worke
 var Tier1WorkerRequestCounter = MetricSet.NewCounter("substreams_tier1_worker_request_counter", "Counter for total Substreams worker requests a tier1 app made against tier2 nodes")
 var Tier1WorkerRetryCounter = MetricSet.NewCounter("substreams_tier1_worker_retry_counter", "Counter for total retryable errors returned from tier2")
 var Tier1WorkerRejectedOverloadedCounter = MetricSet.NewCounter("substreams_tier1_worker_rejected_overloaded_counter", "Counter for number of times a worker rejected a request because it was overloaded (included in RetryCounter)")
+var Tier1DeduplicatedJobsCounter = MetricSet.NewCounter("substreams_tier1_deduplicated_jobs_counter", "Counter for jobs not sent to tier2 because an identical job of another request was processed")
+var Tier1SpeculativeJobsCounter = MetricSet.NewCounter("substreams_tier1_speculative_jobs_counter", "Counter for duplicate jobs sent to tier2 because the original job was much slower than the others of its stage")
+var Tier1TenantQueuedJobs = MetricSet.NewGaugeVec("substreams_tier1_tenant_queued_jobs", []string{"tenant"}, "Number of jobs of a tenant waiting for their fair share of the jobs sent to tier2")
+var Tier1TenantRunningJobs = MetricSet.NewGaugeVec("substreams_tier1_tenant_running_jobs", []string{"tenant"}, "Number of jobs of a tenant currently sent to tier2, when sharing jobs between tenants")
+var Tier1TenantJobWaitDuration = MetricSet.NewHistogramVec("substreams_tier1_tenant_job_wait_duration", []string{"tenant"}, "Time the jobs of a tenant waited for their fair share of the jobs sent to tier2")
 
 var Tier2ActiveRequests = MetricSet.NewGauge("substreams_tier2_active_requests", "Number of active Substreams requests the tier2 is currently serving")
 var Tier2RequestCounter = MetricSet.NewCounter("substreams_tier2_request_counter", "Counter for total Substreams requests the tier2 served")
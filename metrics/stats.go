@@ -30,6 +30,9 @@ type Stats struct {
 	runningJobs        runningJobs
 	completedJobsStats map[string]*pbssinternal.ModuleStats
 
+	// completedJobsBlockDurations holds, per stage, the duration per block of the completed jobs
+	completedJobsBlockDurations map[uint32][]time.Duration
+
 	localProcessedBlockCount  uint64
 	completedJobsBytesRead    uint64
 	completedJobsBytesWritten uint64
@@ -141,6 +144,8 @@ func NewReqStats(config *Config, logger *zap.Logger) *Stats {
 		modulesStats:       make(map[string]*extendedStats),
 		runningJobs:        make(map[uint64]*extendedJob),
 		completedJobsStats: make(map[string]*pbssinternal.ModuleStats),
+
+		completedJobsBlockDurations: make(map[uint32][]time.Duration),
 	}
 }
 
@@ -259,7 +264,10 @@ func (s *Stats) RecordModuleMergeComplete(module string) {
 	stat.mergingTime += time.Since(stat.mergeBegin)
 }
 
-func (s *Stats) RecordEndSubrequest(jobIdx uint64) {
+// RecordEndSubrequest records the end of the job of `jobIdx`. Only the jobs that
+// `succeeded` feed MedianJobBlockDuration: failed and canceled jobs, like the losers of
+// a speculative execution, end at any time.
+func (s *Stats) RecordEndSubrequest(jobIdx uint64, succeeded bool) {
 	s.Lock()
 	defer s.Unlock()
 	job := s.runningJobs[jobIdx]
@@ -284,9 +292,27 @@ func (s *Stats) RecordEndSubrequest(jobIdx uint64) {
 	s.completedJobsBytesRead += job.bytesRead
 	s.completedJobsBytesWritten += job.bytesWritten
 
+	if blocks := job.StopBlock - job.StartBlock; succeeded && blocks != 0 {
+		s.completedJobsBlockDurations[job.Stage] = append(s.completedJobsBlockDurations[job.Stage], time.Since(job.start)/time.Duration(blocks))
+	}
+
 	delete(s.runningJobs, jobIdx)
 }
 
+// MedianJobBlockDuration returns the median duration per block of the succeeded jobs of
+// `stage`, along with the number of those jobs.
+func (s *Stats) MedianJobBlockDuration(stage uint32) (median time.Duration, jobs int) {
+	s.Lock()
+	defer s.Unlock()
+
+	durations := append([]time.Duration(nil), s.completedJobsBlockDurations[stage]...)
+	if len(durations) == 0 {
+		return 0, 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return durations[len(durations)/2], len(durations)
+}
+
 // RecordModuleWasmBlockBegin should be called once per module per block
 func (s *Stats) RecordModuleWasmBlockBegin(moduleName string) uint64 {
 	s.Lock()
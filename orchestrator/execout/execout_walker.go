@@ -17,36 +17,68 @@ import (
 	"github.com/streamingfast/substreams/orchestrator/response"
 	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/pipeline/outputfilter"
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/storage/execout"
 	pboutput "github.com/streamingfast/substreams/storage/execout/pb"
+	"github.com/streamingfast/substreams/storage/store"
 )
 
+// Walker streams the cached outputs of the output modules. The first module is
+// the output module, the others are the additional output modules, all files of
+// a segment are merged into a single BlockScopedData per block.
+//
+// When the output module is a store, its cached outputs are the operations of each
+// block, they are applied on the store's full state at the start of the segment to
+// produce the deltas.
+//
+// Blocks skipped because of the request's output filter or `skip_empty_outputs` are
+// sent as heartbeats, when due. Blocks are batched as requested, batches are flushed
+// at the end of each segment.
 type Walker struct {
 	ctx context.Context
 	*block.Range
-	fileWalker *execout.FileWalker
-	streamOut  *response.Stream
-	module     *pbsubstreams.Module
-	logger     *zap.Logger
-	working    bool
+	fileWalkers []*execout.FileWalker // one per module, walking the same segments
+	streamOut   *response.Batch
+	modules     []*pbsubstreams.Module
+	outputStore *store.Config // set when the output module is a store
+	skipper     *outputfilter.Skipper
+	logger      *zap.Logger
+	working     bool
 }
 
 func NewWalker(
 	ctx context.Context,
-	module *pbsubstreams.Module,
-	fileWalker *execout.FileWalker,
+	modules []*pbsubstreams.Module,
+	fileWalkers []*execout.FileWalker,
+	storeConfigs store.ConfigMap,
 	walkRange *block.Range,
 	stream *response.Stream,
+	skipper *outputfilter.Skipper,
 ) *Walker {
+	if len(modules) == 0 || len(modules) != len(fileWalkers) {
+		panic("assertion: walker requires one file walker per module")
+	}
+
+	var outputStore *store.Config
+	if modules[0].GetKindStore() != nil {
+		outputStore = storeConfigs[modules[0].Name]
+		if outputStore == nil {
+			panic(fmt.Sprintf("assertion: missing store config for output module %q", modules[0].Name))
+		}
+	}
+
 	logger := reqctx.Logger(ctx)
+	details := reqctx.Details(ctx)
 	return &Walker{
-		ctx:        ctx,
-		module:     module,
-		fileWalker: fileWalker,
-		Range:      walkRange,
-		streamOut:  stream,
-		logger:     logger,
+		ctx:         ctx,
+		modules:     modules,
+		fileWalkers: fileWalkers,
+		outputStore: outputStore,
+		skipper:     skipper,
+		Range:       walkRange,
+		streamOut:   stream.NewBatch(details.BatchMaxBlocks, details.BatchMaxBytes),
+		logger:      logger,
 	}
 }
 
@@ -63,22 +95,50 @@ func (r *Walker) IsWorking() bool {
 }
 
 func (r *Walker) CmdDownloadCurrentSegment(waitBefore time.Duration) loop.Cmd {
-	file := r.fileWalker.File()
+	files := make([]*execout.File, len(r.fileWalkers))
+	for i, fileWalker := range r.fileWalkers {
+		files[i] = fileWalker.File()
+	}
 
 	return func() loop.Msg {
 		time.Sleep(waitBefore)
 
-		err := file.Load(r.ctx)
-		if errors.Is(err, dstore.ErrNotFound) {
-			return MsgFileNotPresent{NextWait: computeNewWait(waitBefore)}
+		var outputStore *store.FullKV
+		if r.outputStore != nil {
+			fullKV, found, err := r.loadOutputStore(files[0].StartBlock)
+			if err != nil {
+				return loop.NewQuitMsg(err)
+			}
+			if !found {
+				return MsgFileNotPresent{NextWait: computeNewWait(waitBefore)}
+			}
+			outputStore = fullKV
 		}
-		if err != nil {
-			return loop.NewQuitMsg(fmt.Errorf("loading %s cache %q: %w", file.ModuleName, file.Filename(), err))
+
+		for _, file := range files {
+			err := file.Load(r.ctx)
+			if errors.Is(err, dstore.ErrNotFound) {
+				return MsgFileNotPresent{NextWait: computeNewWait(waitBefore)}
+			}
+			if err != nil {
+				return loop.NewQuitMsg(fmt.Errorf("loading %s cache %q: %w", file.ModuleName, file.Filename(), err))
+			}
 		}
 
-		if err := r.sendItems(file.SortedItems()); err != nil {
+		additionalItems := make([]map[uint64]*pboutput.Item, len(files)-1)
+		for i, file := range files[1:] {
+			additionalItems[i] = make(map[uint64]*pboutput.Item)
+			for _, item := range file.SortedItems() {
+				additionalItems[i][item.BlockNum] = item
+			}
+		}
+
+		if err := r.sendItems(files[0].SortedItems(), additionalItems, outputStore); err != nil {
 			return loop.NewQuitMsg(err)
 		}
+		if err := r.streamOut.Flush(); err != nil {
+			return loop.NewQuitMsg(fmt.Errorf("calling response func: %w", err))
+		}
 		return MsgFileDownloaded{}
 	}
 }
@@ -94,22 +154,79 @@ func computeNewWait(previousWait time.Duration) time.Duration {
 	return newWait
 }
 
-func (r *Walker) sendItems(sortedItems []*pboutput.Item) error {
+// loadOutputStore returns the full state of the output store module at `startBlock`,
+// found is false when it was not produced yet.
+func (r *Walker) loadOutputStore(startBlock uint64) (fullKV *store.FullKV, found bool, err error) {
+	fullKV = r.outputStore.NewFullKV(r.logger)
+	if startBlock <= r.outputStore.ModuleInitialBlock() {
+		return fullKV, true, nil
+	}
+
+	exists, err := r.outputStore.ExistsFullKV(r.ctx, startBlock)
+	if err != nil {
+		return nil, false, fmt.Errorf("checking %s full store at %d: %w", r.outputStore.Name(), startBlock, err)
+	}
+	if !exists {
+		return nil, false, nil
+	}
+
+	file := store.NewCompleteFileInfo(r.outputStore.Name(), r.outputStore.ModuleInitialBlock(), startBlock)
+	if err := fullKV.Load(r.ctx, file); err != nil {
+		return nil, false, fmt.Errorf("loading %s full store at %d: %w", r.outputStore.Name(), startBlock, err)
+	}
+	return fullKV, true, nil
+}
+
+func (r *Walker) sendItems(sortedItems []*pboutput.Item, additionalItems []map[uint64]*pboutput.Item, outputStore *store.FullKV) error {
 	for _, item := range sortedItems {
 		if item == nil {
 			continue // why would that happen?!
 		}
+
+		var deltas *pbsubstreams.StoreDeltas
+		if outputStore != nil {
+			// operations of blocks prior to the walk range still need to be applied
+			if err := outputStore.ApplyOps(item.Payload); err != nil {
+				return fmt.Errorf("applying %s operations at block %d: %w", outputStore.Name(), item.BlockNum, err)
+			}
+			deltas = &pbsubstreams.StoreDeltas{StoreDeltas: outputStore.GetDeltas()}
+			outputStore.Reset()
+		}
+
 		if item.BlockNum < r.StartBlock {
 			continue
 		}
 
-		blockScopedData, err := toBlockScopedData(r.module, item)
+		blockScopedData, err := toBlockScopedData(r.modules[0], item, deltas)
 		if err != nil {
 			return fmt.Errorf("converting to block scoped data: %w", err)
 		}
 
-		if err = r.streamOut.BlockScopedData(blockScopedData); err != nil {
-			return fmt.Errorf("calling response func: %w", err)
+		for i, module := range r.modules[1:] {
+			additionalItem, found := additionalItems[i][item.BlockNum]
+			if !found {
+				continue
+			}
+			output, err := toModuleOutput(module, additionalItem)
+			if err != nil {
+				return fmt.Errorf("module output: %w", err)
+			}
+			blockScopedData.AdditionalOutputs = append(blockScopedData.AdditionalOutputs, output)
+		}
+
+		skip, heartbeat, err := r.skipper.Skip(r.ctx, blockScopedData, blockScopedData.Clock.Number+1 >= r.ExclusiveEndBlock)
+		if err != nil {
+			return err
+		}
+		if heartbeat != nil {
+			if err = r.streamOut.Heartbeat(heartbeat); err != nil {
+				return fmt.Errorf("calling response func: %w", err)
+			}
+		}
+		if !skip {
+			if err = r.streamOut.BlockScopedData(blockScopedData); err != nil {
+				return fmt.Errorf("calling response func: %w", err)
+			}
 		}
 
 		if blockScopedData.Clock.Number >= r.ExclusiveEndBlock {
@@ -124,18 +241,22 @@ func (r *Walker) sendItems(sortedItems []*pboutput.Item) error {
 }
 
 func (r *Walker) Progress() (first, current, last int) {
-	return r.fileWalker.Progress()
+	return r.fileWalkers[0].Progress()
 }
 
 func (r *Walker) NextSegment() {
-	r.fileWalker.Next()
+	for _, fileWalker := range r.fileWalkers {
+		fileWalker.Next()
+	}
 }
 
 func (r *Walker) IsCompleted() bool {
-	return r.fileWalker.IsDone()
+	return r.fileWalkers[0].IsDone()
 }
 
-func toBlockScopedData(module *pbsubstreams.Module, cacheItem *pboutput.Item) (*pbsubstreamsrpc.BlockScopedData, error) {
+// toBlockScopedData converts a cached output, `deltas` are the ones of the block when
+// `module` is a store.
+func toBlockScopedData(module *pbsubstreams.Module, cacheItem *pboutput.Item, deltas *pbsubstreams.StoreDeltas) (*pbsubstreamsrpc.BlockScopedData, error) {
 	clock := toClock(cacheItem)
 	blockRef := bstream.NewBlockRef(clock.Id, clock.Number)
 	cursor := bstream.Cursor{
@@ -150,6 +271,15 @@ func toBlockScopedData(module *pbsubstreams.Module, cacheItem *pboutput.Item) (*
 		FinalBlockHeight: blockRef.Num(),
 	}
 
+	if deltas != nil {
+		m, err := pbsubstreamsrpc.NewStoreDeltasOutput(module.Name, deltas)
+		if err != nil {
+			return nil, fmt.Errorf("store deltas output: %w", err)
+		}
+		out.Output = m
+		return out, nil
+	}
+
 	m, err := toModuleOutput(module, cacheItem)
 	if err != nil {
 		return nil, fmt.Errorf("module output: %w", err)
@@ -0,0 +1,121 @@
+package execout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/orchestrator/response"
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/pipeline/outputfilter"
+	pboutput "github.com/streamingfast/substreams/storage/execout/pb"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+func TestWalker_sendItems_storeDeltas(t *testing.T) {
+	storeConfig, err := store.NewConfig("store_mod", 0, "abc", pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string", dstore.NewMockStore(nil))
+	require.NoError(t, err)
+
+	var sent []*pbsubstreamsrpc.BlockScopedData
+	walker := &Walker{
+		ctx:         context.Background(),
+		Range:       block.NewRange(2, 10),
+		modules:     []*pbsubstreams.Module{{Name: "store_mod", Kind: &pbsubstreams.Module_KindStore_{KindStore: &pbsubstreams.Module_KindStore{}}}},
+		outputStore: storeConfig,
+		logger:      zap.NewNop(),
+		streamOut: response.New(func(resp substreams.ResponseFromAnyTier) error {
+			sent = append(sent, resp.(*pbsubstreamsrpc.Response).GetBlockScopedData())
+			return nil
+		}).NewBatch(0, 0),
+	}
+
+	items := []*pboutput.Item{
+		testOpsItem(t, 0, &pbssinternal.Operation{Type: pbssinternal.Operation_SET, Ord: 1, Key: "a", Value: []byte("1")}),
+		testOpsItem(t, 1, &pbssinternal.Operation{Type: pbssinternal.Operation_SET, Ord: 1, Key: "a", Value: []byte("2")}),
+		testOpsItem(t, 2,
+			&pbssinternal.Operation{Type: pbssinternal.Operation_SET, Ord: 1, Key: "a", Value: []byte("3")},
+			&pbssinternal.Operation{Type: pbssinternal.Operation_SET, Ord: 2, Key: "b", Value: []byte("1")},
+		),
+		testOpsItem(t, 3, &pbssinternal.Operation{Type: pbssinternal.Operation_DELETE_PREFIX, Ord: 1, Key: "b"}),
+	}
+
+	fullKV, found, err := walker.loadOutputStore(0)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.NoError(t, walker.sendItems(items, nil, fullKV))
+
+	require.Len(t, sent, 2)
+	var deltas [][]*pbsubstreamsrpc.StoreDelta
+	for _, data := range sent {
+		storeOutput, err := data.Output.ToStoreModuleOutput()
+		require.NoError(t, err)
+		assert.Equal(t, "store_mod", storeOutput.Name)
+		deltas = append(deltas, storeOutput.DebugStoreDeltas)
+	}
+
+	assert.Equal(t, uint64(2), sent[0].Clock.Number)
+	assert.Equal(t, []*pbsubstreamsrpc.StoreDelta{
+		{Operation: pbsubstreamsrpc.StoreDelta_UPDATE, Ordinal: 1, Key: "a", OldValue: []byte("2"), NewValue: []byte("3")},
+		{Operation: pbsubstreamsrpc.StoreDelta_CREATE, Ordinal: 2, Key: "b", NewValue: []byte("1")},
+	}, deltas[0])
+
+	assert.Equal(t, uint64(3), sent[1].Clock.Number)
+	assert.Equal(t, []*pbsubstreamsrpc.StoreDelta{
+		{Operation: pbsubstreamsrpc.StoreDelta_DELETE, Ordinal: 1, Key: "b", OldValue: []byte("1")},
+	}, deltas[1])
+}
+
+func TestWalker_sendItems_outputFilter(t *testing.T) {
+	storeConfig, err := store.NewConfig("store_mod", 0, "abc", pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string", dstore.NewMockStore(nil))
+	require.NoError(t, err)
+
+	module := &pbsubstreams.Module{Name: "store_mod", Kind: &pbsubstreams.Module_KindStore_{KindStore: &pbsubstreams.Module_KindStore{}}}
+	filter, err := outputfilter.New(`output.store_deltas.exists(d, d.key == "b")`, module, nil)
+	require.NoError(t, err)
+
+	var sent []uint64
+	walker := &Walker{
+		ctx:         context.Background(),
+		Range:       block.NewRange(0, 10),
+		modules:     []*pbsubstreams.Module{module},
+		outputStore: storeConfig,
+		skipper:     outputfilter.NewSkipper(filter, false, time.Hour),
+		logger:      zap.NewNop(),
+		streamOut: response.New(func(resp substreams.ResponseFromAnyTier) error {
+			sent = append(sent, resp.(*pbsubstreamsrpc.Response).GetBlockScopedData().Clock.Number)
+			return nil
+		}).NewBatch(0, 0),
+	}
+
+	items := []*pboutput.Item{
+		testOpsItem(t, 0, &pbssinternal.Operation{Type: pbssinternal.Operation_SET, Ord: 1, Key: "a", Value: []byte("1")}),
+		testOpsItem(t, 1, &pbssinternal.Operation{Type: pbssinternal.Operation_SET, Ord: 1, Key: "b", Value: []byte("1")}),
+		testOpsItem(t, 2),
+		testOpsItem(t, 3, &pbssinternal.Operation{Type: pbssinternal.Operation_DELETE_PREFIX, Ord: 1, Key: "b"}),
+	}
+
+	fullKV, found, err := walker.loadOutputStore(0)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.NoError(t, walker.sendItems(items, nil, fullKV))
+
+	assert.Equal(t, []uint64{1, 3}, sent)
+}
+
+func testOpsItem(t *testing.T, blockNum uint64, ops ...*pbssinternal.Operation) *pboutput.Item {
+	t.Helper()
+
+	payload, err := proto.Marshal(&pbssinternal.Operations{Operations: ops})
+	require.NoError(t, err)
+	return &pboutput.Item{BlockNum: blockNum, BlockId: "id", Payload: payload}
+}
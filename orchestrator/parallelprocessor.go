@@ -12,6 +12,7 @@ import (
 	"github.com/streamingfast/substreams/orchestrator/scheduler"
 	"github.com/streamingfast/substreams/orchestrator/stage"
 	"github.com/streamingfast/substreams/orchestrator/work"
+	"github.com/streamingfast/substreams/pipeline/outputfilter"
 	"github.com/streamingfast/substreams/pipeline/outputmodules"
 	"github.com/streamingfast/substreams/service/config"
 	"github.com/streamingfast/substreams/storage/execout"
@@ -33,14 +34,90 @@ func BuildParallelProcessor(
 	execoutStorage *execout.Configs,
 	respFunc func(resp substreams.ResponseFromAnyTier) error,
 	storeConfigs store.ConfigMap,
+	outputSkipper *outputfilter.Skipper,
 ) (*ParallelProcessor, error) {
 
 	stream := response.New(respFunc)
 	sched := scheduler.New(ctx, stream)
 
-	stages := stage.NewStages(ctx, outputGraph, reqPlan, storeConfigs)
+	stages, err := buildStages(ctx, reqPlan, runtimeConfig, outputGraph, execoutStorage, storeConfigs, false)
+	if err != nil {
+		return nil, err
+	}
 	sched.Stages = stages
 
+	if reqPlan.ReadExecOut != nil {
+		execOutSegmenter := reqPlan.WriteOutSegmenter()
+		// note: a store output module is walked through the operations cached by its stage
+		outputModules := outputGraph.OutputModules()
+		walkers := make([]*execout.FileWalker, len(outputModules))
+		for i, module := range outputModules {
+			walkers[i] = execoutStorage.NewFileWalker(module.Name, execOutSegmenter)
+		}
+
+		sched.ExecOutWalker = orchestratorExecout.NewWalker(
+			ctx,
+			outputModules,
+			walkers,
+			storeConfigs,
+			reqPlan.ReadExecOut,
+			stream,
+			outputSkipper,
+		)
+	}
+
+	if os.Getenv("SUBSTREAMS_DEBUG_SCHEDULER_STATE") == "true" {
+		fmt.Println("Initial state:")
+		fmt.Print(stages.StatesString())
+	}
+
+	// OPTIMIZE(abourget): take all of the ExecOut files that exist
+	//  and use that to PUSH back what the Stages need to do.
+	//  So the first Segment to process will not necessarily be
+	//  segment == 0.  We'll need the segment JUST prior to be
+	//  processed though, because we need to continue working on
+	//  the future segments.  This interplays with the segment
+	//  just before.
+	//  -
+	//  In other words, if we can stream out the ExecOut directly, we don't need
+	//  to dispatch work to process them at all.  But we'll need
+	//  to have stores ready to continue segments work.
+	//  SO: we can move forward the processing pipeline, provided
+	//  all of the stages can be continued forward after the
+	//  last ExecOut segment: that we have complete stores for the
+	//  segment where ExecOut finishes.
+	//  -
+	//  This is an optimization and is not solved herein.
+
+	workerPool := work.NewWorkerPool(ctx, maxParallelJobs, runtimeConfig.WorkerFactory, runtimeConfig.Tier2Capacity)
+	sched.WorkerPool = workerPool
+	sched.FairShare = runtimeConfig.FairShare
+	sched.StragglerFactor = runtimeConfig.StragglerFactor
+	// outputs written but not read are only cached, see `Pipeline.Backfill`
+	sched.WaitOutputsWritten = reqPlan.WriteExecOut != nil && reqPlan.ReadExecOut == nil
+
+	return &ParallelProcessor{
+		scheduler: sched,
+		reqPlan:   reqPlan,
+	}, nil
+}
+
+// buildStages returns the stages processing `reqPlan`, with the state of their segments
+// fetched from the cache, left untouched when `readOnly`.
+func buildStages(
+	ctx context.Context,
+	reqPlan *plan.RequestPlan,
+	runtimeConfig config.RuntimeConfig,
+	outputGraph *outputmodules.Graph,
+	execoutStorage *execout.Configs,
+	storeConfigs store.ConfigMap,
+	readOnly bool,
+) (*stage.Stages, error) {
+	stages := stage.NewStages(ctx, outputGraph, reqPlan, storeConfigs)
+	if runtimeConfig.TargetJobDuration > 0 {
+		stages.SizeJobs(reqPlan, runtimeConfig.TargetJobDuration, runtimeConfig.MaxJobSegments)
+	}
+
 	// OPTIMIZATION: We should fetch the ExecOut files too, and see if they
 	// cover some of the ranges that we're after.
 	// We don't need to plan work for ranges where we have ExecOut
@@ -64,25 +141,6 @@ func BuildParallelProcessor(
 	// FIXME: Are all the progress messages properly sent? When we skip some stores and mark them complete,
 	// for whatever reason,
 
-	if reqPlan.ReadExecOut != nil {
-		execOutSegmenter := reqPlan.WriteOutSegmenter()
-		// note: since we are *NOT* in a sub-request and are setting up output module is a map
-		requestedModule := outputGraph.OutputModule()
-		if requestedModule.GetKindStore() != nil {
-			panic("logic error: should not get a store as outputModule on tier 1")
-		}
-
-		walker := execoutStorage.NewFileWalker(requestedModule.Name, execOutSegmenter)
-
-		sched.ExecOutWalker = orchestratorExecout.NewWalker(
-			ctx,
-			requestedModule,
-			walker,
-			reqPlan.ReadExecOut,
-			stream,
-		)
-	}
-
 	// we may be here only for mapper, without stores
 	if reqPlan.BuildStores != nil {
 		err := stages.FetchStoresState(
@@ -90,6 +148,7 @@ func BuildParallelProcessor(
 			reqPlan.StoresSegmenter(),
 			storeConfigs,
 			execoutStorage,
+			readOnly,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("fetch stores storage state: %w", err)
@@ -100,43 +159,34 @@ func BuildParallelProcessor(
 			reqPlan.WriteOutSegmenter(),
 			storeConfigs,
 			execoutStorage,
+			readOnly,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("fetch stores storage state: %w", err)
 		}
 
 	}
+	return stages, nil
+}
 
-	if os.Getenv("SUBSTREAMS_DEBUG_SCHEDULER_STATE") == "true" {
-		fmt.Println("Initial state:")
-		fmt.Print(stages.StatesString())
+// PlanStages returns the work the parallel processing of `reqPlan` would schedule on each
+// stage, from the segments already cached, without processing or writing anything.
+func PlanStages(
+	ctx context.Context,
+	reqPlan *plan.RequestPlan,
+	runtimeConfig config.RuntimeConfig,
+	outputGraph *outputmodules.Graph,
+	execoutStorage *execout.Configs,
+	storeConfigs store.ConfigMap,
+) ([]*stage.Plan, error) {
+	if !reqPlan.RequiresParallelProcessing() {
+		return nil, nil
 	}
-
-	// OPTIMIZE(abourget): take all of the ExecOut files that exist
-	//  and use that to PUSH back what the Stages need to do.
-	//  So the first Segment to process will not necessarily be
-	//  segment == 0.  We'll need the segment JUST prior to be
-	//  processed though, because we need to continue working on
-	//  the future segments.  This interplays with the segment
-	//  just before.
-	//  -
-	//  In other words, if we can stream out the ExecOut directly, we don't need
-	//  to dispatch work to process them at all.  But we'll need
-	//  to have stores ready to continue segments work.
-	//  SO: we can move forward the processing pipeline, provided
-	//  all of the stages can be continued forward after the
-	//  last ExecOut segment: that we have complete stores for the
-	//  segment where ExecOut finishes.
-	//  -
-	//  This is an optimization and is not solved herein.
-
-	workerPool := work.NewWorkerPool(ctx, maxParallelJobs, runtimeConfig.WorkerFactory)
-	sched.WorkerPool = workerPool
-
-	return &ParallelProcessor{
-		scheduler: sched,
-		reqPlan:   reqPlan,
-	}, nil
+	stages, err := buildStages(ctx, reqPlan, runtimeConfig, outputGraph, execoutStorage, storeConfigs, true)
+	if err != nil {
+		return nil, err
+	}
+	return stages.Plan(), nil
 }
 
 func (b *ParallelProcessor) Stages() *stage.Stages {
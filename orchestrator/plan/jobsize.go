@@ -0,0 +1,61 @@
+package plan
+
+import (
+	"sync"
+	"time"
+)
+
+// costSmoothing is the weight of the latest job in the per-block cost of its stage.
+const costSmoothing = 0.3
+
+// JobCosts holds the processing time per block measured on the completed jobs of the
+// stages, identified by the hashes of their modules, so the later requests for the same
+// stage size their jobs from it.
+type JobCosts struct {
+	mu       sync.Mutex
+	perBlock map[string]time.Duration
+}
+
+// Costs is the registry shared by all the requests of the process.
+var Costs = NewJobCosts()
+
+func NewJobCosts() *JobCosts {
+	return &JobCosts{perBlock: make(map[string]time.Duration)}
+}
+
+// Record updates the per-block cost of `stage` with a job of `blocks` blocks having taken
+// `duration`, as a moving average of the recent jobs.
+func (c *JobCosts) Record(stage string, blocks uint64, duration time.Duration) {
+	if blocks == 0 {
+		return
+	}
+	cost := duration / time.Duration(blocks)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if previous, found := c.perBlock[stage]; found {
+		cost = time.Duration(costSmoothing*float64(cost) + (1-costSmoothing)*float64(previous))
+	}
+	c.perBlock[stage] = cost
+}
+
+// PerBlock returns the per-block cost of `stage`, `found` is false when no job of it
+// completed yet.
+func (c *JobCosts) PerBlock(stage string) (cost time.Duration, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cost, found = c.perBlock[stage]
+	return
+}
+
+// JobSegments returns the number of segments of the jobs of a stage costing `perBlock`
+// per block, so that they take about `target`, between 1 and `maxSegments`. Jobs of
+// stages without a known cost are a single segment.
+func (p *RequestPlan) JobSegments(perBlock time.Duration, target time.Duration, maxSegments uint64) int {
+	if perBlock <= 0 || target <= 0 || maxSegments <= 1 {
+		return 1
+	}
+	segmentCost := perBlock * time.Duration(p.segmentInterval)
+	segments := uint64(target / segmentCost)
+	return int(min(max(segments, 1), maxSegments))
+}
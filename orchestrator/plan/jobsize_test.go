@@ -0,0 +1,49 @@
+package plan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobCosts(t *testing.T) {
+	costs := NewJobCosts()
+	_, found := costs.PerBlock("a")
+	assert.False(t, found)
+
+	costs.Record("a", 100, time.Second)
+	cost, found := costs.PerBlock("a")
+	assert.True(t, found)
+	assert.Equal(t, 10*time.Millisecond, cost)
+
+	costs.Record("a", 100, 2*time.Second)
+	cost, _ = costs.PerBlock("a")
+	assert.Equal(t, 13*time.Millisecond, cost, "moving average")
+
+	costs.Record("b", 0, time.Second)
+	_, found = costs.PerBlock("b")
+	assert.False(t, found, "empty jobs are ignored")
+}
+
+func TestRequestPlan_JobSegments(t *testing.T) {
+	p := &RequestPlan{segmentInterval: 1000}
+	tests := []struct {
+		name        string
+		perBlock    time.Duration
+		target      time.Duration
+		maxSegments uint64
+		expect      int
+	}{
+		{"unknown cost", 0, time.Minute, 10, 1},
+		{"disabled", time.Millisecond, time.Minute, 1, 1},
+		{"expensive stage", time.Second, time.Minute, 10, 1},
+		{"cheap stage", 10 * time.Millisecond, time.Minute, 10, 6},
+		{"very cheap stage", time.Microsecond, time.Minute, 10, 10},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, p.JobSegments(test.perBlock, test.target, test.maxSegments))
+		})
+	}
+}
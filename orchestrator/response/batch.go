@@ -0,0 +1,78 @@
+package response
+
+import (
+	"google.golang.org/protobuf/proto"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+)
+
+const (
+	DefaultBatchMaxBytes = 1024 * 1024
+
+	// MaxBatchMaxBytes bounds the requested `batch_max_bytes`, keeping batches well
+	// under the gRPC message size limits.
+	MaxBatchMaxBytes = 16 * 1024 * 1024
+)
+
+// Batch groups consecutive BlockScopedData in BlockScopedDatas messages, flushed
+// when `maxBlocks` blocks or `maxBytes` bytes are reached. Other messages sent
+// through the batch flush it first, to keep the ordering.
+//
+// With a `maxBlocks` of 0 or 1, blocks are sent individually.
+type Batch struct {
+	stream    *Stream
+	maxBlocks int
+	maxBytes  int
+
+	items []*pbsubstreamsrpc.BlockScopedData
+	size  int
+}
+
+// NewBatch returns a batch sending to `s`, a zero `maxBytes` uses DefaultBatchMaxBytes
+// and `maxBytes` is clamped to MaxBatchMaxBytes.
+func (s *Stream) NewBatch(maxBlocks, maxBytes uint64) *Batch {
+	if maxBytes == 0 {
+		maxBytes = DefaultBatchMaxBytes
+	}
+	maxBytes = min(maxBytes, MaxBatchMaxBytes)
+	return &Batch{
+		stream:    s,
+		maxBlocks: int(maxBlocks),
+		maxBytes:  int(maxBytes),
+	}
+}
+
+func (b *Batch) BlockScopedData(in *pbsubstreamsrpc.BlockScopedData) error {
+	if b.maxBlocks <= 1 {
+		return b.stream.BlockScopedData(in)
+	}
+
+	b.items = append(b.items, in)
+	b.size += proto.Size(in)
+	if len(b.items) >= b.maxBlocks || b.size >= b.maxBytes {
+		return b.Flush()
+	}
+	return nil
+}
+
+func (b *Batch) Heartbeat(in *pbsubstreamsrpc.Heartbeat) error {
+	if err := b.Flush(); err != nil {
+		return err
+	}
+	return b.stream.Heartbeat(in)
+}
+
+// Flush sends the pending blocks, a single block is sent as a BlockScopedData.
+func (b *Batch) Flush() error {
+	items := b.items
+	b.items = nil
+	b.size = 0
+
+	switch len(items) {
+	case 0:
+		return nil
+	case 1:
+		return b.stream.BlockScopedData(items[0])
+	}
+	return b.stream.BlockScopedDatas(&pbsubstreamsrpc.BlockScopedDatas{Items: items})
+}
@@ -0,0 +1,78 @@
+package response
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/streamingfast/substreams"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func TestBatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxBlocks uint64
+		maxBytes  uint64
+		sends     []string // "b<size>" for a block with an output of <size> bytes, "h" for a heartbeat, "f" for a flush
+		expect    string
+	}{
+		{"no batching", 0, 0, []string{"b1", "b1", "h", "b1"}, "data:0 data:1 heartbeat data:3"},
+		{"max blocks", 2, 0, []string{"b1", "b1", "b1", "b1", "b1", "f"}, "datas:0,1 datas:2,3 data:4"},
+		{"max bytes", 10, 100, []string{"b60", "b60", "b10", "f"}, "datas:0,1 data:2"},
+		{"heartbeat flushes", 10, 0, []string{"b1", "b1", "h", "b1", "f"}, "datas:0,1 heartbeat data:3"},
+		{"empty flush", 10, 0, []string{"f", "f"}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			batch := New(func(resp substreams.ResponseFromAnyTier) error {
+				switch m := resp.(*pbsubstreamsrpc.Response).Message.(type) {
+				case *pbsubstreamsrpc.Response_BlockScopedData:
+					got = append(got, fmt.Sprintf("data:%d", m.BlockScopedData.Clock.Number))
+				case *pbsubstreamsrpc.Response_BlockScopedDatas:
+					var nums []string
+					for _, item := range m.BlockScopedDatas.Items {
+						nums = append(nums, fmt.Sprintf("%d", item.Clock.Number))
+					}
+					got = append(got, "datas:"+strings.Join(nums, ","))
+				case *pbsubstreamsrpc.Response_Heartbeat:
+					got = append(got, "heartbeat")
+				}
+				return nil
+			}).NewBatch(test.maxBlocks, test.maxBytes)
+
+			for i, send := range test.sends {
+				switch send[0] {
+				case 'b':
+					var size int
+					_, err := fmt.Sscanf(send[1:], "%d", &size)
+					require.NoError(t, err)
+					require.NoError(t, batch.BlockScopedData(&pbsubstreamsrpc.BlockScopedData{
+						Clock:  &pbsubstreams.Clock{Number: uint64(i)},
+						Output: &pbsubstreamsrpc.MapModuleOutput{MapOutput: &anypb.Any{Value: make([]byte, size)}},
+					}))
+				case 'h':
+					require.NoError(t, batch.Heartbeat(&pbsubstreamsrpc.Heartbeat{}))
+				case 'f':
+					require.NoError(t, batch.Flush())
+				}
+			}
+			assert.Equal(t, test.expect, strings.Join(got, " "))
+		})
+	}
+}
+
+func TestNewBatch_MaxBytes(t *testing.T) {
+	stream := New(func(substreams.ResponseFromAnyTier) error { return nil })
+
+	assert.Equal(t, DefaultBatchMaxBytes, stream.NewBatch(10, 0).maxBytes)
+	assert.Equal(t, 100, stream.NewBatch(10, 100).maxBytes)
+	assert.Equal(t, MaxBatchMaxBytes, stream.NewBatch(10, 1<<40).maxBytes)
+}
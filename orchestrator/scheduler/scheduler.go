@@ -2,12 +2,14 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/streamingfast/substreams/block"
 	"github.com/streamingfast/substreams/metrics"
 	"github.com/streamingfast/substreams/orchestrator/execout"
 	"github.com/streamingfast/substreams/orchestrator/loop"
@@ -27,6 +29,27 @@ type Scheduler struct {
 	Stages        *stage.Stages
 	WorkerPool    *work.WorkerPool
 	ExecOutWalker *execout.Walker
+	FairShare     *work.FairShare // shares the jobs of all requests between their tenants, can be nil
+
+	// StragglerFactor is how many times slower per block than the median of its stage a
+	// job must be to get a speculative duplicate, 0 to disable speculation.
+	StragglerFactor float64
+
+	// WaitOutputsWritten makes the scheduler, without an ExecOutWalker streaming the outputs
+	// of the map stage, complete only once they are all written.
+	WaitOutputsWritten bool
+
+	tenant   string
+	attempts map[stage.Unit]*jobAttempts
+
+	// ownedJobs are the in-flight jobs claimed by this request, by unit. Once processed, the
+	// jobs of store stages move to `squashingJobs`, by the unit of their last segment, and
+	// their waiters are released when it is squashed, so they find the full stores.
+	ownedJobs     map[stage.Unit]*stage.InFlightJob
+	squashingJobs map[stage.Unit]*stage.InFlightJob
+	// retryJobs are the jobs whose identical job of another request failed, processed by
+	// this request once a worker is available.
+	retryJobs []pendingJob
 
 	logger *zap.Logger
 
@@ -38,9 +61,14 @@ type Scheduler struct {
 func New(ctx context.Context, stream *response.Stream) *Scheduler {
 	logger := reqctx.Logger(ctx)
 	s := &Scheduler{
-		ctx:    ctx,
-		stream: stream,
-		logger: logger,
+		ctx:      ctx,
+		stream:   stream,
+		logger:   logger,
+		tenant:   work.Tenant(ctx),
+		attempts: make(map[stage.Unit]*jobAttempts),
+
+		ownedJobs:     make(map[stage.Unit]*stage.InFlightJob),
+		squashingJobs: make(map[stage.Unit]*stage.InFlightJob),
 	}
 	s.EventLoop = loop.NewEventLoop(s.Update)
 	return s
@@ -54,7 +82,7 @@ func (s *Scheduler) Init() loop.Cmd {
 	} else {
 		// This hides the fact that there _was no_ Walker. Could cause
 		// confusing error messages in `cmdShutdownWhenComplete()`.
-		s.outputStreamCompleted = true
+		s.outputStreamCompleted = !s.WaitOutputsWritten || s.Stages.AllMapsCompleted()
 	}
 
 	cmds = append(cmds, work.CmdScheduleNextJob())
@@ -65,9 +93,134 @@ func (s *Scheduler) Init() loop.Cmd {
 
 	cmds = append(cmds, s.Stages.CmdStartMerge())
 
+	if s.StragglerFactor > 0 {
+		cmds = append(cmds, cmdCheckStragglers())
+	}
+
 	return loop.Batch(cmds...)
 }
 
+// Run runs the event loop, then releases the requests awaiting the jobs of this request
+// whose stores were not squashed: they process the jobs themselves.
+func (s *Scheduler) Run(ctx context.Context, initCmd loop.Cmd) error {
+	err := s.EventLoop.Run(ctx, initCmd)
+
+	jobErr := err
+	if jobErr == nil {
+		jobErr = errors.New("request ended before its job was squashed")
+	}
+	for unit, job := range s.ownedJobs {
+		job.Done(jobErr)
+		delete(s.ownedJobs, unit)
+	}
+	for unit, job := range s.squashingJobs {
+		job.Done(jobErr)
+		delete(s.squashingJobs, unit)
+	}
+	return err
+}
+
+// pendingJob is a job attempt dispatched once the FairShare grants it.
+type pendingJob struct {
+	ctx         context.Context // canceled when a speculative duplicate succeeds first
+	unit        stage.Unit
+	workRange   *block.Range
+	modules     []string
+	speculative bool
+}
+
+type msgJobGranted struct {
+	pendingJob
+	release func()
+	err     error
+}
+
+type msgAwaitedJobFailed struct {
+	pendingJob
+	err error
+}
+
+// cmdAcquire waits for the FairShare to grant `job`, for which a worker of the pool is held.
+func (s *Scheduler) cmdAcquire(job pendingJob) loop.Cmd {
+	return func() loop.Msg {
+		release, err := s.FairShare.Acquire(job.ctx, s.tenant)
+		return msgJobGranted{pendingJob: job, release: release, err: err}
+	}
+}
+
+// dispatchJob processes the granted `job` with a worker of the pool, unless another request
+// is processing the same job, in which case its completion is awaited instead, without a
+// worker nor the FairShare slot: both write the same files. Speculative duplicates are
+// always processed, the deduplication would only await the straggler.
+func (s *Scheduler) dispatchJob(job pendingJob, release func()) loop.Cmd {
+	if !job.speculative {
+		inFlight, owner := s.Stages.ClaimJob(job.unit, job.workRange)
+		if !owner {
+			release()
+			s.logger.Info("awaiting identical job of another request", zap.Object("unit", job.unit), zap.Stringer("range", job.workRange))
+			return s.cmdAwaitJob(inFlight, job)
+		}
+		s.ownedJobs[job.unit] = inFlight
+	}
+
+	worker := s.WorkerPool.Borrow()
+	s.Stages.StartJob(job.unit)
+	return s.cmdWork(worker, job, release)
+}
+
+// cmdWork processes `job` with `worker`, then releases its FairShare slot.
+func (s *Scheduler) cmdWork(worker work.Worker, job pendingJob, release func()) loop.Cmd {
+	return func() loop.Msg {
+		defer release()
+
+		msg := worker.Work(job.ctx, job.unit, job.workRange, job.modules, s.stream)()
+		if failed, ok := msg.(work.MsgJobFailed); ok {
+			failed.Worker = worker
+			return failed
+		}
+		return msg
+	}
+}
+
+// cmdAwaitJob awaits the identical `job` of another request. If it fails, this request
+// processes it after all.
+func (s *Scheduler) cmdAwaitJob(job *stage.InFlightJob, awaited pendingJob) loop.Cmd {
+	return func() loop.Msg {
+		err := job.Wait(awaited.ctx)
+		if err == nil {
+			metrics.Tier1DeduplicatedJobsCounter.Inc()
+			return work.MsgJobSucceeded{Unit: awaited.unit}
+		}
+		if awaited.ctx.Err() != nil {
+			return work.MsgJobFailed{Unit: awaited.unit, Error: awaited.ctx.Err()}
+		}
+		return msgAwaitedJobFailed{pendingJob: awaited, err: err}
+	}
+}
+
+// jobSucceeded releases the requests awaiting the job of `unit` if this request owns it,
+// once `last`, the unit of its last segment, is squashed for store stages.
+func (s *Scheduler) jobSucceeded(unit, last stage.Unit) {
+	job := s.ownedJobs[unit]
+	if job == nil {
+		return
+	}
+	delete(s.ownedJobs, unit)
+	if s.Stages.IsStoreStage(unit.Stage) {
+		s.squashingJobs[last] = job
+		return
+	}
+	job.Done(nil)
+}
+
+// jobFailed releases the requests awaiting the job of `unit` with `err` if this request owns it.
+func (s *Scheduler) jobFailed(unit stage.Unit, err error) {
+	if job := s.ownedJobs[unit]; job != nil {
+		delete(s.ownedJobs, unit)
+		job.Done(err)
+	}
+}
+
 func (s *Scheduler) Update(msg loop.Msg) loop.Cmd {
 	defer s.Stages.UpdateStats()
 
@@ -82,9 +235,20 @@ func (s *Scheduler) Update(msg loop.Msg) loop.Cmd {
 	switch msg := msg.(type) {
 	case work.MsgJobSucceeded:
 		metrics.Tier1ActiveWorkerRequest.Dec()
+		if msg.Worker != nil {
+			s.WorkerPool.Return(msg.Worker)
+		}
 
-		s.Stages.MarkSegmentPartialPresent(msg.Unit)
-		s.WorkerPool.Return(msg.Worker)
+		if attempts := s.endAttempt(msg.Unit); attempts != nil {
+			if attempts.succeeded {
+				// the other attempt was first
+				return work.CmdScheduleNextJob()
+			}
+			attempts.succeeded = true
+			attempts.cancelAll()
+		}
+		last := s.Stages.MarkSegmentPartialPresent(msg.Unit)
+		s.jobSucceeded(msg.Unit, last)
 
 		cmds = append(cmds,
 			s.Stages.CmdTryMerge(msg.Unit.Stage),
@@ -92,6 +256,9 @@ func (s *Scheduler) Update(msg loop.Msg) loop.Cmd {
 		)
 		if s.ExecOutWalker != nil {
 			cmds = append(cmds, execout.CmdDownloadSegment(0))
+		} else if !s.outputStreamCompleted && s.Stages.AllMapsCompleted() {
+			s.outputStreamCompleted = true
+			cmds = append(cmds, s.cmdShutdownWhenComplete())
 		}
 
 	case work.MsgScheduleNextJob:
@@ -103,31 +270,82 @@ func (s *Scheduler) Update(msg loop.Msg) loop.Cmd {
 			cmds = append(cmds, loop.Tick(time.Second, func() loop.Msg { return work.MsgScheduleNextJob{} }))
 			break
 		}
+		if len(s.retryJobs) != 0 {
+			retry := s.retryJobs[0]
+			s.retryJobs = s.retryJobs[1:]
+			s.WorkerPool.Hold()
+			return loop.Batch(
+				s.cmdAcquire(retry),
+				work.CmdScheduleNextJob(),
+			)
+		}
+
 		workUnit, workRange := s.Stages.NextJob()
 		if workRange == nil {
 			return nil
 		}
 
-		worker := s.WorkerPool.Borrow()
-
 		s.logger.Info("scheduling work", zap.Object("unit", workUnit))
 		modules := s.Stages.StageModules(workUnit.Stage)
 
 		metrics.Tier1ActiveWorkerRequest.Inc()
 		metrics.Tier1WorkerRequestCounter.Inc()
 
+		ctx, cancel := context.WithCancel(s.ctx)
+		s.attempts[workUnit] = &jobAttempts{
+			workRange: workRange,
+			modules:   modules,
+			cancels:   []context.CancelFunc{cancel},
+			running:   1,
+		}
+
+		s.WorkerPool.Hold()
 		return loop.Batch(
-			worker.Work(s.ctx, workUnit, workRange, modules, s.stream),
+			s.cmdAcquire(pendingJob{ctx: ctx, unit: workUnit, workRange: workRange, modules: modules}),
 			work.CmdScheduleNextJob(),
 		)
 
+	case msgJobGranted:
+		s.WorkerPool.Unhold()
+		if msg.err != nil {
+			failed := work.MsgJobFailed{Unit: msg.unit, Error: msg.err}
+			return func() loop.Msg { return failed }
+		}
+		return s.dispatchJob(msg.pendingJob, msg.release)
+
+	case msgAwaitedJobFailed:
+		s.logger.Info("identical job of another request failed, processing it", zap.Object("unit", msg.unit), zap.Error(msg.err))
+		s.retryJobs = append(s.retryJobs, msg.pendingJob)
+		return work.CmdScheduleNextJob()
+
 	case work.MsgJobFailed:
 		metrics.Tier1ActiveWorkerRequest.Dec()
 
+		if attempts := s.endAttempt(msg.Unit); attempts != nil && (attempts.succeeded || attempts.running > 0) {
+			// another attempt at this unit succeeded or is still running
+			if msg.Worker != nil {
+				s.WorkerPool.Return(msg.Worker)
+			}
+			return work.CmdScheduleNextJob()
+		}
+		s.jobFailed(msg.Unit, msg.Error)
 		cmds = append(cmds, loop.Quit(msg.Error))
 
+	case msgCheckStragglers:
+		cmds = append(cmds, s.speculate()...)
+		cmds = append(cmds, cmdCheckStragglers())
+
 	case stage.MsgMergeFinished:
 		s.Stages.MergeCompleted(msg.Unit)
+		if job := s.squashingJobs[msg.Unit]; job != nil {
+			delete(s.squashingJobs, msg.Unit)
+			// the awaiting requests load the full stores: released once they are written
+			if msg.WaitWritten == nil {
+				job.Done(nil)
+			} else {
+				go func() { job.Done(msg.WaitWritten()) }()
+			}
+		}
 		cmds = append(cmds,
 			work.CmdScheduleNextJob(),
 			s.Stages.CmdTryMerge(msg.Stage),
@@ -1,12 +1,31 @@
 package scheduler
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/streamingfast/dstore"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 
+	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/metrics"
 	"github.com/streamingfast/substreams/orchestrator/execout"
 	"github.com/streamingfast/substreams/orchestrator/loop"
+	"github.com/streamingfast/substreams/orchestrator/plan"
+	"github.com/streamingfast/substreams/orchestrator/response"
+	"github.com/streamingfast/substreams/orchestrator/stage"
+	"github.com/streamingfast/substreams/orchestrator/work"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/storage/store"
 )
 
 func TestSched2_JobFinished(t *testing.T) {
@@ -35,3 +54,182 @@ func TestSched2_JobFinished(t *testing.T) {
 	//  * NextSegment()
 
 }
+
+// testMapScheduler returns a scheduler writing the outputs of a single map module over
+// [0, 40), without an ExecOutWalker streaming them.
+func testMapScheduler(t *testing.T, waitOutputsWritten bool) *Scheduler {
+	t.Helper()
+
+	ctx := reqctx.WithRequest(context.Background(), &reqctx.RequestDetails{OutputModule: "map_a"})
+	ctx = reqctx.WithReqStats(ctx, metrics.NewReqStats(&metrics.Config{}, zap.NewNop()))
+
+	graph, err := outputmodules.NewOutputModuleGraph("map_a", true, &pbsubstreams.Modules{
+		Modules: []*pbsubstreams.Module{{
+			Name:   "map_a",
+			Kind:   &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{OutputType: "proto:test.Output"}},
+			Inputs: []*pbsubstreams.Module_Input{{Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "test.Block"}}}},
+		}},
+		Binaries: []*pbsubstreams.Binary{{Type: "wasm/rust-v1"}},
+	})
+	require.NoError(t, err)
+	reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 0, 0, 40, 40, false)
+	require.NoError(t, err)
+	reqPlan.ReadExecOut = nil
+
+	s := New(ctx, nil)
+	s.Stages = stage.NewStages(ctx, graph, reqPlan, nil)
+	s.WaitOutputsWritten = waitOutputsWritten
+	return s
+}
+
+func TestScheduler_WaitOutputsWritten(t *testing.T) {
+	s := testMapScheduler(t, false)
+	s.Init()
+	assert.True(t, s.outputStreamCompleted, "without a walker, the outputs are not awaited")
+
+	s = testMapScheduler(t, true)
+	s.Init()
+	assert.False(t, s.outputStreamCompleted)
+	s.storesSyncCompleted = true
+
+	var units []stage.Unit
+	for {
+		unit, workRange := s.Stages.NextJob()
+		if workRange == nil {
+			break
+		}
+		units = append(units, unit)
+	}
+	require.Len(t, units, 4)
+
+	for _, unit := range units[:len(units)-1] {
+		s.Update(work.MsgJobSucceeded{Unit: unit})
+		assert.False(t, s.outputStreamCompleted, "segment %d", unit.Segment)
+	}
+
+	cmd := s.Update(work.MsgJobSucceeded{Unit: units[len(units)-1]})
+	assert.True(t, s.outputStreamCompleted, "all the outputs are written")
+	require.NotNil(t, cmd)
+	batch, ok := cmd().(loop.BatchMsg)
+	require.True(t, ok)
+	assert.IsType(t, loop.QuitMsg{}, batch[len(batch)-1](), "completes with the stores synced")
+}
+
+// slowFullKVStore delays the writes of the full stores, as an object store does for large
+// stores.
+type slowFullKVStore struct {
+	dstore.Store
+}
+
+func (s *slowFullKVStore) SubStore(subFolder string) (dstore.Store, error) {
+	sub, err := s.Store.SubStore(subFolder)
+	if err != nil {
+		return nil, err
+	}
+	return &slowFullKVStore{Store: sub}, nil
+}
+
+func (s *slowFullKVStore) WriteObject(ctx context.Context, base string, f io.Reader) error {
+	if strings.HasSuffix(base, ".kv") {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return s.Store.WriteObject(ctx, base, f)
+}
+
+func TestScheduler_ConcurrentRequestsOnSameStoreSegments(t *testing.T) {
+	fileStore, err := dstore.NewStore("file://"+t.TempDir(), "", "", true)
+	require.NoError(t, err)
+	objStore := &slowFullKVStore{Store: fileStore}
+	graph, err := outputmodules.NewOutputModuleGraph("store_a", true, &pbsubstreams.Modules{
+		Modules: []*pbsubstreams.Module{
+			{
+				Name:   "map_a",
+				Kind:   &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{OutputType: "proto:test.Output"}},
+				Inputs: []*pbsubstreams.Module_Input{{Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "test.Block"}}}},
+			},
+			{
+				Name:   "store_a",
+				Kind:   &pbsubstreams.Module_KindStore_{KindStore: &pbsubstreams.Module_KindStore{UpdatePolicy: pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, ValueType: "string"}},
+				Inputs: []*pbsubstreams.Module_Input{{Input: &pbsubstreams.Module_Input_Map_{Map: &pbsubstreams.Module_Input_Map{ModuleName: "map_a"}}}},
+			},
+		},
+		Binaries: []*pbsubstreams.Binary{{Type: "wasm/rust-v1"}},
+	})
+	require.NoError(t, err)
+
+	var lock sync.Mutex
+	processed := make(map[string]int)
+	// each job writes the partial store of its range, as tier2 does
+	newWorker := func(storeConfig *store.Config) work.Worker {
+		return work.NewWorkerFactoryFromFunc(func(ctx context.Context, unit stage.Unit, workRange *block.Range, _ []string, _ *response.Stream) loop.Cmd {
+			return func() loop.Msg {
+				lock.Lock()
+				processed[workRange.String()]++
+				lock.Unlock()
+
+				partial := storeConfig.NewPartialKV(workRange.StartBlock, zap.NewNop())
+				partial.Set(0, fmt.Sprintf("key_%d", workRange.StartBlock), "value")
+				err := partial.Flush()
+				var writer interface{ Write(context.Context) error }
+				if err == nil {
+					_, writer, err = partial.Save(workRange.ExclusiveEndBlock)
+				}
+				if err == nil {
+					err = writer.Write(ctx)
+				}
+				if err != nil {
+					return work.MsgJobFailed{Unit: unit, Error: err}
+				}
+				return work.MsgJobSucceeded{Unit: unit}
+			}
+		})
+	}
+
+	run := func() (store.Map, error) {
+		ctx := reqctx.WithRequest(context.Background(), &reqctx.RequestDetails{OutputModule: "store_a"})
+		ctx = reqctx.WithReqStats(ctx, metrics.NewReqStats(&metrics.Config{}, zap.NewNop()))
+
+		storeConfigs, err := store.NewConfigMap(objStore, graph.Stores(), graph.ModuleHashes())
+		require.NoError(t, err)
+		reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 0, 0, 40, 40, true)
+		require.NoError(t, err)
+
+		capacity := work.NewTier2Capacity()
+		capacity.Update(work.CapacityHeaders("a", 0, 10))
+		s := New(ctx, nil)
+		s.Stages = stage.NewStages(ctx, graph, reqPlan, storeConfigs)
+		s.WorkerPool = work.NewWorkerPool(ctx, 4, func(logger *zap.Logger) work.Worker {
+			return newWorker(storeConfigs["store_a"])
+		}, capacity)
+
+		if err := s.Run(ctx, s.Init()); err != nil {
+			return nil, err
+		}
+		return s.FinalStoreMap(40)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]store.Map, 2)
+	errs := make([]error, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = run()
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		require.NoError(t, errs[i], "request %d", i)
+		final, found := results[i].Get("store_a")
+		require.True(t, found)
+		for block := uint64(0); block < 40; block += 10 {
+			_, found := final.GetFirst(fmt.Sprintf("key_%d", block))
+			assert.True(t, found, "request %d, key of block %d", i, block)
+		}
+	}
+	for rng, count := range processed {
+		assert.Equal(t, 1, count, "range %s processed once, the other request awaits it", rng)
+	}
+}
@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/metrics"
+	"github.com/streamingfast/substreams/orchestrator/loop"
+	"github.com/streamingfast/substreams/orchestrator/stage"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/reqctx"
+)
+
+const (
+	stragglerCheckInterval = 5 * time.Second
+	// minStragglerSamples is the number of completed jobs of a stage needed to trust its median
+	minStragglerSamples = 3
+	// minStragglerDuration keeps short jobs from being duplicated over small variations
+	minStragglerDuration = 10 * time.Second
+)
+
+type msgCheckStragglers struct{}
+
+func cmdCheckStragglers() loop.Cmd {
+	return loop.Tick(stragglerCheckInterval, func() loop.Msg { return msgCheckStragglers{} })
+}
+
+// jobAttempts tracks the attempts at processing a unit: the job first scheduled, and its
+// speculative duplicate if it became a straggler. The first attempt to succeed cancels the
+// other one.
+type jobAttempts struct {
+	workRange  *block.Range
+	modules    []string
+	cancels    []context.CancelFunc
+	running    int
+	succeeded  bool
+	speculated bool
+}
+
+func (a *jobAttempts) cancelAll() {
+	for _, cancel := range a.cancels {
+		cancel()
+	}
+}
+
+// endAttempt records the end of an attempt at `unit`, returning its attempts, nil when
+// it is not tracked. They are forgotten once none is running.
+func (s *Scheduler) endAttempt(unit stage.Unit) *jobAttempts {
+	attempts := s.attempts[unit]
+	if attempts == nil {
+		return nil
+	}
+	attempts.running--
+	if attempts.running <= 0 {
+		attempts.cancelAll()
+		delete(s.attempts, unit)
+	}
+	return attempts
+}
+
+// findStragglers returns the running `jobs` taking more than `factor` times the median
+// duration per block of the completed jobs of their stage.
+func findStragglers(jobs []*pbsubstreamsrpc.Job, medianBlockDuration func(stage uint32) (time.Duration, int), factor float64) (out []*pbsubstreamsrpc.Job) {
+	for _, job := range jobs {
+		blocks := job.StopBlock - job.StartBlock
+		duration := time.Duration(job.DurationMs) * time.Millisecond
+		if blocks == 0 || duration < minStragglerDuration {
+			continue
+		}
+
+		median, samples := medianBlockDuration(job.Stage)
+		if samples < minStragglerSamples {
+			continue
+		}
+		if float64(duration/time.Duration(blocks)) > factor*float64(median) {
+			out = append(out, job)
+		}
+	}
+	return out
+}
+
+// speculate launches a duplicate of the straggler jobs on the free workers.
+func (s *Scheduler) speculate() (cmds []loop.Cmd) {
+	stats := reqctx.ReqStats(s.ctx)
+	for _, job := range findStragglers(stats.JobsStats(), stats.MedianJobBlockDuration, s.StragglerFactor) {
+		unit, attempts := s.findAttempts(job)
+		if attempts == nil {
+			continue
+		}
+		if avail, _ := s.WorkerPool.WorkerAvailable(); !avail {
+			return
+		}
+
+		s.WorkerPool.Hold()
+		ctx, cancel := context.WithCancel(s.ctx)
+		attempts.cancels = append(attempts.cancels, cancel)
+		attempts.running++
+		attempts.speculated = true
+
+		s.logger.Info("launching speculative duplicate of straggler job",
+			zap.Object("unit", unit),
+			zap.Stringer("range", attempts.workRange),
+			zap.Uint64("duration_ms", job.DurationMs),
+		)
+		metrics.Tier1ActiveWorkerRequest.Inc()
+		metrics.Tier1WorkerRequestCounter.Inc()
+		metrics.Tier1SpeculativeJobsCounter.Inc()
+
+		cmds = append(cmds, s.cmdAcquire(pendingJob{ctx: ctx, unit: unit, workRange: attempts.workRange, modules: attempts.modules, speculative: true}))
+	}
+	return
+}
+
+// findAttempts returns the attempts of the unit processed by `job`, matching its stage and
+// its whole range, nil if the unit is done or was already duplicated.
+func (s *Scheduler) findAttempts(job *pbsubstreamsrpc.Job) (stage.Unit, *jobAttempts) {
+	for unit, attempts := range s.attempts {
+		if uint32(unit.Stage) != job.Stage || attempts.workRange.StartBlock != job.StartBlock || attempts.workRange.ExclusiveEndBlock != job.StopBlock {
+			continue
+		}
+		if attempts.succeeded || attempts.speculated {
+			return unit, nil
+		}
+		return unit, attempts
+	}
+	return stage.Unit{}, nil
+}
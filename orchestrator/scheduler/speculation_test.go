@@ -0,0 +1,204 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/metrics"
+	"github.com/streamingfast/substreams/orchestrator/loop"
+	"github.com/streamingfast/substreams/orchestrator/plan"
+	"github.com/streamingfast/substreams/orchestrator/stage"
+	"github.com/streamingfast/substreams/orchestrator/work"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/reqctx"
+)
+
+func TestFindStragglers(t *testing.T) {
+	medians := map[uint32]struct {
+		median  time.Duration
+		samples int
+	}{
+		0: {median: 100 * time.Millisecond, samples: 5},
+		1: {median: 100 * time.Millisecond, samples: 2},
+	}
+	medianBlockDuration := func(stage uint32) (time.Duration, int) {
+		return medians[stage].median, medians[stage].samples
+	}
+
+	job := func(stage uint32, blocks uint64, duration time.Duration) *pbsubstreamsrpc.Job {
+		return &pbsubstreamsrpc.Job{Stage: stage, StartBlock: 1000, StopBlock: 1000 + blocks, DurationMs: uint64(duration.Milliseconds())}
+	}
+
+	straggler := job(0, 100, 40*time.Second)
+	tests := []struct {
+		name   string
+		job    *pbsubstreamsrpc.Job
+		expect bool
+	}{
+		{"much slower than median", straggler, true},
+		{"as fast as median", job(0, 200, 20*time.Second), false},
+		{"under factor", job(0, 100, 25*time.Second), false},
+		{"too short to tell", job(0, 10, 5*time.Second), false},
+		{"too few samples", job(1, 100, 40*time.Second), false},
+		{"no median", job(2, 100, 40*time.Second), false},
+		{"empty range", job(0, 0, 40*time.Second), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := findStragglers([]*pbsubstreamsrpc.Job{test.job}, medianBlockDuration, 3)
+			if test.expect {
+				assert.Equal(t, []*pbsubstreamsrpc.Job{test.job}, out)
+			} else {
+				assert.Empty(t, out)
+			}
+		})
+	}
+}
+
+func testScheduler(t *testing.T) *Scheduler {
+	t.Helper()
+
+	ctx := reqctx.WithRequest(context.Background(), &reqctx.RequestDetails{OutputModule: "E"})
+	ctx = reqctx.WithReqStats(ctx, metrics.NewReqStats(&metrics.Config{}, zap.NewNop()))
+
+	reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 0, 0, 40, 40, true)
+	require.NoError(t, err)
+
+	// a known tier2 capacity skips the ramp-up of the workers
+	capacity := work.NewTier2Capacity()
+	capacity.Update(work.CapacityHeaders("a", 0, 10))
+
+	s := New(ctx, nil)
+	s.Stages = stage.NewStages(ctx, outputmodules.TestGraphStagedModules(0, 0, 0, 0, 0), reqPlan, nil)
+	s.WorkerPool = work.NewWorkerPool(ctx, 2, func(logger *zap.Logger) work.Worker {
+		return work.NewWorkerFactoryFromFunc(nil)
+	}, capacity)
+	return s
+}
+
+// testSpeculatedJob schedules the next job of `s` along with a speculative duplicate,
+// each attempt running on a worker of the pool.
+func testSpeculatedJob(t *testing.T, s *Scheduler) (unit stage.Unit, attempts *jobAttempts, workers [2]work.Worker, ctxs [2]context.Context) {
+	t.Helper()
+
+	unit, workRange := s.Stages.NextJob()
+	require.NotNil(t, workRange)
+
+	attempts = &jobAttempts{workRange: workRange, running: 2, speculated: true}
+	for i := range workers {
+		var cancel context.CancelFunc
+		ctxs[i], cancel = context.WithCancel(context.Background())
+		attempts.cancels = append(attempts.cancels, cancel)
+
+		avail, _ := s.WorkerPool.WorkerAvailable()
+		require.True(t, avail)
+		workers[i] = s.WorkerPool.Borrow()
+	}
+	s.attempts[unit] = attempts
+	return
+}
+
+func testWorkersAvailable(t *testing.T, pool *work.WorkerPool, count int) {
+	t.Helper()
+
+	for i := 0; i < count; i++ {
+		avail, _ := pool.WorkerAvailable()
+		require.True(t, avail, "worker %d", i)
+		pool.Borrow()
+	}
+	avail, _ := pool.WorkerAvailable()
+	require.False(t, avail)
+}
+
+func TestScheduler_endAttempt(t *testing.T) {
+	s := &Scheduler{attempts: make(map[stage.Unit]*jobAttempts)}
+	unit := stage.Unit{Stage: 1, Segment: 2}
+	assert.Nil(t, s.endAttempt(unit), "untracked unit")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.attempts[unit] = &jobAttempts{running: 2, cancels: []context.CancelFunc{cancel}}
+
+	attempts := s.endAttempt(unit)
+	require.NotNil(t, attempts)
+	assert.Equal(t, 1, attempts.running)
+	assert.Contains(t, s.attempts, unit, "an attempt is still running")
+	assert.NoError(t, ctx.Err())
+
+	assert.Same(t, attempts, s.endAttempt(unit))
+	assert.NotContains(t, s.attempts, unit, "no attempt is running")
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
+
+func TestScheduler_LoserFinishesAfterWinner(t *testing.T) {
+	for _, loserSucceeds := range []bool{false, true} {
+		t.Run(fmt.Sprintf("loser succeeds %t", loserSucceeds), func(t *testing.T) {
+			s := testScheduler(t)
+			unit, attempts, workers, ctxs := testSpeculatedJob(t, s)
+
+			require.NotNil(t, s.Update(work.MsgJobSucceeded{Unit: unit, Worker: workers[0]}))
+			assert.True(t, attempts.succeeded)
+			assert.Equal(t, context.Canceled, ctxs[1].Err(), "the loser is canceled")
+			assert.Contains(t, s.attempts, unit, "the loser still runs")
+
+			var msg loop.Msg = work.MsgJobFailed{Unit: unit, Error: context.Canceled, Worker: workers[1]}
+			if loserSucceeds {
+				msg = work.MsgJobSucceeded{Unit: unit, Worker: workers[1]}
+			}
+			// marking the unit partial present twice would panic on the invalid transition
+			var cmd loop.Cmd
+			require.NotPanics(t, func() { cmd = s.Update(msg) })
+			require.NotNil(t, cmd)
+			assert.IsType(t, work.MsgScheduleNextJob{}, cmd(), "the request goes on")
+			assert.NotContains(t, s.attempts, unit)
+
+			testWorkersAvailable(t, s.WorkerPool, 2)
+		})
+	}
+}
+
+func TestScheduler_JobFailedWithWorker(t *testing.T) {
+	s := testScheduler(t)
+	unit, _, workers, _ := testSpeculatedJob(t, s)
+
+	cmd := s.Update(work.MsgJobFailed{Unit: unit, Error: fmt.Errorf("failed"), Worker: workers[0]})
+	require.NotNil(t, cmd)
+	assert.IsType(t, work.MsgScheduleNextJob{}, cmd(), "the duplicate still runs")
+	testWorkersAvailable(t, s.WorkerPool, 1)
+
+	cmd = s.Update(work.MsgJobFailed{Unit: unit, Error: fmt.Errorf("failed again"), Worker: workers[1]})
+	require.NotNil(t, cmd)
+	assert.IsType(t, loop.BatchMsg{}, cmd())
+	assert.IsType(t, loop.QuitMsg{}, cmd().(loop.BatchMsg)[0](), "all the attempts failed")
+}
+
+func TestScheduler_findAttempts(t *testing.T) {
+	s := &Scheduler{attempts: make(map[stage.Unit]*jobAttempts)}
+	unit := stage.Unit{Stage: 1, Segment: 2}
+	attempts := &jobAttempts{workRange: block.NewRange(20, 40)}
+	s.attempts[unit] = attempts
+
+	found, out := s.findAttempts(&pbsubstreamsrpc.Job{Stage: 1, StartBlock: 20, StopBlock: 40})
+	assert.Equal(t, unit, found)
+	assert.Same(t, attempts, out)
+
+	for _, job := range []*pbsubstreamsrpc.Job{
+		{Stage: 0, StartBlock: 20, StopBlock: 40},
+		{Stage: 1, StartBlock: 20, StopBlock: 30},
+		{Stage: 1, StartBlock: 10, StopBlock: 40},
+	} {
+		_, out := s.findAttempts(job)
+		assert.Nil(t, out, "job %s", job)
+	}
+
+	attempts.speculated = true
+	_, out = s.findAttempts(&pbsubstreamsrpc.Job{Stage: 1, StartBlock: 20, StopBlock: 40})
+	assert.Nil(t, out, "already duplicated")
+}
@@ -5,50 +5,72 @@ import (
 	"fmt"
 
 	"github.com/streamingfast/bstream"
+	"go.uber.org/zap"
+
 	"github.com/streamingfast/substreams/block"
 	"github.com/streamingfast/substreams/storage/execout"
-
 	"github.com/streamingfast/substreams/storage/store"
 	"github.com/streamingfast/substreams/storage/store/state"
 )
 
+// FetchStoresState marks the segments of the stages found in the cache. Unless `readOnly`,
+// it also maintains the cache: refreshing the store manifests and deleting the superseded
+// checkpoint KVs.
 func (s *Stages) FetchStoresState(
 	ctx context.Context,
 	segmenter *block.Segmenter,
 	storeConfigMap store.ConfigMap,
 	execoutConfigs *execout.Configs,
+	readOnly bool,
 ) error {
 	completes := make(unitMap)
 	partials := make(unitMap)
 
 	upToBlock := segmenter.ExclusiveEndBlock()
 
-	var mapperName string
-	var mapperFiles execout.FileInfos
-	if lastStage := s.stages[len(s.stages)-1]; lastStage.kind == KindMap {
-		if len(lastStage.storeModuleStates) != 1 {
-			panic("assertion: mapper stage should contain a single module")
-		}
-
-		mapperName = lastStage.storeModuleStates[0].name
-		conf := execoutConfigs.ConfigMap[mapperName]
-		// TODO: OPTIMIZATION: get the actual needed range for execOutputs to optimize lookup
+	// the mapper stage holds the output modules, one per requested output
+	var mapperFiles map[string]execout.FileInfos
+	if lastStage := s.stages[len(s.stages)-1]; lastStage.kind == KindMap && upToBlock != 0 {
+		mapperFiles = make(map[string]execout.FileInfos, len(lastStage.storeModuleStates))
+		for _, mapper := range lastStage.storeModuleStates {
+			conf := execoutConfigs.ConfigMap[mapper.name]
+			// TODO: OPTIMIZATION: get the actual needed range for execOutputs to optimize lookup
 
-		if upToBlock != 0 {
 			files, err := conf.ListSnapshotFiles(ctx, bstream.NewInclusiveRange(0, upToBlock))
 			if err != nil {
-				return fmt.Errorf("fetching mapper storage state: %w", err)
+				return fmt.Errorf("fetching mapper %q storage state: %w", mapper.name, err)
 			}
-			mapperFiles = files
+			mapperFiles[mapper.name] = files
 		}
 	}
 
 	// TODO: OPTIMIZATION: why load stores if there could be ExecOut data present
 	// on disk already, which avoid the need to do _any_ processing whatsoever?
-	state, err := state.FetchState(ctx, storeConfigMap, upToBlock)
+	state, err := state.FetchState(ctx, storeConfigMap, upToBlock, segmenter.Interval(), readOnly)
 	if err != nil {
 		return fmt.Errorf("fetching stores storage state: %w", err)
 	}
+
+	fullKVFiles := make(map[string]store.FileInfos)
+	for _, stage := range s.stages {
+		if stage.kind != KindStore {
+			continue
+		}
+		for _, mod := range stage.storeModuleStates {
+			fullKVFiles[mod.name] = state.Snapshots[mod.name].FullKVFiles
+		}
+	}
+	s.checkpoints = findCheckpoints(segmenter, fullKVFiles)
+	if lastStage := s.stages[len(s.stages)-1]; lastStage.kind == KindStore && s.mapSegmenter != nil {
+		// The outputs of a store output module are streamed from the execution outputs
+		// of its whole segments, which a job resuming from a checkpoint does not produce.
+		for segment := range s.checkpoints {
+			if rng := segmenter.Range(segment); rng != nil && rng.ExclusiveEndBlock > s.mapSegmenter.InitialBlock() {
+				delete(s.checkpoints, segment)
+			}
+		}
+	}
+	checkpointKVs := s.pruneCheckpointKVs(segmenter, storeConfigMap, fullKVFiles, readOnly)
 	for stageIdx, stage := range s.stages {
 		moduleCount := len(stage.storeModuleStates)
 
@@ -59,15 +81,17 @@ func (s *Stages) FetchStoresState(
 			if stageIdx != len(s.stages)-1 {
 				panic("assertion: mapper stage is not the last stage")
 			}
-			for _, outputFile := range mapperFiles {
-				segmentIdx := s.mapSegmenter.IndexForEndBlock(outputFile.BlockRange.ExclusiveEndBlock)
-				rng := s.mapSegmenter.Range(segmentIdx)
-				if rng == nil || rng.ExclusiveEndBlock != outputFile.BlockRange.ExclusiveEndBlock {
-					continue
-				}
-				unit := Unit{Stage: stageIdx, Segment: segmentIdx}
-				if allDone := markFound(completes, unit, mapperName, moduleCount); allDone {
-					s.markSegmentCompleted(unit)
+			for mapperName, files := range mapperFiles {
+				for _, outputFile := range files {
+					segmentIdx := s.mapSegmenter.IndexForEndBlock(outputFile.BlockRange.ExclusiveEndBlock)
+					rng := s.mapSegmenter.Range(segmentIdx)
+					if rng == nil || rng.ExclusiveEndBlock != outputFile.BlockRange.ExclusiveEndBlock {
+						continue
+					}
+					unit := Unit{Stage: stageIdx, Segment: segmentIdx}
+					if allDone := markFound(completes, unit, mapperName, moduleCount); allDone {
+						s.markSegmentCompleted(unit)
+					}
 				}
 			}
 
@@ -75,6 +99,7 @@ func (s *Stages) FetchStoresState(
 		}
 
 		for _, mod := range stage.storeModuleStates {
+			mod.checkpointKVs = checkpointKVs[mod.name]
 			files := state.Snapshots[mod.name]
 			modSegmenter := mod.segmenter
 
@@ -95,7 +120,7 @@ func (s *Stages) FetchStoresState(
 
 			for _, partial := range files.Partials {
 				segmentIdx := modSegmenter.IndexForStartBlock(partial.Range.StartBlock)
-				rng := segmenter.Range(segmentIdx)
+				rng := s.storeRange(segmenter, segmentIdx)
 				if rng == nil {
 					continue
 				}
@@ -124,6 +149,88 @@ func (s *Stages) FetchStoresState(
 	return nil
 }
 
+// findCheckpoints returns, for the segments of `segmenter`, the highest block strictly
+// within the segment at which every store has a full KV. Such full KVs are saved at the
+// linear handoff of requests, which does not fall on a segment boundary.
+func findCheckpoints(segmenter *block.Segmenter, fullKVFiles map[string]store.FileInfos) map[int]uint64 {
+	counts := make(map[uint64]int)
+	for _, files := range fullKVFiles {
+		for _, fullKV := range files {
+			counts[fullKV.Range.ExclusiveEndBlock]++
+		}
+	}
+
+	out := make(map[int]uint64)
+	for block, count := range counts {
+		if count != len(fullKVFiles) {
+			continue
+		}
+		segmentIdx := segmenter.IndexForStartBlock(block)
+		rng := segmenter.Range(segmentIdx)
+		if rng == nil || block <= rng.StartBlock || block >= rng.ExclusiveEndBlock {
+			continue
+		}
+		if block > out[segmentIdx] {
+			out[segmentIdx] = block
+		}
+	}
+	return out
+}
+
+// pruneCheckpointKVs returns the full KVs of each store saved off the save interval, keeping
+// the highest one of each segment. The others, and those of segments whose full KV on
+// interval exists, are superseded and deleted in the background unless `readOnly`.
+func (s *Stages) pruneCheckpointKVs(segmenter *block.Segmenter, storeConfigMap store.ConfigMap, fullKVFiles map[string]store.FileInfos, readOnly bool) map[string]store.FileInfos {
+	out := make(map[string]store.FileInfos, len(fullKVFiles))
+	for name, files := range fullKVFiles {
+		onInterval := make(map[int]bool)
+		latest := make(map[int]*store.FileInfo)
+		var stale store.FileInfos
+		for _, file := range files {
+			end := file.Range.ExclusiveEndBlock
+			segment := segmenter.IndexForEndBlock(end)
+			if end%segmenter.Interval() == 0 {
+				onInterval[segment] = true
+				continue
+			}
+			if previous := latest[segment]; previous != nil {
+				if previous.Range.ExclusiveEndBlock > end {
+					stale = append(stale, file)
+					continue
+				}
+				stale = append(stale, previous)
+			}
+			latest[segment] = file
+		}
+		for segment, file := range latest {
+			if onInterval[segment] {
+				stale = append(stale, file)
+				continue
+			}
+			out[name] = append(out[name], file)
+		}
+		if !readOnly {
+			s.deleteCheckpointKVs(storeConfigMap[name], stale)
+		}
+	}
+	return out
+}
+
+// deleteCheckpointKVs deletes `files` in the background, a failure leaves them to the
+// next request.
+func (s *Stages) deleteCheckpointKVs(config *store.Config, files store.FileInfos) {
+	if len(files) == 0 {
+		return
+	}
+	go func() {
+		for _, file := range files {
+			if err := config.DeleteFullKV(context.Background(), file); err != nil {
+				s.logger.Warn("cannot delete superseded store checkpoint", zap.String("store", config.Name()), zap.Error(err))
+			}
+		}
+	}()
+}
+
 type unitMap map[Unit]map[string]struct{}
 
 func markFound(unitMap unitMap, unit Unit, name string, moduleCount int) bool {
@@ -0,0 +1,79 @@
+package stage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/streamingfast/substreams/block"
+)
+
+// JobKey identifies the work of a job across requests: the state store and cache tag its
+// files are written under, its stage index, the hashes of the stage's modules and its
+// block range.
+type JobKey string
+
+// InFlightJobs registers the jobs being processed for the requests of this process, so a
+// request about to schedule a job identical to one of another request awaits its files
+// instead of processing it twice.
+type InFlightJobs struct {
+	mu   sync.Mutex
+	jobs map[JobKey]*InFlightJob
+}
+
+// InFlight is the registry shared by all the requests of the process.
+var InFlight = NewInFlightJobs()
+
+func NewInFlightJobs() *InFlightJobs {
+	return &InFlightJobs{jobs: make(map[JobKey]*InFlightJob)}
+}
+
+type InFlightJob struct {
+	registry *InFlightJobs
+	key      JobKey
+	done     chan struct{}
+	err      error
+}
+
+// Claim returns the job registered for `key`, registering it when it isn't. `owner` is
+// true when the caller registered it: it must process the job and call Done. Otherwise,
+// another request processes it, and the caller can Wait for it.
+func (r *InFlightJobs) Claim(key JobKey) (job *InFlightJob, owner bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if job, found := r.jobs[key]; found {
+		return job, false
+	}
+	job = &InFlightJob{registry: r, key: key, done: make(chan struct{})}
+	r.jobs[key] = job
+	return job, true
+}
+
+// Done unregisters the job and releases its waiters with `err`, nil if the job succeeded.
+func (j *InFlightJob) Done(err error) {
+	j.registry.mu.Lock()
+	delete(j.registry.jobs, j.key)
+	j.registry.mu.Unlock()
+
+	j.err = err
+	close(j.done)
+}
+
+// Wait returns once the job is done, with its error, or with the error of `ctx`.
+func (j *InFlightJob) Wait(ctx context.Context) error {
+	select {
+	case <-j.done:
+		return j.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newJobKey(scope string, stageIdx int, moduleHashes []string, rng *block.Range) JobKey {
+	hashes := append([]string(nil), moduleHashes...)
+	sort.Strings(hashes)
+	return JobKey(fmt.Sprintf("%s/%d/%s/%d-%d", scope, stageIdx, strings.Join(hashes, ","), rng.StartBlock, rng.ExclusiveEndBlock))
+}
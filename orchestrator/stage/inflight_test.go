@@ -0,0 +1,39 @@
+package stage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/streamingfast/substreams/block"
+)
+
+func TestInFlightJobs(t *testing.T) {
+	registry := NewInFlightJobs()
+	key := newJobKey("store/tag", 1, []string{"b", "a"}, block.NewRange(10, 20))
+	assert.Equal(t, JobKey("store/tag/1/a,b/10-20"), key)
+
+	job, owner := registry.Claim(key)
+	assert.True(t, owner)
+	waiting, owner := registry.Claim(key)
+	assert.False(t, owner)
+	assert.Same(t, job, waiting)
+
+	_, owner = registry.Claim(newJobKey("store/tag", 1, []string{"a", "b"}, block.NewRange(20, 30)))
+	assert.True(t, owner, "another range is another job")
+
+	waitErr := make(chan error)
+	go func() { waitErr <- waiting.Wait(context.Background()) }()
+	job.Done(fmt.Errorf("failed"))
+	assert.EqualError(t, <-waitErr, "failed")
+
+	_, owner = registry.Claim(key)
+	assert.True(t, owner, "done jobs are unregistered")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	waiting, _ = registry.Claim(key)
+	assert.Equal(t, context.Canceled, waiting.Wait(ctx))
+}
@@ -21,6 +21,11 @@ type StoreModuleState struct {
 
 	cachedStore      *store.FullKV
 	lastBlockInStore uint64
+
+	// checkpointKVs are the full KVs of the store saved off the save interval, at most one
+	// per segment. Squashing a segment deletes its checkpoint once a later full KV of the
+	// segment is written.
+	checkpointKVs store.FileInfos
 }
 
 func NewModuleState(logger *zap.Logger, name string, segmenter *block.Segmenter, storeConfig *store.Config) *StoreModuleState {
@@ -50,6 +55,22 @@ func (s *StoreModuleState) getStore(ctx context.Context, exclusiveEndBlock uint6
 	return loadStore, nil
 }
 
+// takeCheckpointKVs removes from the known checkpoints those of `segment` other than the
+// one ending at `savedEnd`, and returns them.
+func (s *StoreModuleState) takeCheckpointKVs(segment int, savedEnd uint64) (out store.FileInfos) {
+	var kept store.FileInfos
+	for _, file := range s.checkpointKVs {
+		end := file.Range.ExclusiveEndBlock
+		if s.segmenter.IndexForEndBlock(end) == segment && end != savedEnd {
+			out = append(out, file)
+			continue
+		}
+		kept = append(kept, file)
+	}
+	s.checkpointKVs = kept
+	return out
+}
+
 func (s *StoreModuleState) derivePartialKV(initialBlock uint64) *store.PartialKV {
 	return s.storeConfig.NewPartialKV(initialBlock, s.logger)
 }
@@ -15,6 +15,10 @@ func CmdAllStoresCompleted() loop.Cmd {
 
 type MsgMergeFinished struct {
 	Unit
+
+	// WaitWritten returns once the merged full stores are written on storage, which
+	// happens in the background, with the error of their writes. Can be nil.
+	WaitWritten func() error
 } // A single partial store was successfully merged into the full store.
 
 type MsgMergeFailed struct {
@@ -0,0 +1,59 @@
+package stage
+
+// Plan is the work left on a stage once its cached segments are known.
+type Plan struct {
+	Modules  []string
+	Kind     Kind
+	Segments int
+	Cached   int    // segments with their outputs or full stores cached
+	Partials int    // segments with their partial stores cached, only merged
+	Jobs     int    // jobs scheduled for the pending segments
+	Blocks   uint64 // blocks processed by the jobs
+}
+
+// Plan returns the work left on each stage from the states of their segments, grouping
+// the pending segments in jobs the way NextJob does.
+func (s *Stages) Plan() (out []*Plan) {
+	for stageIdx, stage := range s.stages {
+		p := &Plan{
+			Modules: append([]string(nil), stage.allExecutedModules...),
+			Kind:    stage.kind,
+		}
+		out = append(out, p)
+
+		jobSegments := s.stageJobSegments(stageIdx)
+		pendingRun := 0
+		endRun := func() {
+			p.Jobs += (pendingRun + jobSegments - 1) / jobSegments
+			pendingRun = 0
+		}
+
+		for segmentIdx := stage.segmenter.FirstIndex(); segmentIdx <= stage.segmenter.LastIndex(); segmentIdx++ {
+			unit := Unit{Segment: segmentIdx, Stage: stageIdx}
+			rng := stage.segmenter.Range(segmentIdx)
+			if stage.kind == KindStore {
+				rng = s.storeRange(stage.segmenter, segmentIdx)
+			}
+			state := s.getState(unit)
+			if rng == nil || rng.Len() == 0 || state == UnitNoOp {
+				endRun()
+				continue
+			}
+
+			p.Segments++
+			switch state {
+			case UnitCompleted:
+				p.Cached++
+				endRun()
+			case UnitPartialPresent:
+				p.Partials++
+				endRun()
+			default:
+				p.Blocks += rng.Len()
+				pendingRun++
+			}
+		}
+		endRun()
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package stage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/streamingfast/substreams/orchestrator/plan"
+	"github.com/streamingfast/substreams/pipeline/outputmodules"
+)
+
+func TestStagesPlan(t *testing.T) {
+	reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 5, 5, 60, 60, true)
+	require.NoError(t, err)
+	stages := NewStages(
+		context.Background(),
+		outputmodules.TestGraphStagedModules(5, 5, 5, 5, 5),
+		reqPlan,
+		nil,
+	)
+	stages.stageModuleHashes = [][]string{{"a"}, {"b"}, {"c"}}
+	stages.costs = plan.NewJobCosts()
+	stages.costs.Record(stages.stageCostKey(0), 10, 10*time.Millisecond) // cheap stage
+	stages.SizeJobs(reqPlan, 50*time.Millisecond, 3)
+
+	stages.allocSegments(5)
+	stages.setState(Unit{Stage: 0, Segment: 0}, UnitCompleted)
+	stages.setState(Unit{Stage: 0, Segment: 2}, UnitPartialPresent)
+	stages.setState(Unit{Stage: 1, Segment: 0}, UnitCompleted)
+	stages.setState(Unit{Stage: 2, Segment: 0}, UnitNoOp)
+
+	plans := stages.Plan()
+	require.Len(t, plans, 3)
+
+	assert.Equal(t, KindStore, plans[0].Kind)
+	assert.Equal(t, 6, plans[0].Segments)
+	assert.Equal(t, 1, plans[0].Cached)
+	assert.Equal(t, 1, plans[0].Partials)
+	assert.Equal(t, 2, plans[0].Jobs, "cheap stage, the pending segments around the partial are grouped up to 3 per job")
+	assert.Equal(t, uint64(40), plans[0].Blocks)
+
+	assert.Equal(t, 1, plans[1].Cached)
+	assert.Equal(t, 5, plans[1].Jobs)
+	assert.Equal(t, uint64(50), plans[1].Blocks)
+
+	assert.Equal(t, KindMap, plans[2].Kind)
+	assert.Equal(t, 5, plans[2].Segments, "the NoOp segment is not part of the plan")
+	assert.Equal(t, 5, plans[2].Jobs)
+}
@@ -3,6 +3,7 @@ package stage
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -21,11 +22,14 @@ import (
 // perhaps used to _produce_ that prior FullKV), or load it from storage.
 // This allows for both initialization of the store, and skipping of FullKV if we
 // happen to have some that were deleted.
-func (s *Stages) multiSquash(stage *Stage, mergeUnit Unit) error {
+// The full KVs are written in the background, tracked by the returned squashWrites.
+func (s *Stages) multiSquash(stage *Stage, mergeUnit Unit) (*squashWrites, error) {
 	if stage.kind != KindStore {
 		panic("multiSquash called on non-store stage")
 	}
 
+	writes := &squashWrites{}
+
 	// Launch parallel jobs to merge all stages' stores.
 	for _, modState := range stage.storeModuleStates {
 		if mergeUnit.Segment < modState.segmenter.FirstIndex() {
@@ -37,7 +41,7 @@ func (s *Stages) multiSquash(stage *Stage, mergeUnit Unit) error {
 			stats := reqctx.ReqStats(s.ctx)
 			stats.RecordModuleMerging(modState.name)
 			defer stats.RecordModuleMergeComplete(modState.name)
-			err := s.singleSquash(stage, modState, mergeUnit)
+			err := s.singleSquash(stage, modState, mergeUnit, writes)
 			if err != nil {
 				return fmt.Errorf("squash stage %d module %q: %w", stage.idx, modState.name, err)
 			}
@@ -45,7 +49,35 @@ func (s *Stages) multiSquash(stage *Stage, mergeUnit Unit) error {
 		})
 	}
 
-	return stage.syncWork.Wait()
+	return writes, stage.syncWork.Wait()
+}
+
+// squashWrites tracks the writes of the full KVs saved by a squash.
+type squashWrites struct {
+	wg  sync.WaitGroup
+	mu  sync.Mutex
+	err error
+}
+
+func (w *squashWrites) add() {
+	w.wg.Add(1)
+}
+
+func (w *squashWrites) done(err error) {
+	if err != nil {
+		w.mu.Lock()
+		w.err = multierror.Append(w.err, err)
+		w.mu.Unlock()
+	}
+	w.wg.Done()
+}
+
+// Wait returns once the full KVs are written on storage, with the error of their writes.
+func (w *squashWrites) Wait() error {
+	w.wg.Wait()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
 }
 
 type Result struct {
@@ -56,11 +88,17 @@ type Result struct {
 }
 
 func getPartialOrFullKV(ctx context.Context, modState *StoreModuleState, rng *block.Range) (*store.PartialKV, *store.FileInfo, *store.FullKV, error) {
+	// both lookups read `modState`: the other one is canceled and awaited before the
+	// caller updates it
+	var lookups sync.WaitGroup
+	defer lookups.Wait()
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	results := make(chan Result, 2)
+	lookups.Add(2)
 	go func() {
+		defer lookups.Done()
 		partialFile := store.NewPartialFileInfo(modState.name, rng.StartBlock, rng.ExclusiveEndBlock)
 		partial := modState.derivePartialKV(rng.StartBlock)
 		err := partial.Load(ctx, partialFile)
@@ -68,6 +106,7 @@ func getPartialOrFullKV(ctx context.Context, modState *StoreModuleState, rng *bl
 	}()
 
 	go func() {
+		defer lookups.Done()
 		nextFull, err := modState.getStore(ctx, rng.ExclusiveEndBlock)
 		results <- Result{fullKVStore: nextFull, error: err}
 	}()
@@ -97,16 +136,15 @@ func getPartialOrFullKV(ctx context.Context, modState *StoreModuleState, rng *bl
 
 // singleSquash gets the current fullKV and merges the next partialKV into it.
 // If there is an existing fullKV at the destination (next segment), it will be loaded instead (whichever file is seen first)
-func (s *Stages) singleSquash(stage *Stage, modState *StoreModuleState, mergeUnit Unit) error {
+func (s *Stages) singleSquash(stage *Stage, modState *StoreModuleState, mergeUnit Unit, writes *squashWrites) error {
 	metrics := mergeMetrics{}
 	metrics.start = time.Now()
 	metrics.stage = stage.idx
 	metrics.moduleName = modState.name
 	metrics.moduleHash = modState.storeConfig.ModuleHash()
 
-	rng := modState.segmenter.Range(mergeUnit.Segment)
+	rng := s.storeRange(modState.segmenter, mergeUnit.Segment)
 	metrics.blockRange = rng
-	segmentEndsOnInterval := modState.segmenter.EndsOnInterval(mergeUnit.Segment)
 
 	// Retrieve store to merge, from cache or load from storage. Allows skipping of segments
 	// for handling partials interspearsed with full KVs.
@@ -142,24 +180,38 @@ func (s *Stages) singleSquash(stage *Stage, modState *StoreModuleState, mergeUni
 	modState.lastBlockInStore = rng.ExclusiveEndBlock
 	metrics.mergeEnd = time.Now()
 
-	s.logger.Info("deleting partial store", zap.Stringer("store", partialKV))
-	stage.asyncWork.Go(func() error {
-		return partialKV.DeleteStore(s.ctx, partialFile)
-	})
+	// Flush full store. A segment not ending on interval ends at the linear handoff, its
+	// full store is a checkpoint from which a later request resumes the segment. It
+	// supersedes the previous checkpoint of the segment, as does the full store on interval.
+	metrics.saveStart = time.Now()
+	_, writer, err := fullKV.Save(rng.ExclusiveEndBlock)
+	if err != nil {
+		return fmt.Errorf("save full store: %w", err)
+	}
+	metrics.saveEnd = time.Now()
 
-	// Flush full store
-	if segmentEndsOnInterval {
-		metrics.saveStart = time.Now()
-		_, writer, err := fullKV.Save(rng.ExclusiveEndBlock)
+	// The partial is only deleted once the full KV is written: the requests awaiting this
+	// segment, released on `writes`, load either one.
+	superseded := modState.takeCheckpointKVs(mergeUnit.Segment, rng.ExclusiveEndBlock)
+	writes.add()
+	stage.asyncWork.Go(func() error {
+		err := writer.Write(context.Background()) // always write files here even if the request was cancelled.
+		writes.done(err)
 		if err != nil {
-			return fmt.Errorf("save full store: %w", err)
+			return err
 		}
-		metrics.saveEnd = time.Now()
 
-		stage.asyncWork.Go(func() error {
-			return writer.Write(context.Background()) // always write files here even if the request was cancelled.
-		})
-	}
+		s.logger.Info("deleting partial store", zap.Stringer("store", partialKV))
+		if err := partialKV.DeleteStore(s.ctx, partialFile); err != nil {
+			return err
+		}
+		for _, file := range superseded {
+			if err := modState.storeConfig.DeleteFullKV(context.Background(), file); err != nil {
+				s.logger.Warn("cannot delete superseded store checkpoint", zap.String("store", modState.name), zap.Error(err))
+			}
+		}
+		return nil
+	})
 
 	s.logger.Info("squashing time metrics", metrics.logFields()...)
 
@@ -3,6 +3,7 @@ package stage
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -49,6 +50,30 @@ type Stages struct {
 	// Any previous segment is assumed to have completed successfully, and any stores that we sync'd prior to this offset
 	// are assumed to have been either fully loaded, or merged up until this offset.
 	segmentOffset int
+
+	// checkpoints maps a store segment to the block within it at which all the stores have a
+	// full KV, saved by a previous request whose linear handoff fell in that segment. The
+	// segment's store jobs resume from there instead of the segment's start.
+	checkpoints map[int]uint64
+
+	// jobScope and stageModuleHashes identify the jobs of this request in the `inFlight`
+	// registry, to await identical jobs of other requests instead of scheduling them.
+	jobScope          string
+	stageModuleHashes [][]string
+	inFlight          *InFlightJobs
+
+	// jobSegments is the number of consecutive segments grouped in the jobs of each stage,
+	// see SizeJobs. The scheduled jobs are tracked in `jobs` by their first unit, their
+	// duration feeding the per-block cost of their stage in `costs`.
+	jobSegments []int
+	jobs        map[Unit]*stageJob
+	costs       *plan.JobCosts
+}
+
+type stageJob struct {
+	segments int
+	blocks   uint64
+	start    time.Time // set by StartJob, when dispatched to a worker
 }
 type stageStates []UnitState
 
@@ -70,6 +95,15 @@ func NewStages(
 		ctx:             ctx,
 		logger:          reqctx.Logger(ctx),
 		globalSegmenter: reqPlan.BackprocessSegmenter(),
+		inFlight:        InFlight,
+		jobs:            make(map[Unit]*stageJob),
+		costs:           plan.Costs,
+	}
+	if params, found := reqctx.GetTier2RequestParameters(ctx); found {
+		out.jobScope = params.StateStoreURL
+	}
+	if details := reqctx.Details(ctx); details != nil {
+		out.jobScope += "/" + details.CacheTag
 	}
 	if reqPlan.BuildStores != nil {
 		out.storeSegmenter = reqPlan.StoresSegmenter()
@@ -115,6 +149,7 @@ func NewStages(
 		stageSegmenter := segmenter.WithInitialBlock(stageLowestInitBlock)
 		stage := NewStage(idx, kind, stageSegmenter, moduleStates, allModules)
 		out.stages = append(out.stages, stage)
+		out.stageModuleHashes = append(out.stageModuleHashes, moduleHashes(outputGraph, allModules))
 	}
 
 	out.initSegmentsOffset(reqPlan)
@@ -122,6 +157,78 @@ func NewStages(
 	return out
 }
 
+func moduleHashes(outputGraph *outputmodules.Graph, moduleNames []string) (out []string) {
+	hashes := outputGraph.ModuleHashes()
+	for _, name := range moduleNames {
+		if hashes == nil {
+			out = append(out, name)
+			continue
+		}
+		out = append(out, hashes.Get(name))
+	}
+	return out
+}
+
+// IsStoreStage returns whether the partial files of the jobs of stage `idx` are squashed.
+func (s *Stages) IsStoreStage(idx int) bool {
+	return s.stages[idx].kind == KindStore
+}
+
+// ClaimJob registers the job of `unit` over `rng` as in flight, see InFlightJobs.Claim.
+func (s *Stages) ClaimJob(unit Unit, rng *block.Range) (job *InFlightJob, owner bool) {
+	return s.inFlight.Claim(newJobKey(s.jobScope, unit.Stage, s.stageModuleHashes[unit.Stage], rng))
+}
+
+// SizeJobs groups consecutive segments of each stage in jobs taking about `target`, from
+// the per-block cost measured on the previous jobs of the stage, up to `maxSegments`
+// segments per job. Cheap stages thus dispatch fewer, larger jobs.
+func (s *Stages) SizeJobs(reqPlan *plan.RequestPlan, target time.Duration, maxSegments uint64) {
+	s.jobSegments = make([]int, len(s.stages))
+	for idx := range s.stages {
+		perBlock, _ := s.costs.PerBlock(s.stageCostKey(idx))
+		s.jobSegments[idx] = reqPlan.JobSegments(perBlock, target, maxSegments)
+		if s.jobSegments[idx] > 1 {
+			s.logger.Info("grouping segments in jobs", zap.Int("stage", idx), zap.Int("segments", s.jobSegments[idx]), zap.Duration("block_cost", perBlock))
+		}
+	}
+}
+
+func (s *Stages) stageCostKey(stageIdx int) string {
+	hashes := append([]string(nil), s.stageModuleHashes[stageIdx]...)
+	sort.Strings(hashes)
+	return strings.Join(hashes, ",")
+}
+
+func (s *Stages) stageJobSegments(stageIdx int) int {
+	if s.jobSegments == nil {
+		return 1
+	}
+	return s.jobSegments[stageIdx]
+}
+
+// StartJob starts the clock of the job starting at `unit`, when it is dispatched to a
+// worker, so that its cost excludes the time it was queued.
+func (s *Stages) StartJob(unit Unit) {
+	if job, found := s.jobs[unit]; found && job.start.IsZero() {
+		job.start = time.Now()
+	}
+}
+
+// endJob forgets the completed job starting at `unit`, returning the number of segments
+// it covers. Its duration is recorded as the cost of its stage, unless it was never
+// dispatched by this request.
+func (s *Stages) endJob(unit Unit) (segments int) {
+	job, found := s.jobs[unit]
+	if !found {
+		return 1
+	}
+	delete(s.jobs, unit)
+	if s.costs != nil && !job.start.IsZero() {
+		s.costs.Record(s.stageCostKey(unit.Stage), job.blocks, time.Since(job.start))
+	}
+	return job.segments
+}
+
 func layerKind(layer outputmodules.LayerModules) Kind {
 	if layer.IsStoreLayer() {
 		return KindStore
@@ -152,6 +259,31 @@ func (s *Stages) AllStoresCompleted() bool {
 	return true
 }
 
+// AllMapsCompleted returns whether the outputs of all the segments of the map stage are
+// written, true when there is no map stage.
+func (s *Stages) AllMapsCompleted() bool {
+	if s.mapSegmenter == nil {
+		return true
+	}
+	lastIdx := len(s.stages) - 1
+	if lastIdx < 0 || s.stages[lastIdx].kind != KindMap {
+		return true
+	}
+
+	stage := s.stages[lastIdx]
+	for seg := stage.segmenter.FirstIndex(); seg <= stage.segmenter.LastIndex(); seg++ {
+		if rng := stage.segmenter.Range(seg); rng == nil || rng.Len() == 0 {
+			continue
+		}
+		switch s.getState(Unit{Segment: seg, Stage: lastIdx}) {
+		case UnitCompleted, UnitPartialPresent, UnitNoOp:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // UpdateStats is gated to be called at most once per second. It runs the first time it is called.
 func (s *Stages) UpdateStats() {
 	if time.Since(s.lastStatUpdate) < 1*time.Second {
@@ -255,10 +387,11 @@ func (s *Stages) CmdTryMerge(stageIdx int) loop.Cmd {
 	s.MarkSegmentMerging(mergeUnit)
 
 	return func() loop.Msg {
-		if err := s.multiSquash(stage, mergeUnit); err != nil {
+		writes, err := s.multiSquash(stage, mergeUnit)
+		if err != nil {
 			return MsgMergeFailed{Unit: mergeUnit, Error: err}
 		}
-		return MsgMergeFinished{Unit: mergeUnit}
+		return MsgMergeFinished{Unit: mergeUnit, WaitWritten: writes.Wait}
 	}
 }
 
@@ -374,6 +507,9 @@ func (s *Stages) NextJob() (Unit, *block.Range) {
 			}
 
 			r := stage.segmenter.Range(unit.Segment)
+			if stage.kind == KindStore {
+				r = s.storeRange(stage.segmenter, unit.Segment)
+			}
 			if r.Len() == 0 {
 				// empty units get marked as completed automatically
 				s.markSegmentCompleted(unit)
@@ -381,6 +517,20 @@ func (s *Stages) NextJob() (Unit, *block.Range) {
 			}
 
 			s.markSegmentScheduled(unit)
+			segments := 1
+			for ; segments < s.stageJobSegments(stageIdx); segments++ {
+				next := Unit{Segment: segmentIdx + segments, Stage: stageIdx}
+				if next.Segment > stage.segmenter.LastIndex() || s.getState(next) != UnitPending {
+					break
+				}
+				s.markSegmentScheduled(next)
+			}
+			if segments > 1 {
+				r = block.NewRange(r.StartBlock, stage.segmenter.JobRange(segmentIdx, segments).ExclusiveEndBlock)
+			}
+			if s.jobs != nil {
+				s.jobs[unit] = &stageJob{segments: segments, blocks: r.Len()}
+			}
 			return unit, r
 		}
 	}
@@ -405,6 +555,10 @@ func (s *Stages) dependenciesCompleted(u Unit) bool {
 	if u.Stage == 0 {
 		return true
 	}
+	if _, found := s.checkpoints[u.Segment]; found && s.stages[u.Stage].kind == KindStore {
+		// the stores of the previous stages are loaded from the checkpoint
+		return true
+	}
 	for i := u.Stage - 1; i >= 0; i-- {
 		state := s.getState(Unit{Segment: u.Segment - 1, Stage: i})
 		if !(state == UnitCompleted || state == UnitNoOp) {
@@ -414,6 +568,16 @@ func (s *Stages) dependenciesCompleted(u Unit) bool {
 	return true
 }
 
+// storeRange returns the range of `segment` to process for stores, starting at
+// the segment's checkpoint if it has one.
+func (s *Stages) storeRange(segmenter *block.Segmenter, segment int) *block.Range {
+	rng := segmenter.Range(segment)
+	if checkpoint, found := s.checkpoints[segment]; found && rng != nil && checkpoint > rng.StartBlock && checkpoint < rng.ExclusiveEndBlock {
+		return block.NewRange(checkpoint, rng.ExclusiveEndBlock)
+	}
+	return rng
+}
+
 func (s *Stages) previousUnitComplete(u Unit) bool {
 	state := s.getState(Unit{Segment: u.Segment - 1, Stage: u.Stage})
 	return state == UnitCompleted || state == UnitNoOp
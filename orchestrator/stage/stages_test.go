@@ -2,15 +2,22 @@ package stage
 
 import (
 	"context"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/streamingfast/dstore"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 
 	"github.com/streamingfast/substreams/block"
 	"github.com/streamingfast/substreams/orchestrator/plan"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/storage/store"
 )
 
 func TestNewStages(t *testing.T) {
@@ -189,6 +196,71 @@ func id(segment, stage int) Unit {
 	return Unit{Stage: stage, Segment: segment}
 }
 
+func TestStagesSizeJobs(t *testing.T) {
+	reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 5, 5, 40, 40, true)
+	assert.NoError(t, err)
+	stages := NewStages(
+		context.Background(),
+		outputmodules.TestGraphStagedModules(5, 5, 5, 5, 5),
+		reqPlan,
+		nil,
+	)
+	stages.stageModuleHashes = [][]string{{"a"}, {"b"}, {"c"}}
+	stages.costs = plan.NewJobCosts()
+	stages.costs.Record(stages.stageCostKey(0), 10, 10*time.Millisecond) // cheap stage
+	stages.costs.Record(stages.stageCostKey(1), 10, 10*time.Second)      // expensive stage
+	stages.SizeJobs(reqPlan, 50*time.Millisecond, 3)
+
+	stages.allocSegments(0)
+	stages.setState(Unit{Stage: 2, Segment: 0}, UnitNoOp)
+
+	unit, r := stages.NextJob()
+	assert.Equal(t, id(0, 1), unit)
+	assert.Equal(t, block.ParseRange("5-10"), r)
+
+	unit, r = stages.NextJob()
+	assert.Equal(t, id(0, 0), unit)
+	assert.Equal(t, block.ParseRange("5-30"), r, "cheap stage, 3 segments per job")
+
+	segmentStateEquals(t, stages, `
+S:SSS
+S:S..
+M:N..`)
+
+	stages.MarkSegmentPartialPresent(unit)
+
+	segmentStateEquals(t, stages, `
+S:PPP
+S:S..
+M:N..`)
+	_, found := stages.jobs[unit]
+	assert.False(t, found)
+}
+
+func TestStagesJobCost(t *testing.T) {
+	reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 5, 5, 40, 40, true)
+	assert.NoError(t, err)
+	stages := NewStages(
+		context.Background(),
+		outputmodules.TestGraphStagedModules(5, 5, 5, 5, 5),
+		reqPlan,
+		nil,
+	)
+	stages.stageModuleHashes = [][]string{{"a"}, {"b"}, {"c"}}
+	stages.costs = plan.NewJobCosts()
+
+	queued, _ := stages.NextJob()
+	stages.MarkSegmentPartialPresent(queued)
+	_, found := stages.costs.PerBlock(stages.stageCostKey(queued.Stage))
+	assert.False(t, found, "a job never dispatched has no cost")
+
+	dispatched, _ := stages.NextJob()
+	stages.StartJob(dispatched)
+	stages.MarkSegmentPartialPresent(dispatched)
+	_, found = stages.costs.PerBlock(stages.stageCostKey(dispatched.Stage))
+	assert.True(t, found)
+}
+
 func segmentStateEquals(t *testing.T, s *Stages, segments string) {
 	t.Helper()
 
@@ -236,3 +308,102 @@ func TestStages_allocSegments(t *testing.T) {
 		})
 	}
 }
+
+func Test_findCheckpoints(t *testing.T) {
+	segmenter := block.NewSegmenter(100, 0, 1000)
+	fullKVs := func(module string, initialBlock uint64, ends ...uint64) store.FileInfos {
+		var out store.FileInfos
+		for _, end := range ends {
+			out = append(out, store.NewCompleteFileInfo(module, initialBlock, end))
+		}
+		return out
+	}
+
+	checkpoints := findCheckpoints(segmenter, map[string]store.FileInfos{
+		"A": fullKVs("A", 0, 100, 200, 250, 280, 320),
+		"B": fullKVs("B", 50, 100, 200, 250, 320),
+		"C": fullKVs("C", 0, 100, 200, 250, 300, 320, 450),
+	})
+	assert.Equal(t, map[int]uint64{2: 250, 3: 320}, checkpoints)
+
+	s := &Stages{checkpoints: checkpoints}
+	assert.Equal(t, "[250, 300)", s.storeRange(segmenter, 2).String())
+	assert.Equal(t, "[320, 400)", s.storeRange(segmenter, 3).String())
+	assert.Equal(t, "[400, 500)", s.storeRange(segmenter, 4).String())
+}
+
+func TestStages_pruneCheckpointKVs(t *testing.T) {
+	segmenter := block.NewSegmenter(100, 0, 1000)
+
+	deleted := make(chan string, 10)
+	objStore := dstore.NewMockStore(nil)
+	objStore.DeleteObjectFunc = func(_ context.Context, base string) error {
+		deleted <- base
+		return nil
+	}
+	config, err := store.NewConfig("A", 0, "abc", pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string", objStore)
+	require.NoError(t, err)
+
+	var files store.FileInfos
+	for _, end := range []uint64{100, 150, 200, 250, 280, 320} {
+		files = append(files, store.NewCompleteFileInfo("A", 0, end))
+	}
+	s := &Stages{logger: zap.NewNop()}
+	checkpointKVs := s.pruneCheckpointKVs(segmenter, store.ConfigMap{"A": config}, map[string]store.FileInfos{"A": files}, true)
+	assert.Equal(t, []string{"[0, 280)", "[0, 320)"}, fileRanges(checkpointKVs["A"]))
+	select {
+	case name := <-deleted:
+		t.Fatalf("read-only pruning deleted %s", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	checkpointKVs = s.pruneCheckpointKVs(segmenter, store.ConfigMap{"A": config}, map[string]store.FileInfos{"A": files}, false)
+	assert.Equal(t, []string{"[0, 280)", "[0, 320)"}, fileRanges(checkpointKVs["A"]))
+
+	var got []string
+	for i := 0; i < 2; i++ {
+		select {
+		case name := <-deleted:
+			got = append(got, name)
+		case <-time.After(time.Second):
+			t.Fatal("superseded checkpoints not deleted")
+		}
+	}
+	assert.ElementsMatch(t, []string{"0000000150-0000000000.kv", "0000000250-0000000000.kv"}, got)
+
+	modState := &StoreModuleState{segmenter: segmenter, checkpointKVs: checkpointKVs["A"]}
+	assert.Empty(t, modState.takeCheckpointKVs(2, 280), "the saved checkpoint is kept")
+	assert.Equal(t, []string{"[0, 280)"}, fileRanges(modState.takeCheckpointKVs(2, 300)))
+	assert.Equal(t, []string{"[0, 320)"}, fileRanges(modState.checkpointKVs))
+}
+
+func fileRanges(files store.FileInfos) (out []string) {
+	for _, file := range files {
+		out = append(out, file.Range.String())
+	}
+	sort.Strings(out)
+	return
+}
+
+func TestStagesAllMapsCompleted(t *testing.T) {
+	reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 5, 5, 40, 40, true)
+	assert.NoError(t, err)
+	stages := NewStages(
+		context.Background(),
+		outputmodules.TestGraphStagedModules(5, 5, 5, 5, 5),
+		reqPlan,
+		nil,
+	)
+	mapStage := len(stages.stages) - 1
+	segmenter := stages.stages[mapStage].segmenter
+	stages.allocSegments(segmenter.LastIndex())
+	assert.False(t, stages.AllMapsCompleted())
+
+	for seg := segmenter.FirstIndex(); seg < segmenter.LastIndex(); seg++ {
+		stages.setState(Unit{Stage: mapStage, Segment: seg}, UnitCompleted)
+	}
+	assert.False(t, stages.AllMapsCompleted(), "the last segment is not written")
+
+	stages.setState(Unit{Stage: mapStage, Segment: segmenter.LastIndex()}, UnitPartialPresent)
+	assert.True(t, stages.AllMapsCompleted())
+}
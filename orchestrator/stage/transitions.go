@@ -107,11 +107,19 @@ func (s *Stages) MarkSegmentPending(u Unit) {
 	)
 }
 
-func (s *Stages) MarkSegmentPartialPresent(u Unit) {
-	s.transition(u, UnitPartialPresent,
-		UnitScheduled, // reported by working completing its generation of a partial
-		UnitPending,   // from initial storage state snapshot
-	)
+// MarkSegmentPartialPresent marks the segment of `u` partial present, along with the
+// following segments of the job starting at `u` when it covers several. The unit of the
+// last segment of the job is returned.
+func (s *Stages) MarkSegmentPartialPresent(u Unit) (last Unit) {
+	segments := s.endJob(u)
+	for i := 0; i < segments; i++ {
+		last = Unit{Segment: u.Segment + i, Stage: u.Stage}
+		s.transition(last, UnitPartialPresent,
+			UnitScheduled, // reported by working completing its generation of a partial
+			UnitPending,   // from initial storage state snapshot
+		)
+	}
+	return last
 }
 
 func (s *Stages) markSegmentScheduled(u Unit) {
@@ -0,0 +1,183 @@
+package work
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Headers of the ProcessRange responses through which a tier2 instance advertises its
+// capacity: the number of requests it is processing, this one included, and the maximum
+// it accepts (0 for no maximum). They are sent on overloaded rejections too.
+const (
+	Tier2InstanceHeader       = "substreams-tier2-instance"
+	Tier2ActiveRequestsHeader = "substreams-tier2-active-requests"
+	Tier2MaxRequestsHeader    = "substreams-tier2-max-requests"
+)
+
+// Tier2CapacityTTL is how long the capacity advertised by a tier2 instance is trusted.
+const Tier2CapacityTTL = 10 * time.Second
+
+func CapacityHeaders(instance string, activeRequests, maxRequests int64) metadata.MD {
+	return metadata.Pairs(
+		Tier2InstanceHeader, instance,
+		Tier2ActiveRequestsHeader, strconv.FormatInt(activeRequests, 10),
+		Tier2MaxRequestsHeader, strconv.FormatInt(maxRequests, 10),
+	)
+}
+
+// Tier2Capacity tracks the free request slots advertised by the tier2 instances, shared by
+// the worker pools of all the requests of a tier1. A slot is reserved for each job
+// launched, and released when the job completes, until the next advertisement of the
+// instance replaces the count.
+type Tier2Capacity struct {
+	mu           sync.Mutex
+	instances    map[string]*instanceCapacity
+	reservations map[string]*reservation // by worker ID
+	ttl          time.Duration
+	now          func() time.Time
+}
+
+type instanceCapacity struct {
+	free      int64
+	max       int64
+	unbounded bool
+	updated   time.Time
+}
+
+// reservation is a slot taken on `instance` while its advertisement was `capacity`.
+type reservation struct {
+	instance string
+	capacity *instanceCapacity
+}
+
+func NewTier2Capacity() *Tier2Capacity {
+	return &Tier2Capacity{
+		instances:    make(map[string]*instanceCapacity),
+		reservations: make(map[string]*reservation),
+		ttl:          Tier2CapacityTTL,
+		now:          time.Now,
+	}
+}
+
+// Update records the capacity advertised in the headers of a ProcessRange response,
+// returning the advertising instance, or an empty string if there is no advertisement.
+// The advertisements older than the TTL are dropped.
+func (c *Tier2Capacity) Update(md metadata.MD) string {
+	instance := firstHeader(md, Tier2InstanceHeader)
+	active, err := strconv.ParseInt(firstHeader(md, Tier2ActiveRequestsHeader), 10, 64)
+	if err != nil || instance == "" {
+		return ""
+	}
+	max, err := strconv.ParseInt(firstHeader(md, Tier2MaxRequestsHeader), 10, 64)
+	if err != nil {
+		return ""
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, capacity := range c.instances {
+		if !c.fresh(capacity) {
+			delete(c.instances, name)
+		}
+	}
+	c.instances[instance] = &instanceCapacity{
+		free:      max - active,
+		max:       max,
+		unbounded: max == 0,
+		updated:   c.now(),
+	}
+	return instance
+}
+
+// Available returns whether a fresh advertisement shows a free slot. `known` is false when
+// no instance advertised its capacity within the TTL.
+func (c *Tier2Capacity) Available() (available bool, known bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, capacity := c.mostFree()
+	return capacity != nil, c.anyFresh()
+}
+
+// Reserve takes a slot for the job launched by `workerID` on the instance with the most
+// free slots.
+func (c *Tier2Capacity) Reserve(workerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settle(workerID)
+
+	instance, capacity := c.mostFree()
+	if capacity == nil {
+		return
+	}
+	if !capacity.unbounded {
+		capacity.free--
+	}
+	c.reservations[workerID] = &reservation{instance: instance, capacity: capacity}
+}
+
+// Settle ends the reservation of `workerID`, once its job completed. The reserved slot is
+// given back unless the instance advertised its capacity since, which counts the job if it
+// was served by this instance. The instance that served the job frees its slot with Release.
+func (c *Tier2Capacity) Settle(workerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settle(workerID)
+}
+
+func (c *Tier2Capacity) settle(workerID string) {
+	r, found := c.reservations[workerID]
+	if !found {
+		return
+	}
+	delete(c.reservations, workerID)
+	if current := c.instances[r.instance]; current == r.capacity && !current.unbounded && current.free < current.max {
+		current.free++
+	}
+}
+
+// Release frees a slot of `instance`, once a job it processed completes.
+func (c *Tier2Capacity) Release(instance string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if capacity, found := c.instances[instance]; found && capacity.free < capacity.max {
+		capacity.free++
+	}
+}
+
+func (c *Tier2Capacity) mostFree() (instance string, out *instanceCapacity) {
+	for name, capacity := range c.instances {
+		if !c.fresh(capacity) {
+			continue
+		}
+		if capacity.unbounded {
+			return name, capacity
+		}
+		if capacity.free > 0 && (out == nil || capacity.free > out.free) {
+			instance, out = name, capacity
+		}
+	}
+	return instance, out
+}
+
+func (c *Tier2Capacity) anyFresh() bool {
+	for _, capacity := range c.instances {
+		if c.fresh(capacity) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Tier2Capacity) fresh(capacity *instanceCapacity) bool {
+	return c.now().Sub(capacity.updated) < c.ttl
+}
+
+func firstHeader(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) != 0 {
+		return values[0]
+	}
+	return ""
+}
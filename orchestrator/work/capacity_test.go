@@ -0,0 +1,124 @@
+package work
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTier2Capacity(t *testing.T) {
+	now := time.Now()
+	capacity := NewTier2Capacity()
+	capacity.now = func() time.Time { return now }
+
+	_, known := capacity.Available()
+	assert.False(t, known)
+	assert.Equal(t, "", capacity.Update(metadata.Pairs("host", "a")))
+
+	assert.Equal(t, "a", capacity.Update(CapacityHeaders("a", 3, 4)))
+	assert.Equal(t, "b", capacity.Update(CapacityHeaders("b", 4, 4)))
+	avail, known := capacity.Available()
+	assert.True(t, avail)
+	assert.True(t, known)
+
+	capacity.Reserve("w1")
+	assert.Equal(t, "a", capacity.reservations["w1"].instance)
+	avail, _ = capacity.Available()
+	assert.False(t, avail, "the only free slot of instance a is reserved")
+
+	capacity.Release("b")
+	avail, _ = capacity.Available()
+	assert.True(t, avail)
+
+	now = now.Add(Tier2CapacityTTL)
+	_, known = capacity.Available()
+	assert.False(t, known, "advertisements expired")
+
+	capacity.Update(CapacityHeaders("c", 12, 0))
+	assert.Len(t, capacity.instances, 1, "expired advertisements are dropped")
+	capacity.Reserve("w2")
+	assert.Equal(t, "c", capacity.reservations["w2"].instance)
+	avail, known = capacity.Available()
+	assert.True(t, avail, "instance without maximum always has free slots")
+	assert.True(t, known)
+}
+
+func TestTier2Capacity_Settle(t *testing.T) {
+	capacity := NewTier2Capacity()
+	capacity.Update(CapacityHeaders("a", 2, 4))
+	capacity.Update(CapacityHeaders("b", 3, 4))
+
+	// the job reserved on a is served by b: a gets its slot back once the job completed
+	capacity.Reserve("w1")
+	assert.Equal(t, "a", capacity.reservations["w1"].instance)
+	assert.Equal(t, int64(1), capacity.instances["a"].free)
+	capacity.Update(CapacityHeaders("b", 4, 4))
+	capacity.Release("b")
+	capacity.Settle("w1")
+	assert.Equal(t, int64(2), capacity.instances["a"].free)
+	assert.Equal(t, int64(1), capacity.instances["b"].free)
+	assert.NotContains(t, capacity.reservations, "w1")
+
+	// the job reserved on a is served by a: its advertisement already counts the job
+	capacity.Reserve("w1")
+	capacity.Update(CapacityHeaders("a", 3, 4))
+	capacity.Release("a")
+	capacity.Settle("w1")
+	assert.Equal(t, int64(2), capacity.instances["a"].free)
+
+	// settling twice has no effect
+	capacity.Settle("w1")
+	assert.Equal(t, int64(2), capacity.instances["a"].free)
+	assert.Empty(t, capacity.reservations)
+}
+
+func TestWorkerPool_WithCapacity(t *testing.T) {
+	capacity := NewTier2Capacity()
+	pool := NewWorkerPool(testLocalWorkerContext(), 3, func(logger *zap.Logger) Worker {
+		return NewWorkerFactoryFromFunc(nil)
+	}, capacity)
+
+	// unknown capacity: timed ramp-up with a single worker
+	avail, _ := pool.WorkerAvailable()
+	assert.True(t, avail)
+	worker1 := pool.Borrow()
+	avail, shouldRetry := pool.WorkerAvailable()
+	assert.False(t, avail)
+	assert.True(t, shouldRetry)
+
+	// two free slots advertised: workers are available without waiting for the ramp-up
+	capacity.Update(CapacityHeaders("a", 1, 3))
+	avail, _ = pool.WorkerAvailable()
+	assert.True(t, avail)
+	worker2 := pool.Borrow()
+	avail, _ = pool.WorkerAvailable()
+	assert.True(t, avail)
+	worker3 := pool.Borrow()
+
+	// all the workers are busy
+	avail, shouldRetry = pool.WorkerAvailable()
+	assert.False(t, avail)
+	assert.False(t, shouldRetry)
+
+	// the job of worker3 never reached the instance: its slot is given back
+	pool.Return(worker3)
+	avail, _ = pool.WorkerAvailable()
+	assert.True(t, avail)
+
+	// a worker is idle but the instance is full
+	capacity.Update(CapacityHeaders("a", 3, 3))
+	avail, shouldRetry = pool.WorkerAvailable()
+	assert.False(t, avail)
+	assert.True(t, shouldRetry)
+
+	capacity.Release("a")
+	avail, _ = pool.WorkerAvailable()
+	assert.True(t, avail)
+
+	pool.Return(worker1)
+	pool.Return(worker2)
+	assert.Empty(t, capacity.reservations, "reservations are settled when the workers are returned")
+}
@@ -0,0 +1,204 @@
+package work
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/dauth"
+
+	"github.com/streamingfast/substreams/metrics"
+)
+
+// AnonymousTenant is the tenant of the requests without a user or API key.
+const AnonymousTenant = "anonymous"
+
+// Tenant returns the tenant of the request in `ctx`: its authenticated user, or API key
+// when there is no user.
+func Tenant(ctx context.Context) string {
+	auth := dauth.FromContext(ctx)
+	if auth == nil {
+		return AnonymousTenant
+	}
+	if userID := auth.UserID(); userID != "" {
+		return userID
+	}
+	if apiKeyID := auth.APIKeyID(); apiKeyID != "" {
+		return apiKeyID
+	}
+	return AnonymousTenant
+}
+
+type FairShareConfig struct {
+	MaxConcurrentJobs uint64            // jobs sent to tier2 at once across all the requests
+	DefaultWeight     uint64            // weight of the tenants not in Weights, defaults to 1
+	Weights           map[string]uint64 // tenant -> weight
+	MaxTenantJobs     uint64            // jobs of a tenant sent at once, 0 for no limit besides MaxConcurrentJobs
+	TenantMaxJobs     map[string]uint64 // tenant -> limit, overriding MaxTenantJobs
+}
+
+// FairShare shares the jobs sent to tier2 by all the requests of a tier1 between their
+// tenants. Jobs are queued per tenant, and a free slot goes to the queued tenant with the
+// fewest running jobs relative to its weight, so a tenant with a heavy request cannot
+// starve the others. A nil FairShare grants every job right away.
+type FairShare struct {
+	mu      sync.Mutex
+	config  FairShareConfig
+	running uint64
+	tenants map[string]*tenantJobs
+	seq     uint64
+}
+
+type tenantJobs struct {
+	name    string
+	running uint64
+	queue   []*queuedJob
+}
+
+type queuedJob struct {
+	seq     uint64
+	since   time.Time
+	granted chan struct{}
+}
+
+func NewFairShare(config FairShareConfig) *FairShare {
+	if config.DefaultWeight == 0 {
+		config.DefaultWeight = 1
+	}
+	return &FairShare{
+		config:  config,
+		tenants: make(map[string]*tenantJobs),
+	}
+}
+
+// Acquire waits for a slot for a job of `tenant`, the returned `release` must be called
+// once the job is done. An error is returned when `ctx` is done first.
+func (f *FairShare) Acquire(ctx context.Context, tenant string) (release func(), err error) {
+	if f == nil {
+		return func() {}, nil
+	}
+
+	f.mu.Lock()
+	t := f.tenant(tenant)
+	f.seq++
+	job := &queuedJob{seq: f.seq, since: time.Now(), granted: make(chan struct{})}
+	t.queue = append(t.queue, job)
+	metrics.Tier1TenantQueuedJobs.Inc(tenant)
+	f.dispatch()
+	f.mu.Unlock()
+
+	release = func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.release(t)
+	}
+
+	select {
+	case <-job.granted:
+		return release, nil
+	case <-ctx.Done():
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		select {
+		case <-job.granted:
+			f.release(t)
+		default:
+			f.dequeue(t, job)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (f *FairShare) tenant(name string) *tenantJobs {
+	t, found := f.tenants[name]
+	if !found {
+		t = &tenantJobs{name: name}
+		f.tenants[name] = t
+	}
+	return t
+}
+
+// dispatch grants the free slots to the queued jobs, the lock must be held.
+func (f *FairShare) dispatch() {
+	for f.config.MaxConcurrentJobs == 0 || f.running < f.config.MaxConcurrentJobs {
+		t := f.nextTenant()
+		if t == nil {
+			return
+		}
+
+		job := t.queue[0]
+		t.queue = t.queue[1:]
+		t.running++
+		f.running++
+
+		metrics.Tier1TenantQueuedJobs.Dec(t.name)
+		metrics.Tier1TenantRunningJobs.Inc(t.name)
+		metrics.Tier1TenantJobWaitDuration.ObserveSince(job.since, t.name)
+		close(job.granted)
+	}
+}
+
+// nextTenant returns the tenant with queued jobs and under its limit having the fewest
+// running jobs relative to its weight, the one queued for the longest on a tie.
+func (f *FairShare) nextTenant() (out *tenantJobs) {
+	for _, t := range f.tenants {
+		if len(t.queue) == 0 {
+			continue
+		}
+		if limit := f.tenantLimit(t.name); limit != 0 && t.running >= limit {
+			continue
+		}
+		if out == nil {
+			out = t
+			continue
+		}
+		// compare running/weight without divisions
+		left, right := t.running*f.weight(out.name), out.running*f.weight(t.name)
+		if left < right || (left == right && t.queue[0].seq < out.queue[0].seq) {
+			out = t
+		}
+	}
+	return out
+}
+
+func (f *FairShare) weight(tenant string) uint64 {
+	if weight, found := f.config.Weights[tenant]; found && weight != 0 {
+		return weight
+	}
+	return f.config.DefaultWeight
+}
+
+func (f *FairShare) tenantLimit(tenant string) uint64 {
+	if limit, found := f.config.TenantMaxJobs[tenant]; found {
+		return limit
+	}
+	return f.config.MaxTenantJobs
+}
+
+func (f *FairShare) release(t *tenantJobs) {
+	t.running--
+	f.running--
+	metrics.Tier1TenantRunningJobs.Dec(t.name)
+	f.forgetIdle(t)
+	f.dispatch()
+}
+
+func (f *FairShare) dequeue(t *tenantJobs, job *queuedJob) {
+	for i, queued := range t.queue {
+		if queued == job {
+			t.queue = append(t.queue[:i], t.queue[i+1:]...)
+			metrics.Tier1TenantQueuedJobs.Dec(t.name)
+			break
+		}
+	}
+	f.forgetIdle(t)
+}
+
+func (f *FairShare) forgetIdle(t *tenantJobs) {
+	if t.running == 0 && len(t.queue) == 0 {
+		delete(f.tenants, t.name)
+		metrics.Tier1TenantQueuedJobs.DeleteLabelValues(t.name)
+		metrics.Tier1TenantRunningJobs.DeleteLabelValues(t.name)
+		metrics.Tier1TenantJobWaitDuration.DeleteLabelValues(t.name)
+	}
+}
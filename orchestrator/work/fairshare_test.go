@@ -0,0 +1,151 @@
+package work
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testFairShareJob struct {
+	tenant  string
+	seq     uint64
+	release chan func()
+	granted bool
+}
+
+// testQueueJob starts acquiring a slot for a job of `tenant`, and returns once it is queued or granted
+func testQueueJob(t *testing.T, ctx context.Context, f *FairShare, tenant string) *testFairShareJob {
+	t.Helper()
+
+	f.mu.Lock()
+	seq := f.seq + 1
+	f.mu.Unlock()
+
+	job := &testFairShareJob{tenant: tenant, seq: seq, release: make(chan func(), 1)}
+	go func() {
+		release, err := f.Acquire(ctx, tenant)
+		if err == nil {
+			job.release <- release
+		}
+	}()
+
+	require.Eventually(t, func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.seq >= seq
+	}, time.Second, time.Millisecond)
+	return job
+}
+
+// testGrantedJobs returns the jobs granted a slot among `jobs`, in order, not returned by a
+// previous call. The slots are granted when the jobs are queued or released, so the jobs no
+// longer queued are granted, and their release function is awaited.
+func testGrantedJobs(t *testing.T, f *FairShare, jobs []*testFairShareJob) (out []string, releases []func()) {
+	t.Helper()
+
+	for _, job := range jobs {
+		if job.granted || testIsQueued(f, job) {
+			continue
+		}
+		select {
+		case release := <-job.release:
+			job.granted = true
+			out = append(out, job.tenant)
+			releases = append(releases, release)
+		case <-time.After(time.Second):
+			t.Fatalf("job %d of tenant %q is neither queued nor granted", job.seq, job.tenant)
+		}
+	}
+	return
+}
+
+func testIsQueued(f *FairShare, job *testFairShareJob) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if tenant, found := f.tenants[job.tenant]; found {
+		for _, queued := range tenant.queue {
+			if queued.seq == job.seq {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestFairShare(t *testing.T) {
+	ctx := context.Background()
+	f := NewFairShare(FairShareConfig{MaxConcurrentJobs: 2})
+
+	var jobs []*testFairShareJob
+	for i := 0; i < 4; i++ {
+		jobs = append(jobs, testQueueJob(t, ctx, f, "heavy"))
+	}
+	granted, heavyReleases := testGrantedJobs(t, f, jobs)
+	assert.Equal(t, []string{"heavy", "heavy"}, granted)
+
+	light := testQueueJob(t, ctx, f, "light")
+	granted, _ = testGrantedJobs(t, f, []*testFairShareJob{light})
+	assert.Empty(t, granted, "no free slot")
+
+	heavyReleases[0]()
+	granted, _ = testGrantedJobs(t, f, append(jobs[2:], light))
+	assert.Equal(t, []string{"light"}, granted, "light has no running job, it goes before the heavy jobs queued earlier")
+}
+
+func TestFairShare_Weights(t *testing.T) {
+	ctx := context.Background()
+	f := NewFairShare(FairShareConfig{MaxConcurrentJobs: 3, Weights: map[string]uint64{"a": 2}})
+
+	blocker := testQueueJob(t, ctx, f, "blocker")
+	blocker2 := testQueueJob(t, ctx, f, "blocker")
+	blocker3 := testQueueJob(t, ctx, f, "blocker")
+	_, releases := testGrantedJobs(t, f, []*testFairShareJob{blocker, blocker2, blocker3})
+	require.Len(t, releases, 3)
+
+	var jobs []*testFairShareJob
+	for i := 0; i < 3; i++ {
+		jobs = append(jobs, testQueueJob(t, ctx, f, "a"), testQueueJob(t, ctx, f, "b"))
+	}
+	for _, release := range releases {
+		release()
+	}
+
+	granted, _ := testGrantedJobs(t, f, jobs)
+	assert.ElementsMatch(t, []string{"a", "a", "b"}, granted)
+}
+
+func TestFairShare_TenantLimit(t *testing.T) {
+	ctx := context.Background()
+	f := NewFairShare(FairShareConfig{MaxConcurrentJobs: 5, MaxTenantJobs: 2, TenantMaxJobs: map[string]uint64{"small": 1}})
+
+	var jobs []*testFairShareJob
+	for i := 0; i < 3; i++ {
+		jobs = append(jobs, testQueueJob(t, ctx, f, "small"), testQueueJob(t, ctx, f, "other"))
+	}
+	granted, _ := testGrantedJobs(t, f, jobs)
+	assert.ElementsMatch(t, []string{"small", "other", "other"}, granted)
+}
+
+func TestFairShare_Canceled(t *testing.T) {
+	f := NewFairShare(FairShareConfig{MaxConcurrentJobs: 1})
+	release, err := f.Acquire(context.Background(), "a")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = f.Acquire(ctx, "b")
+	assert.Equal(t, context.Canceled, err)
+
+	release()
+	f.mu.Lock()
+	assert.Empty(t, f.tenants, "idle tenants are forgotten")
+	f.mu.Unlock()
+
+	var nilFairShare *FairShare
+	release, err = nilFairShare.Acquire(context.Background(), "a")
+	assert.NoError(t, err)
+	release()
+}
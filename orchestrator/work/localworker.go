@@ -0,0 +1,129 @@
+package work
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/orchestrator/loop"
+	"github.com/streamingfast/substreams/orchestrator/response"
+	"github.com/streamingfast/substreams/orchestrator/stage"
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	"github.com/streamingfast/substreams/reqctx"
+)
+
+// ProcessRangeFunc processes a tier2 request in the current process, giving its responses
+// to `respFunc`, see `service.Tier2Service.LocalProcessRange`.
+type ProcessRangeFunc func(ctx context.Context, request *pbssinternal.ProcessRangeRequest, respFunc substreams.ResponseFunc) error
+
+// NewLocalWorkerFactory returns a factory of workers processing their jobs in-process with
+// `processRange`. The workers of all the requests share `maxConcurrentJobs` slots, a job
+// waits for a free slot before being processed.
+func NewLocalWorkerFactory(processRange ProcessRangeFunc, maxConcurrentJobs uint64) WorkerFactory {
+	if maxConcurrentJobs == 0 {
+		maxConcurrentJobs = 1
+	}
+	slots := make(chan struct{}, maxConcurrentJobs)
+
+	return func(logger *zap.Logger) Worker {
+		return NewLocalWorker(processRange, slots, logger)
+	}
+}
+
+// LocalWorker runs the tier2 jobs in the tier1 process instead of sending them to a
+// remote tier2, removing the need for a separate tier2 deployment.
+type LocalWorker struct {
+	processRange ProcessRangeFunc
+	slots        chan struct{}
+	logger       *zap.Logger
+	id           uint64
+}
+
+func NewLocalWorker(processRange ProcessRangeFunc, slots chan struct{}, logger *zap.Logger) *LocalWorker {
+	return &LocalWorker{
+		processRange: processRange,
+		slots:        slots,
+		logger:       logger,
+		id:           atomic.AddUint64(&lastWorkerID, 1),
+	}
+}
+
+func (w *LocalWorker) ID() string {
+	return fmt.Sprintf("%d", w.id)
+}
+
+func (w *LocalWorker) Work(ctx context.Context, unit stage.Unit, workRange *block.Range, moduleNames []string, upstream *response.Stream) loop.Cmd {
+	request := NewRequest(ctx, reqctx.Details(ctx), unit.Stage, workRange)
+	logger := reqctx.Logger(ctx)
+
+	return func() loop.Msg {
+		startTime := time.Now()
+
+		select {
+		case w.slots <- struct{}{}:
+		case <-ctx.Done():
+			logger.Debug("job canceled waiting for a local slot", zap.Object("unit", unit), zap.Error(ctx.Err()))
+			return MsgJobFailed{Unit: unit, Error: ctx.Err()}
+		}
+		defer func() { <-w.slots }()
+
+		w.logger.Info("launching local worker",
+			zap.Uint64("start_block_num", request.StartBlockNum),
+			zap.Uint64("stop_block_num", request.StopBlockNum),
+			zap.Uint32("stage", request.Stage),
+			zap.String("output_module", request.OutputModule),
+			zap.Duration("slot_wait", time.Since(startTime)),
+		)
+
+		if err := w.work(ctx, request); err != nil {
+			if errors.Is(err, context.Canceled) {
+				logger.Debug("job canceled", zap.Object("unit", unit), zap.Error(err))
+			} else {
+				logger.Warn("job failed", zap.Object("unit", unit), zap.Strings("module_name", moduleNames), zap.Duration("duration", time.Since(startTime)), zap.Error(err))
+			}
+			return MsgJobFailed{Unit: unit, Error: err}
+		}
+
+		timeTook := time.Since(startTime)
+		logger.Info(
+			"job completed",
+			zap.Object("unit", unit),
+			zap.Strings("module_name", moduleNames),
+			zap.Float64("duration", timeTook.Seconds()),
+			zap.Float64("processing_time_per_block", timeTook.Seconds()/float64(request.StopBlockNum-request.StartBlockNum)),
+		)
+		return MsgJobSucceeded{
+			Unit:   unit,
+			Worker: w,
+		}
+	}
+}
+
+func (w *LocalWorker) work(ctx context.Context, request *pbssinternal.ProcessRangeRequest) (err error) {
+	ctx, span := reqctx.WithSpan(ctx, fmt.Sprintf("substreams/tier1/schedule/%s/%d-%d", request.OutputModule, request.StartBlockNum, request.StopBlockNum))
+	defer span.EndWithErr(&err)
+
+	stats := reqctx.ReqStats(ctx)
+	jobIdx := stats.RecordNewSubrequest(request.Stage, request.StartBlockNum, request.StopBlockNum)
+	defer func() { stats.RecordEndSubrequest(jobIdx, err == nil) }()
+
+	err = w.processRange(ctx, request, func(respAny substreams.ResponseFromAnyTier) error {
+		if r, ok := respAny.(*pbssinternal.ProcessRangeResponse).Type.(*pbssinternal.ProcessRangeResponse_Update); ok {
+			stats.RecordJobUpdate(jobIdx, r.Update)
+		}
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("local work failed: %w", err)
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,98 @@
+package work
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/metrics"
+	"github.com/streamingfast/substreams/orchestrator/stage"
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/reqctx"
+)
+
+func testLocalWorkerContext() context.Context {
+	stats := metrics.NewReqStats(&metrics.Config{}, zap.NewNop())
+	stats.RecordStages([]*pbsubstreamsrpc.Stage{{Modules: []string{"mod"}}})
+
+	ctx := reqctx.WithRequest(context.Background(), &reqctx.RequestDetails{OutputModule: "mod"})
+	ctx = reqctx.WithTier2RequestParameters(ctx, reqctx.Tier2RequestParameters{})
+	return reqctx.WithReqStats(ctx, stats)
+}
+
+func TestLocalWorker_Work(t *testing.T) {
+	var running, maxRunning int64
+	factory := NewLocalWorkerFactory(func(ctx context.Context, request *pbssinternal.ProcessRangeRequest, respFunc substreams.ResponseFunc) error {
+		current := atomic.AddInt64(&running, 1)
+		defer atomic.AddInt64(&running, -1)
+		for {
+			seen := atomic.LoadInt64(&maxRunning)
+			if current <= seen || atomic.CompareAndSwapInt64(&maxRunning, seen, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		if request.StartBlockNum == 30 {
+			return fmt.Errorf("failed at block 35")
+		}
+		return respFunc(&pbssinternal.ProcessRangeResponse{Type: &pbssinternal.ProcessRangeResponse_Update{Update: &pbssinternal.Update{ProcessedBlocks: 10}}})
+	}, 2)
+
+	ctx := testLocalWorkerContext()
+	msgs := make([]interface{}, 5)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			worker := factory(zap.NewNop())
+			msgs[i] = worker.Work(ctx, stage.Unit{Segment: i}, block.NewRange(uint64(i*10), uint64(i*10+10)), []string{"mod"}, nil)()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(2), maxRunning)
+	for i, msg := range msgs {
+		if i == 3 {
+			require.IsType(t, MsgJobFailed{}, msg)
+			assert.ErrorContains(t, msg.(MsgJobFailed).Error, "failed at block 35")
+			continue
+		}
+		assert.IsType(t, MsgJobSucceeded{}, msg)
+	}
+	_, jobs := reqctx.ReqStats(ctx).MedianJobBlockDuration(0)
+	assert.Equal(t, 4, jobs, "only the succeeded jobs are timed")
+}
+
+func TestLocalWorker_Work_Canceled(t *testing.T) {
+	factory := NewLocalWorkerFactory(func(ctx context.Context, request *pbssinternal.ProcessRangeRequest, respFunc substreams.ResponseFunc) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, 1)
+
+	ctx, cancel := context.WithCancel(testLocalWorkerContext())
+	first := factory(zap.NewNop()).Work(ctx, stage.Unit{Segment: 0}, block.NewRange(0, 10), []string{"mod"}, nil)
+	second := factory(zap.NewNop()).Work(ctx, stage.Unit{Segment: 1}, block.NewRange(10, 20), []string{"mod"}, nil)
+
+	firstMsg := make(chan interface{})
+	go func() { firstMsg <- first() }()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	// the second job never got a slot
+	assert.Equal(t, MsgJobFailed{Unit: stage.Unit{Segment: 1}, Error: context.Canceled}, second())
+	assert.Equal(t, MsgJobFailed{Unit: stage.Unit{Segment: 0}, Error: context.Canceled}, <-firstMsg)
+	_, jobs := reqctx.ReqStats(ctx).MedianJobBlockDuration(0)
+	assert.Zero(t, jobs, "canceled jobs are not timed")
+}
@@ -8,8 +8,9 @@ import (
 // Messages
 
 type MsgJobFailed struct {
-	Unit  stage.Unit
-	Error error
+	Unit   stage.Unit
+	Error  error
+	Worker Worker // set when the worker can be returned to the pool
 }
 
 type MsgJobSucceeded struct {
@@ -29,6 +29,7 @@ import (
 	"github.com/streamingfast/substreams/orchestrator/stage"
 	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
 	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/storage/failure"
 	"github.com/streamingfast/substreams/storage/store"
 )
 
@@ -69,14 +70,18 @@ type WorkerFactory = func(logger *zap.Logger) Worker
 
 type RemoteWorker struct {
 	clientFactory client.InternalClientFactory
+	capacity      *Tier2Capacity
 	tracer        ttrace.Tracer
 	logger        *zap.Logger
 	id            uint64
 }
 
-func NewRemoteWorker(clientFactory client.InternalClientFactory, logger *zap.Logger) *RemoteWorker {
+// NewRemoteWorker creates a worker sending its jobs to the tier2 of `clientFactory`, which
+// records the capacity advertised by the tier2 instances in `capacity` when it isn't nil.
+func NewRemoteWorker(clientFactory client.InternalClientFactory, capacity *Tier2Capacity, logger *zap.Logger) *RemoteWorker {
 	return &RemoteWorker{
 		clientFactory: clientFactory,
+		capacity:      capacity,
 		tracer:        otel.GetTracerProvider().Tracer("worker"),
 		logger:        logger,
 		id:            atomic.AddUint64(&lastWorkerID, 1),
@@ -100,6 +105,8 @@ func NewRequest(ctx context.Context, req *reqctx.RequestDetails, stageIndex int,
 		OutputModule:  req.OutputModule,
 		Stage:         uint32(stageIndex),
 
+		AdditionalOutputModules: req.AdditionalOutputModules,
+
 		MeteringConfig:       tier2ReqParams.MeteringConfig,
 		FirstStreamableBlock: tier2ReqParams.FirstStreamableBlock,
 		MergedBlocksStore:    tier2ReqParams.MergedBlockStoreURL,
@@ -213,7 +220,8 @@ func (w *RemoteWorker) work(ctx context.Context, request *pbssinternal.ProcessRa
 
 	stats := reqctx.ReqStats(ctx)
 	jobIdx := stats.RecordNewSubrequest(request.Stage, request.StartBlockNum, request.StopBlockNum)
-	defer stats.RecordEndSubrequest(jobIdx)
+	succeeded := false
+	defer func() { stats.RecordEndSubrequest(jobIdx, succeeded) }()
 
 	ctx = dauth.FromContext(ctx).ToOutgoingGRPCContext(ctx)
 	if headers.IsSet() {
@@ -253,6 +261,18 @@ func (w *RemoteWorker) work(ctx context.Context, request *pbssinternal.ProcessRa
 
 	span.SetAttributes(attribute.String("substreams.remote_hostname", remoteHostname))
 
+	// the slot taken on the instance is freed once it processed the job, not when it rejected it
+	accepted := false
+	if w.capacity != nil {
+		if instance := w.capacity.Update(meta); instance != "" {
+			defer func() {
+				if accepted {
+					w.capacity.Release(instance)
+				}
+			}()
+		}
+	}
+
 	for {
 		resp, err := stream.Recv()
 
@@ -264,6 +284,7 @@ func (w *RemoteWorker) work(ctx context.Context, request *pbssinternal.ProcessRa
 		}
 
 		if resp != nil {
+			accepted = true
 			switch r := resp.Type.(type) {
 			case *pbssinternal.ProcessRangeResponse_Update:
 				stats.RecordJobUpdate(jobIdx, r.Update)
@@ -281,6 +302,7 @@ func (w *RemoteWorker) work(ctx context.Context, request *pbssinternal.ProcessRa
 
 			case *pbssinternal.ProcessRangeResponse_Completed:
 				logger.Debug("worker done")
+				succeeded = true
 				return &Result{
 					PartialFilesWritten: toRPCPartialFiles(r.Completed),
 				}
@@ -289,13 +311,14 @@ func (w *RemoteWorker) work(ctx context.Context, request *pbssinternal.ProcessRa
 
 		if err != nil {
 			if err == io.EOF {
+				succeeded = true
 				return &Result{}
 			}
 			if ctx.Err() != nil {
 				return &Result{Error: ctx.Err()}
 			}
 			if grpcErr := dgrpc.AsGRPCError(err); grpcErr.Code() == codes.InvalidArgument {
-				return &Result{Error: err}
+				return &Result{Error: failure.FromGRPCError(err)}
 			}
 			return &Result{
 				Error: NewRetryableErr(fmt.Errorf("receiving stream resp: %w", err)),
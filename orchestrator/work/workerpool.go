@@ -10,8 +10,10 @@ import (
 )
 
 type WorkerPool struct {
-	workers []*WorkerStatus
-	started *time.Time
+	workers  []*WorkerStatus
+	started  *time.Time
+	capacity *Tier2Capacity
+	held     int // free workers set aside for the jobs waiting for their turn, see Hold
 }
 
 type WorkerState int
@@ -27,7 +29,11 @@ type WorkerStatus struct {
 	Worker Worker
 }
 
-func NewWorkerPool(ctx context.Context, workerCount int, workerFactory WorkerFactory) *WorkerPool {
+// NewWorkerPool creates `workerCount` workers. When the tier2 instances advertise their
+// capacity in `capacity`, which can be nil, workers are only made available while the
+// instances have free slots. Otherwise, a single worker is available for the first
+// seconds, after which they all are.
+func NewWorkerPool(ctx context.Context, workerCount int, workerFactory WorkerFactory, capacity *Tier2Capacity) *WorkerPool {
 	logger := reqctx.Logger(ctx)
 
 	logger.Debug("initializing worker pool", zap.Int("worker_count", workerCount))
@@ -46,8 +52,9 @@ func NewWorkerPool(ctx context.Context, workerCount int, workerFactory WorkerFac
 
 	now := time.Now()
 	return &WorkerPool{
-		workers: workers,
-		started: &now,
+		workers:  workers,
+		started:  &now,
+		capacity: capacity,
 	}
 }
 
@@ -69,20 +76,75 @@ func (p *WorkerPool) inRampupPhase() bool {
 }
 
 func (p *WorkerPool) WorkerAvailable() (avail bool, shouldRetry bool) {
+	if p.capacity != nil {
+		if capacityAvail, known := p.capacity.Available(); known {
+			return p.workerAvailableWithCapacity(capacityAvail)
+		}
+	}
+
 	if p.inRampupPhase() {
 		p.rampupWorkers()
 	}
+	if p.freeWorkers() > p.held {
+		return true, false
+	}
+	return false, p.inRampupPhase()
+}
+
+// workerAvailableWithCapacity replaces the timed ramp-up when the tier2 capacity is known:
+// any idle worker is available while the tier2 instances have free slots, and the
+// scheduling is retried later when they have none.
+func (p *WorkerPool) workerAvailableWithCapacity(capacityAvail bool) (avail bool, shouldRetry bool) {
+	free := p.freeWorkers() > p.held
+	var waiting *WorkerStatus
+	for _, w := range p.workers {
+		if w.State == WorkerInitialWait {
+			waiting = w
+			break
+		}
+	}
+	if !free && waiting == nil {
+		return false, false
+	}
+	if !capacityAvail {
+		return false, true
+	}
+	if !free {
+		waiting.State = WorkerFree
+	}
+	return true, false
+}
+
+func (p *WorkerPool) freeWorkers() (count int) {
 	for _, w := range p.workers {
 		if w.State == WorkerFree {
-			return true, false
+			count++
 		}
 	}
-	return false, p.inRampupPhase()
+	return count
+}
+
+// Hold sets a free worker aside for a job waiting for its turn, before it is borrowed, so
+// WorkerAvailable doesn't count it. No slot of the tier2 instances is reserved until it is
+// borrowed. WorkerAvailable must be called first.
+func (p *WorkerPool) Hold() {
+	p.held++
+}
+
+// Unhold gives back the worker set aside by Hold, to be borrowed right away or not at all.
+func (p *WorkerPool) Unhold() {
+	if p.held == 0 {
+		panic("no held worker")
+	}
+	p.held--
 }
 
 func (p *WorkerPool) Borrow() Worker {
 	for _, status := range p.workers {
 		if status.State == WorkerFree {
+			if p.capacity != nil {
+				p.capacity.Reserve(status.Worker.ID())
+			}
 			status.State = WorkerWorking
 			return status.Worker
 		}
@@ -96,6 +158,9 @@ func (p *WorkerPool) Return(worker Worker) {
 			if status.State != WorkerWorking {
 				panic("returned worker was already free")
 			}
+			if p.capacity != nil {
+				p.capacity.Settle(worker.ID())
+			}
 			status.State = WorkerFree
 			return
 		}
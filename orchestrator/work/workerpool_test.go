@@ -22,7 +22,7 @@ func Test_workerPoolPool_Borrow_Return(t *testing.T) {
 				return &Result{}
 			}
 		})
-	})
+	}, nil)
 
 	assert.Len(t, pi.workers, 2)
 	avail, shouldRetry := pi.WorkerAvailable()
@@ -55,3 +55,33 @@ func Test_workerPoolPool_Borrow_Return(t *testing.T) {
 	pi.Return(worker1)
 	assert.Panics(t, func() { pi.Return(worker1) })
 }
+
+func Test_workerPool_Hold(t *testing.T) {
+	capacity := NewTier2Capacity()
+	capacity.Update(CapacityHeaders("a", 0, 4))
+	pool := NewWorkerPool(context.Background(), 2, func(logger *zap.Logger) Worker {
+		return NewWorkerFactoryFromFunc(nil)
+	}, capacity)
+
+	avail, _ := pool.WorkerAvailable()
+	assert.True(t, avail)
+	pool.Hold()
+	avail, _ = pool.WorkerAvailable()
+	assert.True(t, avail)
+	pool.Hold()
+
+	avail, shouldRetry := pool.WorkerAvailable()
+	assert.False(t, avail, "all the workers are held")
+	assert.False(t, shouldRetry)
+	assert.Equal(t, int64(4), capacity.instances["a"].free, "held workers take no tier2 slot")
+
+	pool.Unhold()
+	worker := pool.Borrow()
+	assert.Equal(t, int64(3), capacity.instances["a"].free)
+
+	pool.Unhold()
+	assert.Panics(t, func() { pool.Unhold() })
+	avail, _ = pool.WorkerAvailable()
+	assert.True(t, avail)
+	pool.Return(worker)
+}
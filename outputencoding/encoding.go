@@ -0,0 +1,266 @@
+// Package outputencoding compresses the `map_output` payloads of the output modules sent on
+// the Blocks stream, in one of the encodings accepted by the client, and decodes them back.
+package outputencoding
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+)
+
+const (
+	Zstd = "zstd"
+	Gzip = "gzip"
+)
+
+// Supported lists the encodings, in order of preference.
+var Supported = []string{Zstd, Gzip}
+
+// MinSize is the size under which payloads are not encoded.
+const MinSize = 128
+
+// MaxDecodedSize bounds the size of a decoded payload, so a small encoded payload
+// cannot exhaust the memory of the client.
+const MaxDecodedSize = 1 << 30
+
+// negotiate returns the first of the `accepted` encodings that is supported, or an
+// empty string if none is.
+func negotiate(accepted []string) string {
+	for _, encoding := range accepted {
+		for _, supported := range Supported {
+			if encoding == supported {
+				return encoding
+			}
+		}
+	}
+	return ""
+}
+
+// Encoder encodes the outputs of a single stream. With zstd, the first payload of a module
+// of at least MinSize bytes becomes that module's raw dictionary, returned by Encode to be
+// sent to the client before the output, and the following payloads of the module are
+// compressed with it.
+type Encoder struct {
+	encoding string
+
+	dictionaries map[string]uint32 // module name -> dictionary id
+	zstdEncoders map[uint32]*zstd.Encoder
+}
+
+// NewEncoder returns an encoder for the first supported of the `accepted` encodings, nil
+// if none is supported.
+func NewEncoder(accepted []string) *Encoder {
+	encoding := negotiate(accepted)
+	if encoding == "" {
+		return nil
+	}
+	return &Encoder{
+		encoding:     encoding,
+		dictionaries: make(map[string]uint32),
+		zstdEncoders: make(map[uint32]*zstd.Encoder),
+	}
+}
+
+// Encode replaces the payload of `output` by its encoded value. A dictionary created for
+// the output's module is returned, it must reach the client before the output. The
+// payload's Any is replaced, not modified, as it can be shared with other outputs.
+//
+// A nil encoder leaves the output untouched.
+func (e *Encoder) Encode(output *pbsubstreamsrpc.MapModuleOutput) (*pbsubstreamsrpc.OutputDictionary, error) {
+	if e == nil || output == nil || output.MapOutput == nil || output.MapOutputEncoding != "" {
+		return nil, nil
+	}
+	payload := output.MapOutput.Value
+	if len(payload) < MinSize {
+		return nil, nil
+	}
+
+	var dictionary *pbsubstreamsrpc.OutputDictionary
+	var encoded []byte
+	switch e.encoding {
+	case Zstd:
+		id, found := e.dictionaries[output.Name]
+		if !found {
+			id = uint32(len(e.dictionaries) + 1)
+			e.dictionaries[output.Name] = id
+			dictionary = &pbsubstreamsrpc.OutputDictionary{Id: id, ModuleName: output.Name, Content: payload}
+		}
+		encoder, err := e.zstdEncoder(id, dictionary)
+		if err != nil {
+			return nil, err
+		}
+		encoded = encoder.EncodeAll(payload, nil)
+	case Gzip:
+		buf := bytes.NewBuffer(nil)
+		writer := gzip.NewWriter(buf)
+		if _, err := writer.Write(payload); err != nil {
+			return nil, fmt.Errorf("gzip output of module %q: %w", output.Name, err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("gzip output of module %q: %w", output.Name, err)
+		}
+		encoded = buf.Bytes()
+	}
+
+	output.MapOutput = &anypb.Any{TypeUrl: output.MapOutput.TypeUrl, Value: encoded}
+	output.MapOutputEncoding = e.encoding
+	return dictionary, nil
+}
+
+func (e *Encoder) zstdEncoder(id uint32, dictionary *pbsubstreamsrpc.OutputDictionary) (*zstd.Encoder, error) {
+	if encoder, found := e.zstdEncoders[id]; found {
+		return encoder, nil
+	}
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderDictRaw(id, dictionary.Content), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, fmt.Errorf("new zstd encoder: %w", err)
+	}
+	e.zstdEncoders[id] = encoder
+	return encoder, nil
+}
+
+// EncodeResponse encodes the outputs of the output modules in a BlockScopedData or
+// BlockScopedDatas response, returning the dictionaries to send before it.
+func (e *Encoder) EncodeResponse(resp *pbsubstreamsrpc.Response) (dictionaries []*pbsubstreamsrpc.OutputDictionary, err error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	var datas []*pbsubstreamsrpc.BlockScopedData
+	switch m := resp.Message.(type) {
+	case *pbsubstreamsrpc.Response_BlockScopedData:
+		datas = []*pbsubstreamsrpc.BlockScopedData{m.BlockScopedData}
+	case *pbsubstreamsrpc.Response_BlockScopedDatas:
+		datas = m.BlockScopedDatas.Items
+	default:
+		return nil, nil
+	}
+
+	for _, data := range datas {
+		for _, output := range append([]*pbsubstreamsrpc.MapModuleOutput{data.Output}, data.AdditionalOutputs...) {
+			dictionary, err := e.Encode(output)
+			if err != nil {
+				return nil, err
+			}
+			if dictionary != nil {
+				dictionaries = append(dictionaries, dictionary)
+			}
+		}
+	}
+	return dictionaries, nil
+}
+
+// Decoder decodes the outputs encoded by an Encoder, using the dictionaries received
+// on the same stream.
+type Decoder struct {
+	dictionaries   []*pbsubstreamsrpc.OutputDictionary
+	zstdDecoder    *zstd.Decoder
+	maxDecodedSize int
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{maxDecodedSize: MaxDecodedSize}
+}
+
+// AddDictionary registers a dictionary used by the outputs that follow it.
+func (d *Decoder) AddDictionary(dictionary *pbsubstreamsrpc.OutputDictionary) {
+	d.dictionaries = append(d.dictionaries, dictionary)
+	if d.zstdDecoder != nil {
+		d.zstdDecoder.Close()
+		d.zstdDecoder = nil
+	}
+}
+
+// Decode replaces the encoded payload of `output` by its decoded value.
+func (d *Decoder) Decode(output *pbsubstreamsrpc.MapModuleOutput) error {
+	if output == nil || output.MapOutputEncoding == "" {
+		return nil
+	}
+	if output.MapOutput == nil {
+		output.MapOutputEncoding = ""
+		return nil
+	}
+
+	var decoded []byte
+	switch output.MapOutputEncoding {
+	case Zstd:
+		decoder, err := d.getZstdDecoder()
+		if err != nil {
+			return err
+		}
+		decoded, err = decoder.DecodeAll(output.MapOutput.Value, nil)
+		if err != nil {
+			return fmt.Errorf("zstd output of module %q: %w", output.Name, err)
+		}
+	case Gzip:
+		reader, err := gzip.NewReader(bytes.NewReader(output.MapOutput.Value))
+		if err != nil {
+			return fmt.Errorf("gzip output of module %q: %w", output.Name, err)
+		}
+		decoded, err = io.ReadAll(io.LimitReader(reader, int64(d.maxDecodedSize)+1))
+		if err != nil {
+			return fmt.Errorf("gzip output of module %q: %w", output.Name, err)
+		}
+		if len(decoded) > d.maxDecodedSize {
+			return fmt.Errorf("gzip output of module %q: decoded size exceeds %d bytes", output.Name, d.maxDecodedSize)
+		}
+	default:
+		return fmt.Errorf("unsupported encoding %q of output of module %q", output.MapOutputEncoding, output.Name)
+	}
+
+	output.MapOutput = &anypb.Any{TypeUrl: output.MapOutput.TypeUrl, Value: decoded}
+	output.MapOutputEncoding = ""
+	return nil
+}
+
+func (d *Decoder) getZstdDecoder() (*zstd.Decoder, error) {
+	if d.zstdDecoder != nil {
+		return d.zstdDecoder, nil
+	}
+	opts := []zstd.DOption{zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(d.maxDecodedSize))}
+	for _, dictionary := range d.dictionaries {
+		opts = append(opts, zstd.WithDecoderDictRaw(dictionary.Id, dictionary.Content))
+	}
+	decoder, err := zstd.NewReader(nil, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("new zstd decoder: %w", err)
+	}
+	d.zstdDecoder = decoder
+	return decoder, nil
+}
+
+// DecodeResponse decodes the outputs of a BlockScopedData or BlockScopedDatas response, and
+// registers the dictionary of an OutputDictionary response, in which case `consumed` is true:
+// the response is not meant for the caller.
+func (d *Decoder) DecodeResponse(resp *pbsubstreamsrpc.Response) (consumed bool, err error) {
+	var datas []*pbsubstreamsrpc.BlockScopedData
+	switch m := resp.Message.(type) {
+	case *pbsubstreamsrpc.Response_OutputDictionary:
+		d.AddDictionary(m.OutputDictionary)
+		return true, nil
+	case *pbsubstreamsrpc.Response_BlockScopedData:
+		datas = []*pbsubstreamsrpc.BlockScopedData{m.BlockScopedData}
+	case *pbsubstreamsrpc.Response_BlockScopedDatas:
+		datas = m.BlockScopedDatas.Items
+	default:
+		return false, nil
+	}
+
+	for _, data := range datas {
+		if err := d.Decode(data.Output); err != nil {
+			return false, err
+		}
+		for _, output := range data.AdditionalOutputs {
+			if err := d.Decode(output); err != nil {
+				return false, err
+			}
+		}
+	}
+	return false, nil
+}
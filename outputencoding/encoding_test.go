@@ -0,0 +1,108 @@
+package outputencoding
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+)
+
+func TestNewEncoder(t *testing.T) {
+	assert.Nil(t, NewEncoder(nil))
+	assert.Nil(t, NewEncoder([]string{"br"}))
+	assert.Equal(t, Gzip, NewEncoder([]string{"br", Gzip, Zstd}).encoding)
+	assert.Equal(t, Zstd, NewEncoder([]string{Zstd, Gzip}).encoding)
+}
+
+func TestEncodeDecode(t *testing.T) {
+	payload := func(i int) []byte {
+		return bytes.Repeat([]byte{'a', byte(i), 'c', 'd'}, 100)
+	}
+	output := func(module string, value []byte) *pbsubstreamsrpc.MapModuleOutput {
+		return &pbsubstreamsrpc.MapModuleOutput{Name: module, MapOutput: &anypb.Any{TypeUrl: "type.googleapis.com/test.Output", Value: value}}
+	}
+
+	tests := []struct {
+		encoding           string
+		expectDictionaries []string
+	}{
+		{Zstd, []string{"map_a", "map_b"}},
+		{Gzip, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.encoding, func(t *testing.T) {
+			encoder := NewEncoder([]string{test.encoding})
+			decoder := NewDecoder()
+
+			var dictionaries []string
+			for i := 0; i < 3; i++ {
+				data := &pbsubstreamsrpc.BlockScopedData{
+					Output:            output("map_a", payload(i)),
+					AdditionalOutputs: []*pbsubstreamsrpc.MapModuleOutput{output("map_b", payload(i+10)), output("map_c", []byte("small"))},
+				}
+				expected := proto.Clone(data)
+				sharedAny := data.Output.MapOutput
+
+				resp := &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockScopedData{BlockScopedData: data}}
+				dicts, err := encoder.EncodeResponse(resp)
+				require.NoError(t, err)
+				assert.Equal(t, payload(i), sharedAny.Value, "shared payload must not be modified")
+
+				assert.Equal(t, test.encoding, data.Output.MapOutputEncoding)
+				assert.Equal(t, test.encoding, data.AdditionalOutputs[0].MapOutputEncoding)
+				assert.Equal(t, "", data.AdditionalOutputs[1].MapOutputEncoding, "small payloads are not encoded")
+				assert.Less(t, len(data.Output.MapOutput.Value), len(payload(i)))
+
+				for _, dict := range dicts {
+					dictionaries = append(dictionaries, dict.ModuleName)
+					consumed, err := decoder.DecodeResponse(&pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_OutputDictionary{OutputDictionary: dict}})
+					require.NoError(t, err)
+					assert.True(t, consumed)
+				}
+
+				consumed, err := decoder.DecodeResponse(resp)
+				require.NoError(t, err)
+				assert.False(t, consumed)
+				assert.True(t, proto.Equal(expected, data))
+			}
+			assert.Equal(t, test.expectDictionaries, dictionaries)
+		})
+	}
+}
+
+func TestEncoder_Nil(t *testing.T) {
+	var encoder *Encoder
+	data := &pbsubstreamsrpc.BlockScopedData{Output: &pbsubstreamsrpc.MapModuleOutput{MapOutput: &anypb.Any{Value: make([]byte, 1000)}}}
+
+	dicts, err := encoder.EncodeResponse(&pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockScopedData{BlockScopedData: data}})
+	require.NoError(t, err)
+	assert.Nil(t, dicts)
+	assert.Equal(t, "", data.Output.MapOutputEncoding)
+}
+
+func TestDecoder_MaxDecodedSize(t *testing.T) {
+	for _, encoding := range []string{Zstd, Gzip} {
+		t.Run(encoding, func(t *testing.T) {
+			encoder := NewEncoder([]string{encoding})
+			decoder := NewDecoder()
+			decoder.maxDecodedSize = 1000
+
+			data := &pbsubstreamsrpc.BlockScopedData{Output: &pbsubstreamsrpc.MapModuleOutput{Name: "map_a", MapOutput: &anypb.Any{Value: make([]byte, 1001)}}}
+			resp := &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockScopedData{BlockScopedData: data}}
+			dicts, err := encoder.EncodeResponse(resp)
+			require.NoError(t, err)
+			for _, dict := range dicts {
+				decoder.AddDictionary(dict)
+			}
+
+			_, err = decoder.DecodeResponse(resp)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -20,11 +20,15 @@ func (r *ProcessRangeRequest) Validate() error {
 	}
 
 	seenStores := map[string]bool{}
+	seenMaps := map[string]bool{}
 	outputModuleFound := false
 	for _, mod := range r.Modules.Modules {
 		if _, ok := mod.Kind.(*pbsubstreams.Module_KindStore_); ok {
 			seenStores[mod.Name] = true
 		}
+		if _, ok := mod.Kind.(*pbsubstreams.Module_KindMap_); ok {
+			seenMaps[mod.Name] = true
+		}
 		if mod.Name == r.OutputModule { // internal request can have store or module output
 			outputModuleFound = true
 		}
@@ -33,5 +37,14 @@ func (r *ProcessRangeRequest) Validate() error {
 		return fmt.Errorf("output module %q not found in modules", r.OutputModule)
 	}
 
+	for _, additionalOutput := range r.AdditionalOutputModules {
+		if !seenMaps[additionalOutput] {
+			if seenStores[additionalOutput] {
+				return fmt.Errorf("additional output module %q must be of kind 'map'", additionalOutput)
+			}
+			return fmt.Errorf("additional output module %q not found in modules", additionalOutput)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,83 @@
+package pbsubstreamsrpc
+
+import (
+	"fmt"
+	"strings"
+
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+)
+
+// ShortName returns the level name in lower case without the `LOG_LEVEL_` prefix, e.g. `warn`.
+func (l LogLevel) ShortName() string {
+	return strings.ToLower(strings.TrimPrefix(l.String(), "LOG_LEVEL_"))
+}
+
+// ParseLogLevel accepts a level either by its short name (`warn`) or its full
+// enum name (`LOG_LEVEL_WARN`), case-insensitively.
+func ParseLogLevel(in string) (LogLevel, error) {
+	name := strings.ToUpper(in)
+	if !strings.HasPrefix(name, "LOG_LEVEL_") {
+		name = "LOG_LEVEL_" + name
+	}
+
+	level, found := LogLevel_value[name]
+	if !found || LogLevel(level) == LogLevel_LOG_LEVEL_UNSPECIFIED {
+		return LogLevel_LOG_LEVEL_UNSPECIFIED, fmt.Errorf("invalid log level %q, valid values are trace, debug, info, warn and error", in)
+	}
+	return LogLevel(level), nil
+}
+
+// NewLogEntryFromInternal converts a log entry received from tier2.
+func NewLogEntryFromInternal(in *pbssinternal.LogEntry) *LogEntry {
+	var fields []*LogField
+	for _, field := range in.Fields {
+		fields = append(fields, &LogField{Key: field.Key, Value: field.Value})
+	}
+	return &LogEntry{
+		Level:   LogLevel(in.Level),
+		Message: in.Message,
+		Fields:  fields,
+	}
+}
+
+// Render returns the entry as a single line. Entries at info level without
+// fields render as their message alone, like `logger.println` always did.
+func (e *LogEntry) Render() string {
+	if e.Level == LogLevel_LOG_LEVEL_INFO && len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	out := &strings.Builder{}
+	if e.Level != LogLevel_LOG_LEVEL_INFO {
+		out.WriteString("[")
+		out.WriteString(strings.ToUpper(e.Level.ShortName()))
+		out.WriteString("] ")
+	}
+	out.WriteString(e.Message)
+	for _, field := range e.Fields {
+		fmt.Fprintf(out, " %s=%q", field.Key, field.Value)
+	}
+	return out.String()
+}
+
+// LogEntries returns the structured logs of the output at `minLevel` or above. Servers
+// that predate structured logs only fill `Logs`, those are then returned at info level.
+func (d *OutputDebugInfo) LogEntries(minLevel LogLevel) (out []*LogEntry) {
+	if d == nil {
+		return nil
+	}
+
+	entries := d.StructuredLogs
+	if len(entries) == 0 && len(d.Logs) != 0 {
+		for _, log := range d.Logs {
+			entries = append(entries, &LogEntry{Level: LogLevel_LOG_LEVEL_INFO, Message: log})
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.Level >= minLevel {
+			out = append(out, entry)
+		}
+	}
+	return
+}
@@ -23,6 +23,8 @@ const _ = connect.IsAtLeastVersion1_13_0
 const (
 	// StreamName is the fully-qualified name of the Stream service.
 	StreamName = "sf.substreams.rpc.v2.Stream"
+	// ChecksumsName is the fully-qualified name of the Checksums service.
+	ChecksumsName = "sf.substreams.rpc.v2.Checksums"
 )
 
 // These constants are the fully-qualified names of the RPCs defined in this package. They're
@@ -35,17 +37,34 @@ const (
 const (
 	// StreamBlocksProcedure is the fully-qualified name of the Stream's Blocks RPC.
 	StreamBlocksProcedure = "/sf.substreams.rpc.v2.Stream/Blocks"
+	// StreamPlanProcedure is the fully-qualified name of the Stream's Plan RPC.
+	StreamPlanProcedure = "/sf.substreams.rpc.v2.Stream/Plan"
+	// StreamBackfillProcedure is the fully-qualified name of the Stream's Backfill RPC.
+	StreamBackfillProcedure = "/sf.substreams.rpc.v2.Stream/Backfill"
+	// ChecksumsOutputChecksumsProcedure is the fully-qualified name of the Checksums's OutputChecksums
+	// RPC.
+	ChecksumsOutputChecksumsProcedure = "/sf.substreams.rpc.v2.Checksums/OutputChecksums"
 )
 
 // These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
 var (
-	streamServiceDescriptor      = v2.File_sf_substreams_rpc_v2_service_proto.Services().ByName("Stream")
-	streamBlocksMethodDescriptor = streamServiceDescriptor.Methods().ByName("Blocks")
+	streamServiceDescriptor                  = v2.File_sf_substreams_rpc_v2_service_proto.Services().ByName("Stream")
+	streamBlocksMethodDescriptor             = streamServiceDescriptor.Methods().ByName("Blocks")
+	streamPlanMethodDescriptor               = streamServiceDescriptor.Methods().ByName("Plan")
+	streamBackfillMethodDescriptor           = streamServiceDescriptor.Methods().ByName("Backfill")
+	checksumsServiceDescriptor               = v2.File_sf_substreams_rpc_v2_service_proto.Services().ByName("Checksums")
+	checksumsOutputChecksumsMethodDescriptor = checksumsServiceDescriptor.Methods().ByName("OutputChecksums")
 )
 
 // StreamClient is a client for the sf.substreams.rpc.v2.Stream service.
 type StreamClient interface {
 	Blocks(context.Context, *connect.Request[v2.Request]) (*connect.ServerStreamForClient[v2.Response], error)
+	// Plan returns the work that `Blocks` would schedule for the request, from the cached
+	// segments of each stage, without processing anything.
+	Plan(context.Context, *connect.Request[v2.Request]) (*connect.Response[v2.PlanResponse], error)
+	// Backfill caches the stores and the outputs of a module graph up to a final block, then
+	// returns. Only the session and the `ModulesProgress` messages are sent, no output.
+	Backfill(context.Context, *connect.Request[v2.BackfillRequest]) (*connect.ServerStreamForClient[v2.Response], error)
 }
 
 // NewStreamClient constructs a client for the sf.substreams.rpc.v2.Stream service. By default, it
@@ -64,12 +83,26 @@ func NewStreamClient(httpClient connect.HTTPClient, baseURL string, opts ...conn
 			connect.WithSchema(streamBlocksMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
+		plan: connect.NewClient[v2.Request, v2.PlanResponse](
+			httpClient,
+			baseURL+StreamPlanProcedure,
+			connect.WithSchema(streamPlanMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		backfill: connect.NewClient[v2.BackfillRequest, v2.Response](
+			httpClient,
+			baseURL+StreamBackfillProcedure,
+			connect.WithSchema(streamBackfillMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // streamClient implements StreamClient.
 type streamClient struct {
-	blocks *connect.Client[v2.Request, v2.Response]
+	blocks   *connect.Client[v2.Request, v2.Response]
+	plan     *connect.Client[v2.Request, v2.PlanResponse]
+	backfill *connect.Client[v2.BackfillRequest, v2.Response]
 }
 
 // Blocks calls sf.substreams.rpc.v2.Stream.Blocks.
@@ -77,9 +110,25 @@ func (c *streamClient) Blocks(ctx context.Context, req *connect.Request[v2.Reque
 	return c.blocks.CallServerStream(ctx, req)
 }
 
+// Plan calls sf.substreams.rpc.v2.Stream.Plan.
+func (c *streamClient) Plan(ctx context.Context, req *connect.Request[v2.Request]) (*connect.Response[v2.PlanResponse], error) {
+	return c.plan.CallUnary(ctx, req)
+}
+
+// Backfill calls sf.substreams.rpc.v2.Stream.Backfill.
+func (c *streamClient) Backfill(ctx context.Context, req *connect.Request[v2.BackfillRequest]) (*connect.ServerStreamForClient[v2.Response], error) {
+	return c.backfill.CallServerStream(ctx, req)
+}
+
 // StreamHandler is an implementation of the sf.substreams.rpc.v2.Stream service.
 type StreamHandler interface {
 	Blocks(context.Context, *connect.Request[v2.Request], *connect.ServerStream[v2.Response]) error
+	// Plan returns the work that `Blocks` would schedule for the request, from the cached
+	// segments of each stage, without processing anything.
+	Plan(context.Context, *connect.Request[v2.Request]) (*connect.Response[v2.PlanResponse], error)
+	// Backfill caches the stores and the outputs of a module graph up to a final block, then
+	// returns. Only the session and the `ModulesProgress` messages are sent, no output.
+	Backfill(context.Context, *connect.Request[v2.BackfillRequest], *connect.ServerStream[v2.Response]) error
 }
 
 // NewStreamHandler builds an HTTP handler from the service implementation. It returns the path on
@@ -94,10 +143,26 @@ func NewStreamHandler(svc StreamHandler, opts ...connect.HandlerOption) (string,
 		connect.WithSchema(streamBlocksMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
+	streamPlanHandler := connect.NewUnaryHandler(
+		StreamPlanProcedure,
+		svc.Plan,
+		connect.WithSchema(streamPlanMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	streamBackfillHandler := connect.NewServerStreamHandler(
+		StreamBackfillProcedure,
+		svc.Backfill,
+		connect.WithSchema(streamBackfillMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/sf.substreams.rpc.v2.Stream/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case StreamBlocksProcedure:
 			streamBlocksHandler.ServeHTTP(w, r)
+		case StreamPlanProcedure:
+			streamPlanHandler.ServeHTTP(w, r)
+		case StreamBackfillProcedure:
+			streamBackfillHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -110,3 +175,83 @@ type UnimplementedStreamHandler struct{}
 func (UnimplementedStreamHandler) Blocks(context.Context, *connect.Request[v2.Request], *connect.ServerStream[v2.Response]) error {
 	return connect.NewError(connect.CodeUnimplemented, errors.New("sf.substreams.rpc.v2.Stream.Blocks is not implemented"))
 }
+
+func (UnimplementedStreamHandler) Plan(context.Context, *connect.Request[v2.Request]) (*connect.Response[v2.PlanResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("sf.substreams.rpc.v2.Stream.Plan is not implemented"))
+}
+
+func (UnimplementedStreamHandler) Backfill(context.Context, *connect.Request[v2.BackfillRequest], *connect.ServerStream[v2.Response]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("sf.substreams.rpc.v2.Stream.Backfill is not implemented"))
+}
+
+// ChecksumsClient is a client for the sf.substreams.rpc.v2.Checksums service.
+type ChecksumsClient interface {
+	// OutputChecksums returns the checksums of the cached outputs of a module, per segment,
+	// to verify that different providers produce the same outputs.
+	OutputChecksums(context.Context, *connect.Request[v2.OutputChecksumsRequest]) (*connect.Response[v2.OutputChecksumsResponse], error)
+}
+
+// NewChecksumsClient constructs a client for the sf.substreams.rpc.v2.Checksums service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewChecksumsClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) ChecksumsClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &checksumsClient{
+		outputChecksums: connect.NewClient[v2.OutputChecksumsRequest, v2.OutputChecksumsResponse](
+			httpClient,
+			baseURL+ChecksumsOutputChecksumsProcedure,
+			connect.WithSchema(checksumsOutputChecksumsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// checksumsClient implements ChecksumsClient.
+type checksumsClient struct {
+	outputChecksums *connect.Client[v2.OutputChecksumsRequest, v2.OutputChecksumsResponse]
+}
+
+// OutputChecksums calls sf.substreams.rpc.v2.Checksums.OutputChecksums.
+func (c *checksumsClient) OutputChecksums(ctx context.Context, req *connect.Request[v2.OutputChecksumsRequest]) (*connect.Response[v2.OutputChecksumsResponse], error) {
+	return c.outputChecksums.CallUnary(ctx, req)
+}
+
+// ChecksumsHandler is an implementation of the sf.substreams.rpc.v2.Checksums service.
+type ChecksumsHandler interface {
+	// OutputChecksums returns the checksums of the cached outputs of a module, per segment,
+	// to verify that different providers produce the same outputs.
+	OutputChecksums(context.Context, *connect.Request[v2.OutputChecksumsRequest]) (*connect.Response[v2.OutputChecksumsResponse], error)
+}
+
+// NewChecksumsHandler builds an HTTP handler from the service implementation. It returns the path
+// on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewChecksumsHandler(svc ChecksumsHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	checksumsOutputChecksumsHandler := connect.NewUnaryHandler(
+		ChecksumsOutputChecksumsProcedure,
+		svc.OutputChecksums,
+		connect.WithSchema(checksumsOutputChecksumsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/sf.substreams.rpc.v2.Checksums/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case ChecksumsOutputChecksumsProcedure:
+			checksumsOutputChecksumsHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedChecksumsHandler returns CodeUnimplemented from all methods.
+type UnimplementedChecksumsHandler struct{}
+
+func (UnimplementedChecksumsHandler) OutputChecksums(context.Context, *connect.Request[v2.OutputChecksumsRequest]) (*connect.Response[v2.OutputChecksumsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("sf.substreams.rpc.v2.Checksums.OutputChecksums is not implemented"))
+}
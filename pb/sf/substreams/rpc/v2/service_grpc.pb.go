@@ -23,6 +23,12 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type StreamClient interface {
 	Blocks(ctx context.Context, in *Request, opts ...grpc.CallOption) (Stream_BlocksClient, error)
+	// Plan returns the work that `Blocks` would schedule for the request, from the cached
+	// segments of each stage, without processing anything.
+	Plan(ctx context.Context, in *Request, opts ...grpc.CallOption) (*PlanResponse, error)
+	// Backfill caches the stores and the outputs of a module graph up to a final block, then
+	// returns. Only the session and the `ModulesProgress` messages are sent, no output.
+	Backfill(ctx context.Context, in *BackfillRequest, opts ...grpc.CallOption) (Stream_BackfillClient, error)
 }
 
 type streamClient struct {
@@ -65,11 +71,58 @@ func (x *streamBlocksClient) Recv() (*Response, error) {
 	return m, nil
 }
 
+func (c *streamClient) Plan(ctx context.Context, in *Request, opts ...grpc.CallOption) (*PlanResponse, error) {
+	out := new(PlanResponse)
+	err := c.cc.Invoke(ctx, "/sf.substreams.rpc.v2.Stream/Plan", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *streamClient) Backfill(ctx context.Context, in *BackfillRequest, opts ...grpc.CallOption) (Stream_BackfillClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Stream_ServiceDesc.Streams[1], "/sf.substreams.rpc.v2.Stream/Backfill", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &streamBackfillClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Stream_BackfillClient interface {
+	Recv() (*Response, error)
+	grpc.ClientStream
+}
+
+type streamBackfillClient struct {
+	grpc.ClientStream
+}
+
+func (x *streamBackfillClient) Recv() (*Response, error) {
+	m := new(Response)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // StreamServer is the server API for Stream service.
 // All implementations should embed UnimplementedStreamServer
 // for forward compatibility
 type StreamServer interface {
 	Blocks(*Request, Stream_BlocksServer) error
+	// Plan returns the work that `Blocks` would schedule for the request, from the cached
+	// segments of each stage, without processing anything.
+	Plan(context.Context, *Request) (*PlanResponse, error)
+	// Backfill caches the stores and the outputs of a module graph up to a final block, then
+	// returns. Only the session and the `ModulesProgress` messages are sent, no output.
+	Backfill(*BackfillRequest, Stream_BackfillServer) error
 }
 
 // UnimplementedStreamServer should be embedded to have forward compatible implementations.
@@ -79,6 +132,12 @@ type UnimplementedStreamServer struct {
 func (UnimplementedStreamServer) Blocks(*Request, Stream_BlocksServer) error {
 	return status.Errorf(codes.Unimplemented, "method Blocks not implemented")
 }
+func (UnimplementedStreamServer) Plan(context.Context, *Request) (*PlanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Plan not implemented")
+}
+func (UnimplementedStreamServer) Backfill(*BackfillRequest, Stream_BackfillServer) error {
+	return status.Errorf(codes.Unimplemented, "method Backfill not implemented")
+}
 
 // UnsafeStreamServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to StreamServer will
@@ -112,19 +171,156 @@ func (x *streamBlocksServer) Send(m *Response) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Stream_Plan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StreamServer).Plan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sf.substreams.rpc.v2.Stream/Plan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StreamServer).Plan(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Stream_Backfill_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BackfillRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StreamServer).Backfill(m, &streamBackfillServer{stream})
+}
+
+type Stream_BackfillServer interface {
+	Send(*Response) error
+	grpc.ServerStream
+}
+
+type streamBackfillServer struct {
+	grpc.ServerStream
+}
+
+func (x *streamBackfillServer) Send(m *Response) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Stream_ServiceDesc is the grpc.ServiceDesc for Stream service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var Stream_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "sf.substreams.rpc.v2.Stream",
 	HandlerType: (*StreamServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Plan",
+			Handler:    _Stream_Plan_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "Blocks",
 			Handler:       _Stream_Blocks_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Backfill",
+			Handler:       _Stream_Backfill_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "sf/substreams/rpc/v2/service.proto",
+}
+
+// ChecksumsClient is the client API for Checksums service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ChecksumsClient interface {
+	// OutputChecksums returns the checksums of the cached outputs of a module, per segment,
+	// to verify that different providers produce the same outputs.
+	OutputChecksums(ctx context.Context, in *OutputChecksumsRequest, opts ...grpc.CallOption) (*OutputChecksumsResponse, error)
+}
+
+type checksumsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChecksumsClient(cc grpc.ClientConnInterface) ChecksumsClient {
+	return &checksumsClient{cc}
+}
+
+func (c *checksumsClient) OutputChecksums(ctx context.Context, in *OutputChecksumsRequest, opts ...grpc.CallOption) (*OutputChecksumsResponse, error) {
+	out := new(OutputChecksumsResponse)
+	err := c.cc.Invoke(ctx, "/sf.substreams.rpc.v2.Checksums/OutputChecksums", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChecksumsServer is the server API for Checksums service.
+// All implementations should embed UnimplementedChecksumsServer
+// for forward compatibility
+type ChecksumsServer interface {
+	// OutputChecksums returns the checksums of the cached outputs of a module, per segment,
+	// to verify that different providers produce the same outputs.
+	OutputChecksums(context.Context, *OutputChecksumsRequest) (*OutputChecksumsResponse, error)
+}
+
+// UnimplementedChecksumsServer should be embedded to have forward compatible implementations.
+type UnimplementedChecksumsServer struct {
+}
+
+func (UnimplementedChecksumsServer) OutputChecksums(context.Context, *OutputChecksumsRequest) (*OutputChecksumsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OutputChecksums not implemented")
+}
+
+// UnsafeChecksumsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChecksumsServer will
+// result in compilation errors.
+type UnsafeChecksumsServer interface {
+	mustEmbedUnimplementedChecksumsServer()
+}
+
+func RegisterChecksumsServer(s grpc.ServiceRegistrar, srv ChecksumsServer) {
+	s.RegisterService(&Checksums_ServiceDesc, srv)
+}
+
+func _Checksums_OutputChecksums_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OutputChecksumsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChecksumsServer).OutputChecksums(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sf.substreams.rpc.v2.Checksums/OutputChecksums",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChecksumsServer).OutputChecksums(ctx, req.(*OutputChecksumsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Checksums_ServiceDesc is the grpc.ServiceDesc for Checksums service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Checksums_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sf.substreams.rpc.v2.Checksums",
+	HandlerType: (*ChecksumsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "OutputChecksums",
+			Handler:    _Checksums_OutputChecksums_Handler,
+		},
 	},
+	Streams:  []grpc.StreamDesc{},
 	Metadata: "sf/substreams/rpc/v2/service.proto",
 }
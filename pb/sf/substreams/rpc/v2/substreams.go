@@ -3,9 +3,16 @@ package pbsubstreamsrpc
 import (
 	"fmt"
 
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 )
 
+// StoreDeltasTypeURL is the type of the `map_output` sent for a store module
+// requested as `output_module`.
+const StoreDeltasTypeURL = "type.googleapis.com/sf.substreams.v1.StoreDeltas"
+
 type AnyModuleOutput struct {
 	MapOutput   *MapModuleOutput
 	StoreOutput *StoreModuleOutput
@@ -52,8 +59,56 @@ func (s *StoreModuleOutput) ToAny() *AnyModuleOutput {
 	}
 }
 
+// NewStoreDeltasOutput wraps the deltas of the store module `name` as a map output.
+func NewStoreDeltasOutput(name string, deltas *pbsubstreams.StoreDeltas) (*MapModuleOutput, error) {
+	data, err := proto.Marshal(deltas)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling store deltas: %w", err)
+	}
+
+	return &MapModuleOutput{
+		Name:      name,
+		MapOutput: &anypb.Any{TypeUrl: StoreDeltasTypeURL, Value: data},
+	}, nil
+}
+
+// IsStoreDeltas returns true if `m` holds the deltas of a store module.
+func (m *MapModuleOutput) IsStoreDeltas() bool {
+	return m.GetMapOutput().GetTypeUrl() == StoreDeltasTypeURL
+}
+
+// ToStoreModuleOutput turns a map output holding store deltas into a StoreModuleOutput.
+func (m *MapModuleOutput) ToStoreModuleOutput() (*StoreModuleOutput, error) {
+	if !m.IsStoreDeltas() {
+		return nil, fmt.Errorf("output of module %q does not hold store deltas", m.Name)
+	}
+
+	deltas := &pbsubstreams.StoreDeltas{}
+	if err := proto.Unmarshal(m.MapOutput.Value, deltas); err != nil {
+		return nil, fmt.Errorf("unmarshalling store deltas: %w", err)
+	}
+
+	out := &StoreModuleOutput{
+		Name:      m.Name,
+		DebugInfo: m.DebugInfo,
+	}
+	for _, delta := range deltas.StoreDeltas {
+		out.DebugStoreDeltas = append(out.DebugStoreDeltas, &StoreDelta{
+			Operation: StoreDelta_Operation(delta.Operation),
+			Ordinal:   delta.Ordinal,
+			Key:       delta.Key,
+			OldValue:  delta.OldValue,
+			NewValue:  delta.NewValue,
+		})
+	}
+	return out, nil
+}
+
 func (bd *BlockScopedData) AllModuleOutputs() (out []*AnyModuleOutput) {
 	out = append(out, bd.Output.ToAny())
+	for _, mapOut := range bd.AdditionalOutputs {
+		out = append(out, mapOut.ToAny())
+	}
 	for _, mapOut := range bd.DebugMapOutputs {
 		out = append(out, mapOut.ToAny())
 	}
@@ -63,6 +118,34 @@ func (bd *BlockScopedData) AllModuleOutputs() (out []*AnyModuleOutput) {
 	return
 }
 
+// IsEmpty returns true if none of the outputs of `bd` holds data.
+func (bd *BlockScopedData) IsEmpty() bool {
+	if len(bd.Output.GetMapOutput().GetValue()) != 0 {
+		return false
+	}
+	for _, mapOut := range bd.AdditionalOutputs {
+		if len(mapOut.GetMapOutput().GetValue()) != 0 {
+			return false
+		}
+	}
+	for _, mapOut := range bd.DebugMapOutputs {
+		if len(mapOut.GetMapOutput().GetValue()) != 0 {
+			return false
+		}
+	}
+	for _, storeOut := range bd.DebugStoreOutputs {
+		if len(storeOut.DebugStoreDeltas) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// AllOutputModules returns the output module followed by the additional output modules.
+func (req *Request) AllOutputModules() []string {
+	return append([]string{req.OutputModule}, req.AdditionalOutputModules...)
+}
+
 func (req *Request) Validate() error {
 	seenStores := map[string]bool{}
 
@@ -78,13 +161,21 @@ func (req *Request) Validate() error {
 		return fmt.Errorf("cannot set 'debug-modules-initial-snapshot' in 'production-mode'")
 	}
 
+	if req.OutputFilter != nil && req.OutputFilter.Expression == "" {
+		return fmt.Errorf("output filter expression cannot be empty")
+	}
+
 	outputModuleFound := false
+	seenMaps := map[string]bool{}
 	for _, mod := range req.Modules.Modules {
 		if _, ok := mod.Kind.(*pbsubstreams.Module_KindStore_); ok {
 			seenStores[mod.Name] = true
 		}
+		if _, ok := mod.Kind.(*pbsubstreams.Module_KindMap_); ok {
+			seenMaps[mod.Name] = true
+		}
 		if mod.Name == req.OutputModule {
-			if _, ok := mod.Kind.(*pbsubstreams.Module_KindStore_); ok {
+			if _, ok := mod.Kind.(*pbsubstreams.Module_KindStore_); ok && !req.ProductionMode {
 				return fmt.Errorf("output module must be of kind 'map'")
 			}
 			outputModuleFound = true
@@ -94,6 +185,21 @@ func (req *Request) Validate() error {
 		return fmt.Errorf("output module %q not found in modules", req.OutputModule)
 	}
 
+	seenOutputs := map[string]bool{req.OutputModule: true}
+	for _, additionalOutput := range req.AdditionalOutputModules {
+		if seenOutputs[additionalOutput] {
+			return fmt.Errorf("additional output module %q is requested more than once", additionalOutput)
+		}
+		seenOutputs[additionalOutput] = true
+
+		if !seenMaps[additionalOutput] {
+			if seenStores[additionalOutput] {
+				return fmt.Errorf("additional output module %q must be of kind 'map'", additionalOutput)
+			}
+			return fmt.Errorf("additional output module %q not found in modules", additionalOutput)
+		}
+	}
+
 	for _, storeSnapshot := range req.DebugInitialStoreSnapshotForModules {
 		if !seenStores[storeSnapshot] {
 			return fmt.Errorf("initial store snapshots for module: %q: no such 'store' module defined modules graph", storeSnapshot)
@@ -6,6 +6,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 )
 
 func Test_ValidateRequest(t *testing.T) {
@@ -19,7 +22,14 @@ func Test_ValidateRequest(t *testing.T) {
 		{"negative start block num", TestNewRequest(-1, withTestOutputModule("output_mod_1"), withTestMapModule("output_mod_1")), nil},
 		{"no modules found in request", &Request{StartBlockNum: 1}, fmt.Errorf("no modules found in request")},
 		{"store output module is accepted for sub-request", TestNewRequest(1, withTestOutputModule("output_mod_1"), withTestStoreModule("output_mod_1")), fmt.Errorf("output module must be of kind 'map'")},
+		{"store output module is accepted in production mode", TestNewRequest(1, withTestOutputModule("output_mod_1"), withTestStoreModule("output_mod_1"), withProductionMode()), nil},
 		{"production mode should fail with debug flag", TestNewRequest(1, withTestOutputModule("output_mod_1"), withTestMapModule("output_mod_1"), withProductionMode(), withDebugSnapshotsModule("output_mod_1")), fmt.Errorf("cannot set 'debug-modules-initial-snapshot' in 'production-mode'")},
+		{"empty output filter expression", TestNewRequest(1, withTestOutputModule("output_mod_1"), withTestMapModule("output_mod_1"), withOutputFilter("")), fmt.Errorf("output filter expression cannot be empty")},
+		{"additional output modules", TestNewRequest(1, withTestOutputModule("output_mod_1"), withTestMapModule("output_mod_1"), withTestMapModule("output_mod_2"), withTestMapModule("output_mod_3"), withProductionMode(), withAdditionalOutputModules("output_mod_3", "output_mod_2")), nil},
+		{"additional output module not found", TestNewRequest(1, withTestOutputModule("output_mod_1"), withTestMapModule("output_mod_1"), withAdditionalOutputModules("output_mod_2")), fmt.Errorf("additional output module \"output_mod_2\" not found in modules")},
+		{"additional output module is a store", TestNewRequest(1, withTestOutputModule("output_mod_1"), withTestMapModule("output_mod_1"), withTestStoreModule("store_mod"), withAdditionalOutputModules("store_mod")), fmt.Errorf("additional output module \"store_mod\" must be of kind 'map'")},
+		{"additional output module is the output module", TestNewRequest(1, withTestOutputModule("output_mod_1"), withTestMapModule("output_mod_1"), withAdditionalOutputModules("output_mod_1")), fmt.Errorf("additional output module \"output_mod_1\" is requested more than once")},
+		{"additional output module repeated", TestNewRequest(1, withTestOutputModule("output_mod_1"), withTestMapModule("output_mod_1"), withTestMapModule("output_mod_2"), withAdditionalOutputModules("output_mod_2", "output_mod_2")), fmt.Errorf("additional output module \"output_mod_2\" is requested more than once")},
 	}
 
 	for _, test := range tests {
@@ -34,3 +44,50 @@ func Test_ValidateRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestMapModuleOutput_ToStoreModuleOutput(t *testing.T) {
+	deltas := &pbsubstreams.StoreDeltas{StoreDeltas: []*pbsubstreams.StoreDelta{
+		{Operation: pbsubstreams.StoreDelta_CREATE, Ordinal: 1, Key: "a", NewValue: []byte("1")},
+		{Operation: pbsubstreams.StoreDelta_UPDATE, Ordinal: 2, Key: "a", OldValue: []byte("1"), NewValue: []byte("2")},
+		{Operation: pbsubstreams.StoreDelta_DELETE, Ordinal: 3, Key: "b", OldValue: []byte("3")},
+	}}
+
+	output, err := NewStoreDeltasOutput("store_mod", deltas)
+	require.NoError(t, err)
+	assert.True(t, output.IsStoreDeltas())
+
+	storeOutput, err := output.ToStoreModuleOutput()
+	require.NoError(t, err)
+	assert.Equal(t, "store_mod", storeOutput.Name)
+	assert.Equal(t, []*StoreDelta{
+		{Operation: StoreDelta_CREATE, Ordinal: 1, Key: "a", NewValue: []byte("1")},
+		{Operation: StoreDelta_UPDATE, Ordinal: 2, Key: "a", OldValue: []byte("1"), NewValue: []byte("2")},
+		{Operation: StoreDelta_DELETE, Ordinal: 3, Key: "b", OldValue: []byte("3")},
+	}, storeOutput.DebugStoreDeltas)
+
+	_, err = (&MapModuleOutput{Name: "map_mod", MapOutput: &anypb.Any{TypeUrl: "type.googleapis.com/foo.Bar"}}).ToStoreModuleOutput()
+	require.Error(t, err)
+}
+
+func TestBlockScopedData_IsEmpty(t *testing.T) {
+	withData := &MapModuleOutput{MapOutput: &anypb.Any{Value: []byte{0x01}}}
+	empty := &MapModuleOutput{MapOutput: &anypb.Any{}}
+
+	tests := []struct {
+		name   string
+		data   *BlockScopedData
+		expect bool
+	}{
+		{"no output", &BlockScopedData{}, true},
+		{"empty output", &BlockScopedData{Output: empty, AdditionalOutputs: []*MapModuleOutput{empty}}, true},
+		{"output", &BlockScopedData{Output: withData}, false},
+		{"additional output", &BlockScopedData{Output: empty, AdditionalOutputs: []*MapModuleOutput{empty, withData}}, false},
+		{"debug store output", &BlockScopedData{Output: empty, DebugStoreOutputs: []*StoreModuleOutput{{DebugStoreDeltas: []*StoreDelta{{Key: "a"}}}}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, test.data.IsEmpty())
+		})
+	}
+}
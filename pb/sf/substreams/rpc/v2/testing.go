@@ -36,6 +36,20 @@ func withTestOutputModule(module string) testNewRequestOption {
 	}
 }
 
+func withAdditionalOutputModules(modules ...string) testNewRequestOption {
+	return func(req *Request) *Request {
+		req.AdditionalOutputModules = append(req.AdditionalOutputModules, modules...)
+		return req
+	}
+}
+
+func withOutputFilter(expression string) testNewRequestOption {
+	return func(req *Request) *Request {
+		req.OutputFilter = &OutputFilter{Expression: expression}
+		return req
+	}
+}
+
 func withTestStoreModule(name string) testNewRequestOption {
 	return func(req *Request) *Request {
 		req.Modules.Modules = append(req.Modules.Modules, TestNewStoreModule(name))
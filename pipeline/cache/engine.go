@@ -76,7 +76,9 @@ func (e *Engine) HandleFinal(clock *pbsubstreams.Clock) error {
 	}
 
 	for _, writer := range e.execOutputWriters {
-		writer.Write(clock, execOutBuf)
+		if err := writer.Write(e.ctx, clock, execOutBuf); err != nil {
+			return err
+		}
 	}
 
 	delete(e.reversibleBuffers, clock.Number)
@@ -92,7 +94,7 @@ func (e *Engine) HandleStalled(clock *pbsubstreams.Clock) error {
 func (e *Engine) EndOfStream(lastFinalClock *pbsubstreams.Clock) error {
 	var errs error
 	for _, writer := range e.execOutputWriters {
-		if err := writer.Close(context.Background()); err != nil {
+		if err := writer.Close(e.ctx); err != nil {
 			errs = multierror.Append(errs, err)
 		}
 	}
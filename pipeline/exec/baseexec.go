@@ -9,6 +9,7 @@ import (
 
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/failure"
 	"github.com/streamingfast/substreams/wasm"
 )
 
@@ -27,7 +28,7 @@ type BaseExecutor struct {
 	cachedInstance       wasm.Instance
 
 	// Results
-	logs           []string
+	logs           []*wasm.LogEntry
 	logsTruncated  bool
 	executionStack []string
 }
@@ -88,13 +89,17 @@ func (e *BaseExecutor) wasmCall(outputGetter execout.ExecutionOutputGetter) (cal
 				message:    panicErr.Error(),
 				stackTrace: call.ExecutionStack,
 			}
-			return nil, fmt.Errorf("block %d: module %q: general wasm execution panicked: %w: %s", clock.Number, e.moduleName, ErrWasmDeterministicExec, errExecutor.Error())
+			return nil, failure.NewDeterministicError(e.moduleName, clock.Number, fmt.Errorf("block %d: module %q: general wasm execution panicked: %w: %s", clock.Number, e.moduleName, ErrWasmDeterministicExec, errExecutor.Error()))
 		}
 		if err != nil {
 			if err := e.ctx.Err(); err != nil {
 				return nil, fmt.Errorf("block %d: module %q: general wasm execution failed: %w", clock.Number, e.moduleName, err)
 			}
-			return nil, fmt.Errorf("block %d: module %q: general wasm execution failed: %w: %s", clock.Number, e.moduleName, ErrWasmDeterministicExec, err)
+			if !errors.Is(err, wasm.ErrTrap) {
+				// host functions and wasm extensions (RPC calls for instance) can succeed on retry
+				return nil, fmt.Errorf("block %d: module %q: general wasm execution failed: %w", clock.Number, e.moduleName, err)
+			}
+			return nil, failure.NewDeterministicError(e.moduleName, clock.Number, fmt.Errorf("block %d: module %q: general wasm execution failed: %w: %s", clock.Number, e.moduleName, ErrWasmDeterministicExec, err))
 		}
 		if e.instanceCacheEnabled {
 			if err := inst.Cleanup(e.ctx); err != nil {
@@ -120,7 +125,7 @@ func (e *BaseExecutor) Close(ctx context.Context) error {
 	return nil
 }
 
-func (e *BaseExecutor) lastExecutionLogs() (logs []string, truncated bool) {
+func (e *BaseExecutor) lastExecutionLogs() (logs []*wasm.LogEntry, truncated bool) {
 	return e.logs, e.logsTruncated
 }
 func (e *BaseExecutor) lastExecutionStack() []string {
@@ -8,6 +8,7 @@ import (
 
 	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
 	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/failure"
 
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/wasm"
@@ -15,7 +16,8 @@ import (
 
 type MapperModuleExecutor struct {
 	BaseExecutor
-	outputType string
+	outputType   string
+	namedOutputs []string
 }
 
 var _ ModuleExecutor = (*MapperModuleExecutor)(nil)
@@ -29,6 +31,13 @@ func (e *MapperModuleExecutor) Name() string { return e.moduleName }
 
 func (e *MapperModuleExecutor) String() string { return e.Name() }
 
+// WithNamedOutputs marks the module as a multi-output one, its output is then
+// the `MapOutputs` built from the values emitted for each of these names.
+func (e *MapperModuleExecutor) WithNamedOutputs(names []string) *MapperModuleExecutor {
+	e.namedOutputs = names
+	return e
+}
+
 // todo: this is strange because it has to be done on both the store and the mapper
 // and in this case, we don't do anything
 func (e *MapperModuleExecutor) applyCachedOutput([]byte) error { return nil }
@@ -44,6 +53,11 @@ func (e *MapperModuleExecutor) run(ctx context.Context, reader execout.Execution
 
 	if call != nil {
 		out = call.Output()
+		if len(e.namedOutputs) != 0 {
+			if out, err = encodeNamedOutputs(e.namedOutputs, call.NamedOutputs()); err != nil {
+				return nil, nil, failure.NewDeterministicError(e.moduleName, call.Clock.Number, fmt.Errorf("block %d: module %q: %w: %s", call.Clock.Number, e.moduleName, ErrWasmDeterministicExec, err))
+			}
+		}
 	}
 
 	modOut, err := e.toModuleOutput(out)
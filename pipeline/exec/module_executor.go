@@ -11,8 +11,10 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/wasm"
 
 	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 )
 
 func RunModule(ctx context.Context, executor ModuleExecutor, execOutput execout.ExecutionOutputGetter) (*pbssinternal.ModuleOutput, []byte, error) {
@@ -86,6 +88,36 @@ func fillModuleOutputMetadata(executor ModuleExecutor, in *pbssinternal.ModuleOu
 	logs, truncated := executor.lastExecutionLogs()
 
 	in.ModuleName = executor.Name()
-	in.Logs = logs
+	in.Logs = toModuleOutputLogs(logs)
+	in.LegacyLogs = toModuleOutputLegacyLogs(in.Logs)
 	in.DebugLogsTruncated = truncated
 }
+
+func toModuleOutputLogs(in []*wasm.LogEntry) (out []*pbssinternal.LogEntry) {
+	if len(in) == 0 {
+		return nil
+	}
+
+	out = make([]*pbssinternal.LogEntry, len(in))
+	for i, entry := range in {
+		var fields []*pbssinternal.LogField
+		for _, field := range entry.Fields {
+			fields = append(fields, &pbssinternal.LogField{Key: field.Key, Value: field.Value})
+		}
+		out[i] = &pbssinternal.LogEntry{
+			Level:   pbssinternal.LogLevel(entry.Level),
+			Message: entry.Message,
+			Fields:  fields,
+		}
+	}
+	return
+}
+
+// toModuleOutputLegacyLogs renders the logs for tier1 instances that only read the
+// `legacy_logs` field, until all of them read the structured `logs`.
+func toModuleOutputLegacyLogs(in []*pbssinternal.LogEntry) (out []string) {
+	for _, entry := range in {
+		out = append(out, pbsubstreamsrpc.NewLogEntryFromInternal(entry).Render())
+	}
+	return
+}
@@ -13,6 +13,7 @@ import (
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/wasm"
 )
 
 type MockExecOutput struct {
@@ -48,7 +49,7 @@ type MockModuleExecutor struct {
 
 	RunFunc      func(ctx context.Context, reader execout.ExecutionOutputGetter) (out []byte, moduleOutputData *pbssinternal.ModuleOutput, err error)
 	ApplyFunc    func(value []byte) error
-	LogsFunc     func() (logs []string, truncated bool)
+	LogsFunc     func() (logs []*wasm.LogEntry, truncated bool)
 	StackFunc    func() []string
 	ToOutputFunc func(data []byte) (*pbssinternal.ModuleOutput, error)
 	cacheable    bool
@@ -82,7 +83,7 @@ func (t *MockModuleExecutor) toModuleOutput(data []byte) (*pbssinternal.ModuleOu
 	return nil, fmt.Errorf("not implemented")
 }
 
-func (t *MockModuleExecutor) lastExecutionLogs() (logs []string, truncated bool) {
+func (t *MockModuleExecutor) lastExecutionLogs() (logs []*wasm.LogEntry, truncated bool) {
 	if t.LogsFunc != nil {
 		return t.LogsFunc()
 	}
@@ -109,8 +110,8 @@ func TestModuleExecutorRunner_Run_HappyPath(t *testing.T) {
 				},
 			}, nil
 		},
-		LogsFunc: func() (logs []string, truncated bool) {
-			return []string{"test"}, false
+		LogsFunc: func() (logs []*wasm.LogEntry, truncated bool) {
+			return []*wasm.LogEntry{{Level: wasm.LogLevelInfo, Message: "test"}}, false
 		},
 	}
 	output := &MockExecOutput{
@@ -151,8 +152,8 @@ func TestModuleExecutorRunner_Run_CachedOutput(t *testing.T) {
 			applied = true
 			return nil
 		},
-		LogsFunc: func() (logs []string, truncated bool) {
-			return []string{"test"}, false
+		LogsFunc: func() (logs []*wasm.LogEntry, truncated bool) {
+			return []*wasm.LogEntry{{Level: wasm.LogLevelInfo, Message: "test"}}, false
 		},
 	}
 	output := &MockExecOutput{
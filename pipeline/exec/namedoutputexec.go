@@ -0,0 +1,125 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/wasm"
+)
+
+// NamedOutputModuleExecutor runs the modules generated for each named output of
+// a multi-output map module. It does not run any WASM code, it extracts its
+// output from the `MapOutputs` produced by the parent module.
+type NamedOutputModuleExecutor struct {
+	moduleName string
+	parentName string
+	outputName string
+	outputType string
+}
+
+var _ ModuleExecutor = (*NamedOutputModuleExecutor)(nil)
+
+func NewNamedOutputModuleExecutor(moduleName, parentName, outputName, outputType string) *NamedOutputModuleExecutor {
+	return &NamedOutputModuleExecutor{
+		moduleName: moduleName,
+		parentName: parentName,
+		outputName: outputName,
+		outputType: outputType,
+	}
+}
+
+// Name implements ModuleExecutor
+func (e *NamedOutputModuleExecutor) Name() string { return e.moduleName }
+
+func (e *NamedOutputModuleExecutor) String() string { return e.Name() }
+
+func (e *NamedOutputModuleExecutor) Close(ctx context.Context) error { return nil }
+
+func (e *NamedOutputModuleExecutor) applyCachedOutput([]byte) error { return nil }
+
+func (e *NamedOutputModuleExecutor) run(ctx context.Context, reader execout.ExecutionOutputGetter) (out []byte, moduleOutputData *pbssinternal.ModuleOutput, err error) {
+	ctx, span := reqctx.WithModuleExecutionSpan(ctx, "exec_named_output")
+	defer span.EndWithErr(&err)
+
+	data, _, err := reader.Get(e.parentName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("input data for %q: %w", e.parentName, err)
+	}
+
+	out, err = ExtractNamedOutput(data, e.outputName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("module %q: %w", e.moduleName, err)
+	}
+
+	modOut, err := e.toModuleOutput(out)
+	if err != nil {
+		return nil, nil, fmt.Errorf("converting back to module output: %w", err)
+	}
+
+	return out, modOut, nil
+}
+
+func (e *NamedOutputModuleExecutor) toModuleOutput(data []byte) (*pbssinternal.ModuleOutput, error) {
+	return &pbssinternal.ModuleOutput{
+		Data: &pbssinternal.ModuleOutput_MapOutput{
+			MapOutput: &anypb.Any{TypeUrl: "type.googleapis.com/" + e.outputType, Value: data},
+		},
+	}, nil
+}
+
+func (e *NamedOutputModuleExecutor) HasValidOutput() bool {
+	return true
+}
+
+func (e *NamedOutputModuleExecutor) lastExecutionLogs() (logs []*wasm.LogEntry, truncated bool) {
+	return nil, false
+}
+
+func (e *NamedOutputModuleExecutor) lastExecutionStack() []string {
+	return nil
+}
+
+// ExtractNamedOutput returns the value of the output `name` from the encoded
+// `MapOutputs` of a multi-output module, or nil if the module did not emit it.
+func ExtractNamedOutput(data []byte, name string) ([]byte, error) {
+	outputs := &pbsubstreams.MapOutputs{}
+	if err := proto.Unmarshal(data, outputs); err != nil {
+		return nil, fmt.Errorf("decoding named outputs: %w", err)
+	}
+
+	for _, output := range outputs.Outputs {
+		if output.Name == name {
+			return output.Value, nil
+		}
+	}
+	return nil, nil
+}
+
+// encodeNamedOutputs builds the `MapOutputs` of a multi-output module from the
+// values it set through `output_named`, in the order the outputs are declared.
+func encodeNamedOutputs(declared []string, values map[string][]byte) ([]byte, error) {
+	isDeclared := make(map[string]bool, len(declared))
+	for _, name := range declared {
+		isDeclared[name] = true
+	}
+	for name := range values {
+		if !isDeclared[name] {
+			return nil, fmt.Errorf("output %q is not declared by the module", name)
+		}
+	}
+
+	outputs := &pbsubstreams.MapOutputs{}
+	for _, name := range declared {
+		if value, found := values[name]; found {
+			outputs.Outputs = append(outputs.Outputs, &pbsubstreams.MapOutput{Name: name, Value: value})
+		}
+	}
+	return proto.Marshal(outputs)
+}
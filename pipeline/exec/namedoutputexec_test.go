@@ -0,0 +1,33 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamedOutputs(t *testing.T) {
+	declared := []string{"transfers", "approvals", "mints"}
+
+	data, err := encodeNamedOutputs(declared, map[string][]byte{
+		"approvals": []byte("approvals data"),
+		"transfers": []byte("transfers data"),
+	})
+	require.NoError(t, err)
+
+	transfers, err := ExtractNamedOutput(data, "transfers")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("transfers data"), transfers)
+
+	approvals, err := ExtractNamedOutput(data, "approvals")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("approvals data"), approvals)
+
+	mints, err := ExtractNamedOutput(data, "mints")
+	require.NoError(t, err)
+	assert.Nil(t, mints)
+
+	_, err = encodeNamedOutputs(declared, map[string][]byte{"burns": nil})
+	assert.EqualError(t, err, `output "burns" is not declared by the module`)
+}
@@ -20,6 +20,7 @@ type UndoHandler func(clock *pbsubstreams.Clock, moduleOutputs []*pbssinternal.M
 //	and have some nested structs handle
 type ForkHandler struct {
 	reversibleOutputs map[string][]*pbssinternal.ModuleOutput
+	sentBlocks        map[string]bool // reversible blocks whose outputs were sent to the client
 	undoHandlers      []UndoHandler
 
 	mu sync.RWMutex
@@ -28,6 +29,7 @@ type ForkHandler struct {
 func NewForkHandler() *ForkHandler {
 	return &ForkHandler{
 		reversibleOutputs: make(map[string][]*pbssinternal.ModuleOutput),
+		sentBlocks:        make(map[string]bool),
 		undoHandlers:      []UndoHandler{},
 	}
 }
@@ -51,9 +53,32 @@ func (f *ForkHandler) handleUndo(
 	return nil
 }
 
+// sentOutputsOf returns the reversible outputs of `blockID` if they were sent to the
+// client, nil for a block skipped by the request's output filter or `skip_empty_outputs`.
+func (f *ForkHandler) sentOutputsOf(blockID string) []*pbssinternal.ModuleOutput {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if !f.sentBlocks[blockID] {
+		return nil
+	}
+	return f.reversibleOutputs[blockID]
+}
+
+func (f *ForkHandler) markSent(blockID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.sentBlocks == nil {
+		f.sentBlocks = make(map[string]bool)
+	}
+	f.sentBlocks[blockID] = true
+}
+
 func (f *ForkHandler) removeReversibleOutput(blockID string) {
 	f.mu.Lock()
 	delete(f.reversibleOutputs, blockID)
+	delete(f.sentBlocks, blockID)
 	f.mu.Unlock()
 }
 
@@ -5,7 +5,10 @@ import (
 
 	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 var reversibleOutputs = map[string][]*pbssinternal.ModuleOutput{
@@ -111,3 +114,54 @@ func Test_HandleIrreversibility(t *testing.T) {
 		})
 	}
 }
+
+func TestPipeline_revertedBlock(t *testing.T) {
+	mapModule := func(name string) *pbsubstreams.Module {
+		return &pbsubstreams.Module{
+			Name:   name,
+			Kind:   &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{OutputType: "proto:test.Output"}},
+			Inputs: []*pbsubstreams.Module_Input{{Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "test.Block"}}}},
+		}
+	}
+	graph, err := outputmodules.NewOutputModuleGraph("map_a", true, &pbsubstreams.Modules{
+		Modules:  []*pbsubstreams.Module{mapModule("map_a"), mapModule("map_b"), mapModule("map_c")},
+		Binaries: []*pbsubstreams.Binary{{Type: "wasm/rust-v1"}},
+	}, "map_c", "map_b")
+	require.NoError(t, err)
+
+	output := func(name string) *pbssinternal.ModuleOutput {
+		return &pbssinternal.ModuleOutput{ModuleName: name, Data: &pbssinternal.ModuleOutput_MapOutput{MapOutput: &anypb.Any{Value: []byte(name)}}}
+	}
+	forkHandler := NewForkHandler()
+	for _, name := range []string{"map_a", "map_b", "map_c"} {
+		forkHandler.addReversibleOutput(output(name), "10b")
+	}
+	forkHandler.addReversibleOutput(output("map_a"), "11b")
+	forkHandler.addReversibleOutput(output("map_a"), "13b") // skipped by the output filter
+	forkHandler.markSent("10b")
+	forkHandler.markSent("11b")
+
+	p := &Pipeline{outputGraph: graph, forkHandler: forkHandler}
+
+	reverted := p.revertedBlock(&pbsubstreams.Clock{Id: "10b", Number: 10})
+	assert.Equal(t, "10b", reverted.Clock.Id)
+	assert.Equal(t, "map_a", reverted.Output.Name)
+	require.Len(t, reverted.AdditionalOutputs, 2)
+	assert.Equal(t, "map_c", reverted.AdditionalOutputs[0].Name) // in request order
+	assert.Equal(t, "map_b", reverted.AdditionalOutputs[1].Name)
+
+	reverted = p.revertedBlock(&pbsubstreams.Clock{Id: "11b", Number: 11})
+	assert.Equal(t, "map_a", reverted.Output.Name)
+	assert.Empty(t, reverted.AdditionalOutputs)
+
+	reverted = p.revertedBlock(&pbsubstreams.Clock{Id: "12b", Number: 12})
+	assert.Nil(t, reverted.Output)
+
+	reverted = p.revertedBlock(&pbsubstreams.Clock{Id: "13b", Number: 13})
+	assert.Equal(t, "13b", reverted.Clock.Id)
+	assert.Nil(t, reverted.Output, "outputs of a block that was not sent are not reverted")
+
+	forkHandler.removeReversibleOutput("11b")
+	assert.Empty(t, forkHandler.sentOutputsOf("11b"))
+	assert.NotContains(t, forkHandler.sentBlocks, "11b")
+}
@@ -3,6 +3,7 @@ package pipeline
 import (
 	"github.com/streamingfast/substreams"
 	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/pipeline/outputfilter"
 )
 
 type Option func(p *Pipeline)
@@ -38,6 +39,13 @@ func WithFinalBlocksOnly() Option {
 	}
 }
 
+// WithOutputSkipper skips sending the blocks rejected by `skipper`, heartbeats are sent in their place when due
+func WithOutputSkipper(skipper *outputfilter.Skipper) Option {
+	return func(p *Pipeline) {
+		p.outputSkipper = skipper
+	}
+}
+
 func WithHighestStage(stage uint32) Option {
 	return func(p *Pipeline) {
 		s := int(stage)
@@ -0,0 +1,177 @@
+package outputfilter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// outputVariable is the name under which the decoded output is exposed to the expression.
+const outputVariable = "output"
+
+const (
+	// maxCost bounds the cost of an expression, in CEL cost units: expressions whose
+	// estimated cost exceeds it are rejected, evaluations reaching it fail.
+	maxCost = 10_000_000
+
+	// estimatedMaxSize is the size assumed for the lists, maps, strings and bytes of
+	// the outputs when estimating the cost of an expression.
+	estimatedMaxSize = 10_000
+
+	// interruptCheckFrequency is the number of comprehension iterations between two
+	// checks of the evaluation context.
+	interruptCheckFrequency = 100
+)
+
+// Filter evaluates a CEL expression on the decoded outputs of the output module,
+// to decide if a block is sent to the client.
+type Filter struct {
+	expression string
+	descriptor protoreflect.MessageDescriptor
+	program    cel.Program
+}
+
+// New compiles `expression` for the outputs of `module`, which must evaluate to
+// a boolean. The output type is resolved from `protoFiles`, or from the types
+// known by the server, which covers the deltas of a store output module.
+func New(expression string, module *pbsubstreams.Module, protoFiles []*descriptorpb.FileDescriptorProto) (*Filter, error) {
+	outputType := "sf.substreams.v1.StoreDeltas"
+	if module.GetKindMap() != nil {
+		outputType = strings.TrimPrefix(module.Output.Type, "proto:")
+	}
+
+	files, err := newFiles(protoFiles)
+	if err != nil {
+		return nil, fmt.Errorf("output filter: %w", err)
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(outputType))
+	if err != nil {
+		desc, err = protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(outputType))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("output filter: output type %q of module %q not found in proto files", outputType, module.Name)
+	}
+	msgDesc, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("output filter: output type %q of module %q is not a message", outputType, module.Name)
+	}
+
+	env, err := cel.NewEnv(
+		cel.TypeDescs(msgDesc.ParentFile()),
+		cel.Variable(outputVariable, cel.ObjectType(outputType)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("output filter: creating environment: %w", err)
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("output filter: invalid expression %q: %w", expression, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("output filter: expression %q must evaluate to a bool, got %s", expression, ast.OutputType())
+	}
+
+	cost, err := env.EstimateCost(ast, sizeEstimator{})
+	if err != nil {
+		return nil, fmt.Errorf("output filter: estimating cost of expression %q: %w", expression, err)
+	}
+	if cost.Max > maxCost {
+		return nil, fmt.Errorf("output filter: expression %q is too expensive, its estimated cost of %d exceeds the limit of %d", expression, cost.Max, maxCost)
+	}
+
+	program, err := env.Program(ast,
+		cel.CostLimit(maxCost),
+		cel.InterruptCheckFrequency(interruptCheckFrequency),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("output filter: building program: %w", err)
+	}
+
+	return &Filter{
+		expression: expression,
+		descriptor: msgDesc,
+		program:    program,
+	}, nil
+}
+
+func (f *Filter) String() string {
+	return f.expression
+}
+
+// Matches returns true if `output` passes the filter. A missing output is evaluated
+// as an empty message.
+func (f *Filter) Matches(ctx context.Context, output *pbsubstreamsrpc.MapModuleOutput) (bool, error) {
+	msg := dynamicpb.NewMessage(f.descriptor)
+	if err := proto.Unmarshal(output.GetMapOutput().GetValue(), msg); err != nil {
+		return false, fmt.Errorf("output filter: decoding output of module %q: %w", output.GetName(), err)
+	}
+
+	val, _, err := f.program.ContextEval(ctx, map[string]any{outputVariable: msg})
+	if err != nil {
+		return false, fmt.Errorf("output filter: evaluating %q: %w", f.expression, err)
+	}
+
+	matches, ok := val.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("output filter: expression %q evaluated to %T, expected a bool", f.expression, val.Value())
+	}
+	return matches, nil
+}
+
+// sizeEstimator assumes estimatedMaxSize for every value whose size is unknown, the
+// default estimation assumes unbounded sizes which rejects any comprehension.
+type sizeEstimator struct{}
+
+func (sizeEstimator) EstimateSize(checker.AstNode) *checker.SizeEstimate {
+	return &checker.SizeEstimate{Min: 0, Max: estimatedMaxSize}
+}
+
+func (sizeEstimator) EstimateCallCost(string, string, *checker.AstNode, []checker.AstNode) *checker.CallEstimate {
+	return nil
+}
+
+// newFiles builds a registry from `protoFiles`, imports missing from it, like the
+// well-known types, are taken from the ones compiled in the server.
+func newFiles(protoFiles []*descriptorpb.FileDescriptorProto) (*protoregistry.Files, error) {
+	set := &descriptorpb.FileDescriptorSet{File: append([]*descriptorpb.FileDescriptorProto(nil), protoFiles...)}
+
+	known := make(map[string]bool, len(protoFiles))
+	for _, file := range protoFiles {
+		known[file.GetName()] = true
+	}
+
+	// appended files are visited in turn, resolving the transitive imports
+	for i := 0; i < len(set.File); i++ {
+		for _, dep := range set.File[i].Dependency {
+			if known[dep] {
+				continue
+			}
+			fd, err := protoregistry.GlobalFiles.FindFileByPath(dep)
+			if err != nil {
+				return nil, fmt.Errorf("proto file %q imported by %q not found", dep, set.File[i].GetName())
+			}
+			known[dep] = true
+			set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+		}
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("building proto files: %w", err)
+	}
+	return files, nil
+}
@@ -0,0 +1,149 @@
+package outputfilter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func TestFilter_Matches(t *testing.T) {
+	noTransfers := testTransfersOutput(t)
+	smallTransfer := testTransfersOutput(t, 10)
+	largeTransfer := testTransfersOutput(t, 10, 2000)
+
+	tests := []struct {
+		name       string
+		expression string
+		output     *pbsubstreamsrpc.MapModuleOutput
+		expect     bool
+	}{
+		{"size no transfers", "size(output.transfers) > 0", noTransfers, false},
+		{"size with transfers", "size(output.transfers) > 0", smallTransfer, true},
+		{"missing output", "size(output.transfers) > 0", nil, false},
+		{"exists no match", "output.transfers.exists(t, t.amount > 1000u)", smallTransfer, false},
+		{"exists match", "output.transfers.exists(t, t.amount > 1000u)", largeTransfer, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := New(test.expression, testMapModule("proto:test.Transfers"), []*descriptorpb.FileDescriptorProto{testTransfersFile()})
+			require.NoError(t, err)
+
+			matches, err := filter.Matches(context.Background(), test.output)
+			require.NoError(t, err)
+			assert.Equal(t, test.expect, matches)
+		})
+	}
+}
+
+func TestFilter_StoreDeltas(t *testing.T) {
+	filter, err := New(`output.store_deltas.exists(d, d.key == "a")`, &pbsubstreams.Module{Name: "store_mod", Kind: &pbsubstreams.Module_KindStore_{KindStore: &pbsubstreams.Module_KindStore{}}}, nil)
+	require.NoError(t, err)
+
+	output, err := pbsubstreamsrpc.NewStoreDeltasOutput("store_mod", &pbsubstreams.StoreDeltas{StoreDeltas: []*pbsubstreams.StoreDelta{{Key: "a"}}})
+	require.NoError(t, err)
+
+	matches, err := filter.Matches(context.Background(), output)
+	require.NoError(t, err)
+	assert.True(t, matches)
+}
+
+func TestFilter_MatchesInterrupted(t *testing.T) {
+	filter, err := New("output.transfers.exists(t, t.amount > 1000u)", testMapModule("proto:test.Transfers"), []*descriptorpb.FileDescriptorProto{testTransfersFile()})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = filter.Matches(ctx, testTransfersOutput(t, make([]uint64, 2*interruptCheckFrequency)...))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "operation interrupted")
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		outputType string
+		expectErr  error
+	}{
+		{"invalid expression", "size(output.transfers) >", "proto:test.Transfers", fmt.Errorf("output filter: invalid expression %q", "size(output.transfers) >")},
+		{"unknown field", "size(output.unknown) > 0", "proto:test.Transfers", fmt.Errorf("output filter: invalid expression %q", "size(output.unknown) > 0")},
+		{"not a bool", "size(output.transfers)", "proto:test.Transfers", fmt.Errorf("output filter: expression %q must evaluate to a bool, got int", "size(output.transfers)")},
+		{"too expensive", "output.transfers.exists(a, output.transfers.exists(b, a.amount == b.amount))", "proto:test.Transfers", fmt.Errorf("output filter: expression %q is too expensive", "output.transfers.exists(a, output.transfers.exists(b, a.amount == b.amount))")},
+		{"unknown type", "true", "proto:test.Unknown", fmt.Errorf(`output filter: output type "test.Unknown" of module "map_mod" not found in proto files`)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(test.expression, testMapModule(test.outputType), []*descriptorpb.FileDescriptorProto{testTransfersFile()})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectErr.Error())
+		})
+	}
+}
+
+func testMapModule(outputType string) *pbsubstreams.Module {
+	return &pbsubstreams.Module{
+		Name:   "map_mod",
+		Kind:   &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{OutputType: outputType}},
+		Output: &pbsubstreams.Module_Output{Type: outputType},
+	}
+}
+
+func testTransfersFile() *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/transfers.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Transfers"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("transfers"), JsonName: proto.String("transfers"), Number: proto.Int32(1), Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), TypeName: proto.String(".test.Transfer")},
+				},
+			},
+			{
+				Name: proto.String("Transfer"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("amount"), JsonName: proto.String("amount"), Number: proto.Int32(1), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_UINT64.Enum()},
+				},
+			},
+		},
+	}
+}
+
+func testTransfersOutput(t *testing.T, amounts ...uint64) *pbsubstreamsrpc.MapModuleOutput {
+	t.Helper()
+
+	file, err := protodesc.NewFile(testTransfersFile(), nil)
+	require.NoError(t, err)
+	transfersDesc := file.Messages().ByName("Transfers")
+	transferDesc := file.Messages().ByName("Transfer")
+
+	transfers := dynamicpb.NewMessage(transfersDesc)
+	list := transfers.Mutable(transfersDesc.Fields().ByName("transfers")).List()
+	for _, amount := range amounts {
+		transfer := dynamicpb.NewMessage(transferDesc)
+		transfer.Set(transferDesc.Fields().ByName("amount"), protoreflect.ValueOfUint64(amount))
+		list.Append(protoreflect.ValueOfMessage(transfer))
+	}
+
+	payload, err := proto.Marshal(transfers)
+	require.NoError(t, err)
+	return &pbsubstreamsrpc.MapModuleOutput{
+		Name:      "map_mod",
+		MapOutput: &anypb.Any{TypeUrl: "type.googleapis.com/test.Transfers", Value: payload},
+	}
+}
@@ -0,0 +1,79 @@
+package outputfilter
+
+import (
+	"context"
+	"time"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+)
+
+const DefaultHeartbeatInterval = 10 * time.Second
+
+// Skipper decides which blocks are sent to the client, from the request's
+// `output_filter` and `skip_empty_outputs`. A skipped block is sent as a
+// `Heartbeat` when no message was sent for the heartbeat interval, so that
+// consumers can still persist their cursor.
+//
+// A nil Skipper sends every block.
+type Skipper struct {
+	filter            *Filter
+	skipEmptyOutputs  bool
+	heartbeatInterval time.Duration
+
+	lastSent time.Time
+	now      func() time.Time
+}
+
+// NewSkipper returns nil when no block can be skipped. A zero `heartbeatInterval`
+// uses DefaultHeartbeatInterval.
+func NewSkipper(filter *Filter, skipEmptyOutputs bool, heartbeatInterval time.Duration) *Skipper {
+	if filter == nil && !skipEmptyOutputs {
+		return nil
+	}
+	if heartbeatInterval == 0 {
+		heartbeatInterval = DefaultHeartbeatInterval
+	}
+
+	return &Skipper{
+		filter:            filter,
+		skipEmptyOutputs:  skipEmptyOutputs,
+		heartbeatInterval: heartbeatInterval,
+		lastSent:          time.Now(),
+		now:               time.Now,
+	}
+}
+
+// Skip returns true if `data` must not be sent, along with the heartbeat to send
+// in its place when one is due. `last` is set for the last block of the request,
+// a skipped last block is always sent as a heartbeat.
+func (s *Skipper) Skip(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, last bool) (skip bool, heartbeat *pbsubstreamsrpc.Heartbeat, err error) {
+	if s == nil {
+		return false, nil, nil
+	}
+
+	now := s.now()
+	if s.skipEmptyOutputs && data.IsEmpty() {
+		skip = true
+	} else if s.filter != nil {
+		matches, err := s.filter.Matches(ctx, data.Output)
+		if err != nil {
+			return false, nil, err
+		}
+		skip = !matches
+	}
+
+	if !skip {
+		s.lastSent = now
+		return false, nil, nil
+	}
+
+	if last || now.Sub(s.lastSent) >= s.heartbeatInterval {
+		s.lastSent = now
+		heartbeat = &pbsubstreamsrpc.Heartbeat{
+			Clock:            data.Clock,
+			Cursor:           data.Cursor,
+			FinalBlockHeight: data.FinalBlockHeight,
+		}
+	}
+	return true, heartbeat, nil
+}
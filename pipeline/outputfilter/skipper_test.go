@@ -0,0 +1,108 @@
+package outputfilter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func TestSkipper_Skip(t *testing.T) {
+	filter, err := New("size(output.transfers) > 1", testMapModule("proto:test.Transfers"), []*descriptorpb.FileDescriptorProto{testTransfersFile()})
+	require.NoError(t, err)
+
+	type block struct {
+		output  *pbsubstreamsrpc.MapModuleOutput
+		elapsed time.Duration
+		last    bool
+	}
+	tests := []struct {
+		name             string
+		filter           *Filter
+		skipEmptyOutputs bool
+		blocks           []block
+		expect           string
+	}{
+		{
+			name:             "skip empty outputs",
+			skipEmptyOutputs: true,
+			blocks: []block{
+				{output: testTransfersOutput(t, 1)},
+				{output: testTransfersOutput(t), elapsed: 6 * time.Second},
+				{output: testTransfersOutput(t), elapsed: 6 * time.Second},
+				{output: testTransfersOutput(t), elapsed: 6 * time.Second},
+				{output: testTransfersOutput(t, 1), elapsed: 15 * time.Second},
+				{output: testTransfersOutput(t), elapsed: time.Second, last: true},
+			},
+			expect: "sent skipped heartbeat skipped sent heartbeat",
+		},
+		{
+			name:   "filter",
+			filter: filter,
+			blocks: []block{
+				{output: testTransfersOutput(t, 1, 2)},
+				{output: testTransfersOutput(t, 1), elapsed: 11 * time.Second},
+				{output: testTransfersOutput(t), elapsed: time.Second},
+			},
+			expect: "sent heartbeat skipped",
+		},
+		{
+			name:             "skip empty outputs with filter",
+			filter:           filter,
+			skipEmptyOutputs: true,
+			blocks: []block{
+				{output: testTransfersOutput(t)},
+				{output: testTransfersOutput(t, 1)},
+				{output: testTransfersOutput(t, 1, 2)},
+			},
+			expect: "skipped skipped sent",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			skipper := NewSkipper(test.filter, test.skipEmptyOutputs, 0)
+			skipper.now = func() time.Time { return now }
+			skipper.lastSent = now
+
+			var got []string
+			for i, b := range test.blocks {
+				now = now.Add(b.elapsed)
+				data := &pbsubstreamsrpc.BlockScopedData{Output: b.output, Clock: &pbsubstreams.Clock{Number: uint64(i)}, Cursor: "cursor"}
+
+				skip, heartbeat, err := skipper.Skip(context.Background(), data, b.last)
+				require.NoError(t, err)
+				switch {
+				case heartbeat != nil:
+					assert.True(t, skip)
+					assert.Equal(t, uint64(i), heartbeat.Clock.Number)
+					assert.Equal(t, "cursor", heartbeat.Cursor)
+					got = append(got, "heartbeat")
+				case skip:
+					got = append(got, "skipped")
+				default:
+					got = append(got, "sent")
+				}
+			}
+			assert.Equal(t, test.expect, strings.Join(got, " "))
+		})
+	}
+}
+
+func TestSkipper_Nil(t *testing.T) {
+	skipper := NewSkipper(nil, false, time.Second)
+	assert.Nil(t, skipper)
+
+	skip, heartbeat, err := skipper.Skip(context.Background(), &pbsubstreamsrpc.BlockScopedData{}, true)
+	require.NoError(t, err)
+	assert.False(t, skip)
+	assert.Nil(t, heartbeat)
+}
@@ -2,6 +2,7 @@ package outputmodules
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/streamingfast/substreams/manifest"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
@@ -15,13 +16,22 @@ type Graph struct {
 	stores            []*pbsubstreams.Module // subset of allModules: only the stores
 	lowestInitBlock   uint64
 
-	outputModule *pbsubstreams.Module
+	outputModule            *pbsubstreams.Module
+	additionalOutputModules []*pbsubstreams.Module // map modules streamed along `outputModule`, in request order
 
 	schedulableModules      []*pbsubstreams.Module // stores and output mappers needed to execute to produce output for all `output_modules`.
 	schedulableAncestorsMap map[string][]string    // modules that are ancestors (therefore dependencies) of a given module
 }
 
-func (g *Graph) OutputModule() *pbsubstreams.Module  { return g.outputModule }
+func (g *Graph) OutputModule() *pbsubstreams.Module { return g.outputModule }
+func (g *Graph) AdditionalOutputModules() []*pbsubstreams.Module {
+	return g.additionalOutputModules
+}
+
+// OutputModules returns the output module followed by the additional output modules.
+func (g *Graph) OutputModules() []*pbsubstreams.Module {
+	return append([]*pbsubstreams.Module{g.outputModule}, g.additionalOutputModules...)
+}
 func (g *Graph) Stores() []*pbsubstreams.Module      { return g.stores }
 func (g *Graph) UsedModules() []*pbsubstreams.Module { return g.usedModules }
 func (g *Graph) UsedModulesUpToStage(stage int) (out []*pbsubstreams.Module) {
@@ -34,50 +44,64 @@ func (g *Graph) UsedModulesUpToStage(stage int) (out []*pbsubstreams.Module) {
 	}
 	return
 }
-func (g *Graph) StagedUsedModules() ExecutionStages   { return g.stagedUsedModules }
-func (g *Graph) IsOutputModule(name string) bool      { return g.outputModule.Name == name }
+func (g *Graph) StagedUsedModules() ExecutionStages { return g.stagedUsedModules }
+func (g *Graph) IsOutputModule(name string) bool {
+	for _, mod := range g.OutputModules() {
+		if mod.Name == name {
+			return true
+		}
+	}
+	return false
+}
 func (g *Graph) ModuleHashes() *manifest.ModuleHashes { return g.moduleHashes }
 func (g *Graph) LowestInitBlock() uint64              { return g.lowestInitBlock }
 
-func NewOutputModuleGraph(outputModule string, productionMode bool, modules *pbsubstreams.Modules) (out *Graph, err error) {
+func NewOutputModuleGraph(outputModule string, productionMode bool, modules *pbsubstreams.Modules, additionalOutputModules ...string) (out *Graph, err error) {
 	out = &Graph{
 		requestModules: modules,
 	}
-	if err := out.computeGraph(outputModule, productionMode, modules); err != nil {
+	if err := out.computeGraph(outputModule, additionalOutputModules, productionMode, modules); err != nil {
 		return nil, fmt.Errorf("module graph: %w", err)
 	}
 
 	return out, nil
 }
 
-func (g *Graph) computeGraph(outputModule string, productionMode bool, modules *pbsubstreams.Modules) error {
+func (g *Graph) computeGraph(outputModule string, additionalOutputModules []string, productionMode bool, modules *pbsubstreams.Modules) error {
 	graph, err := manifest.NewModuleGraph(modules.Modules)
 	if err != nil {
 		return fmt.Errorf("compute graph: %w", err)
 	}
-	outputModuleName := outputModule
+	outputModuleNames := append([]string{outputModule}, additionalOutputModules...)
 
-	processModules, err := graph.ModulesDownTo(outputModuleName)
+	if err := validateIndependentOutputs(graph, outputModuleNames); err != nil {
+		return err
+	}
+
+	processModules, err := modulesDownTo(graph, outputModuleNames, graph.ModulesDownTo)
 	if err != nil {
 		return fmt.Errorf("building execution moduleGraph: %w", err)
 	}
 	g.usedModules = processModules
-	g.stagedUsedModules = computeStages(processModules)
+	g.stagedUsedModules = computeStages(processModules, outputModuleNames...)
 	g.lowestInitBlock = computeLowestInitBlock(processModules)
 
 	if err := g.hashModules(graph); err != nil {
 		return fmt.Errorf("cannot hash module: %w", err)
 	}
 
-	g.outputModule = computeOutputModule(g.usedModules, outputModuleName)
+	g.outputModule = computeOutputModule(g.usedModules, outputModule)
+	for _, name := range additionalOutputModules {
+		g.additionalOutputModules = append(g.additionalOutputModules, computeOutputModule(g.usedModules, name))
+	}
 
-	storeModules, err := graph.StoresDownTo(g.outputModule.Name)
+	storeModules, err := modulesDownTo(graph, outputModuleNames, graph.StoresDownTo)
 	if err != nil {
 		return fmt.Errorf("stores down: %w", err)
 	}
 	g.stores = storeModules
 
-	g.schedulableModules = computeSchedulableModules(storeModules, g.outputModule, productionMode)
+	g.schedulableModules = computeSchedulableModules(storeModules, g.OutputModules(), productionMode)
 
 	ancestorsMap, err := computeSchedulableAncestors(graph, g.schedulableModules)
 	if err != nil {
@@ -88,6 +112,69 @@ func (g *Graph) computeGraph(outputModule string, productionMode bool, modules *
 	return nil
 }
 
+// validateIndependentOutputs ensures that no output module is an ancestor of another one.
+func validateIndependentOutputs(graph *manifest.ModuleGraph, outputModuleNames []string) error {
+	if len(outputModuleNames) < 2 {
+		return nil
+	}
+
+	outputs := map[string]bool{}
+	for _, name := range outputModuleNames {
+		outputs[name] = true
+	}
+
+	for _, name := range outputModuleNames {
+		ancestors, err := graph.AncestorsOf(name)
+		if err != nil {
+			return fmt.Errorf("computing ancestors of %q: %w", name, err)
+		}
+		for _, ancestor := range ancestors {
+			if outputs[ancestor.Name] {
+				return fmt.Errorf("output module %q depends on output module %q", name, ancestor.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// modulesDownTo merges the modules returned by `downTo` for each of `outputModuleNames`,
+// keeping the ordering of a single call.
+func modulesDownTo(graph *manifest.ModuleGraph, outputModuleNames []string, downTo func(string) ([]*pbsubstreams.Module, error)) ([]*pbsubstreams.Module, error) {
+	if len(outputModuleNames) == 1 {
+		return downTo(outputModuleNames[0])
+	}
+
+	sortedModules, ok := graph.TopologicalSort()
+	if !ok {
+		return nil, fmt.Errorf("could not get topological sort of module graph")
+	}
+	topologicalIndex := map[string]int{}
+	for i, node := range sortedModules {
+		topologicalIndex[node.Name] = i
+	}
+
+	alreadyAdded := map[string]bool{}
+	var res []*pbsubstreams.Module
+	for _, name := range outputModuleNames {
+		mods, err := downTo(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mod := range mods {
+			if alreadyAdded[mod.Name] {
+				continue
+			}
+			res = append(res, mod)
+			alreadyAdded[mod.Name] = true
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return topologicalIndex[res[i].Name] > topologicalIndex[res[j].Name]
+	})
+	return res, nil
+}
+
 func computeLowestInitBlock(modules []*pbsubstreams.Module) (out uint64) {
 	lowest := modules[0].InitialBlock
 	for _, mod := range modules {
@@ -126,9 +213,21 @@ func (l LayerModules) IsStoreLayer() bool {
 	return l[0].GetKindStore() != nil
 }
 
-func computeStages(mods []*pbsubstreams.Module) (stages ExecutionStages) {
+// computeStages groups `mods` in execution stages. The `deferredMaps` are only placed once
+// every other module is, so that additional output modules all land in the last stage.
+func computeStages(mods []*pbsubstreams.Module, deferredMaps ...string) (stages ExecutionStages) {
 	seen := map[string]bool{}
 
+	deferred := map[string]bool{}
+	for _, mod := range mods {
+		for _, name := range deferredMaps {
+			if mod.Name == name && mod.GetKindMap() != nil {
+				deferred[mod.Name] = true
+			}
+		}
+	}
+	seenNonDeferred := 0
+
 	var layers StageLayers
 
 	for i := 0; ; i++ {
@@ -153,6 +252,10 @@ func computeStages(mods []*pbsubstreams.Module) (stages ExecutionStages) {
 				continue
 			}
 
+			if deferred[mod.Name] && seenNonDeferred < len(mods)-len(deferred) {
+				continue
+			}
+
 			for _, dep := range mod.Inputs {
 				var depModName string
 				switch input := dep.Input.(type) {
@@ -178,6 +281,9 @@ func computeStages(mods []*pbsubstreams.Module) (stages ExecutionStages) {
 			layers = append(layers, layer)
 			for _, mod := range layer {
 				seen[mod.Name] = true
+				if !deferred[mod.Name] {
+					seenNonDeferred++
+				}
 			}
 		}
 	}
@@ -208,16 +314,19 @@ func computeOutputModule(mods []*pbsubstreams.Module, outputModule string) *pbsu
 
 }
 
-func computeSchedulableModules(stores []*pbsubstreams.Module, outputModule *pbsubstreams.Module, productionMode bool) []*pbsubstreams.Module {
+func computeSchedulableModules(stores []*pbsubstreams.Module, outputModules []*pbsubstreams.Module, productionMode bool) []*pbsubstreams.Module {
 	if !productionMode { // dev never schedules maps, all stores are in there
 		return stores
 	}
 
-	if outputModule.GetKindStore() != nil {
-		return stores
+	out := stores
+	for _, outputModule := range outputModules {
+		if outputModule.GetKindStore() != nil {
+			continue
+		}
+		out = append(out, outputModule)
 	}
-
-	return append(stores, outputModule)
+	return out
 }
 
 func computeSchedulableAncestors(graph *manifest.ModuleGraph, schedulableModules []*pbsubstreams.Module) (out map[string][]string, err error) {
@@ -258,8 +367,10 @@ func (g *Graph) hashModules(graph *manifest.ModuleGraph) error {
 }
 
 func (g *Graph) ValidateRequestStartBlock(requestStartBlockNum uint64) error {
-	if requestStartBlockNum < g.outputModule.InitialBlock {
-		return fmt.Errorf("start block %d smaller than request outputs for module %q with start block %d", requestStartBlockNum, g.outputModule.Name, g.outputModule.InitialBlock)
+	for _, outputModule := range g.OutputModules() {
+		if requestStartBlockNum < outputModule.InitialBlock {
+			return fmt.Errorf("start block %d smaller than request outputs for module %q with start block %d", requestStartBlockNum, outputModule.Name, outputModule.InitialBlock)
+		}
 	}
 	return nil
 }
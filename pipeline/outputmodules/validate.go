@@ -18,7 +18,7 @@ func ValidateTier1Request(request *pbsubstreamsrpc.Request, blockType string) er
 		return fmt.Errorf("validate tier1 request: %s", err)
 	}
 
-	err := validateRequest(request.Modules.Binaries, request.Modules, request.OutputModule, blockType)
+	err := validateRequest(request.Modules.Binaries, request.Modules, request.AllOutputModules(), blockType)
 	if err != nil {
 		return err
 	}
@@ -31,7 +31,7 @@ func ValidateTier2Request(request *pbssinternal.ProcessRangeRequest) error {
 		return fmt.Errorf("validate tier2 request: %s", err)
 	}
 
-	err := validateRequest(request.Modules.Binaries, request.Modules, request.OutputModule, request.BlockType)
+	err := validateRequest(request.Modules.Binaries, request.Modules, append([]string{request.OutputModule}, request.AdditionalOutputModules...), request.BlockType)
 	if err != nil {
 		return err
 	}
@@ -39,7 +39,7 @@ func ValidateTier2Request(request *pbssinternal.ProcessRangeRequest) error {
 	return nil
 }
 
-func validateRequest(binaries []*pbsubstreams.Binary, modules *pbsubstreams.Modules, outputModule string, blockType string) error {
+func validateRequest(binaries []*pbsubstreams.Binary, modules *pbsubstreams.Modules, outputModules []string, blockType string) error {
 	if err := validateBinaryTypes(binaries); err != nil {
 		return err
 	}
@@ -48,7 +48,7 @@ func validateRequest(binaries []*pbsubstreams.Binary, modules *pbsubstreams.Modu
 		return fmt.Errorf("modules validation failed: %w", err)
 	}
 
-	if err := validateModuleGraph(modules.Modules, outputModule, blockType); err != nil {
+	if err := validateModuleGraph(modules.Modules, outputModules, blockType); err != nil {
 		return err
 	}
 
@@ -70,26 +70,32 @@ func checkNotImplemented(mods []*pbsubstreams.Module) error {
 	return nil
 }
 
-func validateModuleGraph(mods []*pbsubstreams.Module, outputModule string, blockType string) error {
+func validateModuleGraph(mods []*pbsubstreams.Module, outputModules []string, blockType string) error {
 	graph, err := manifest.NewModuleGraph(mods)
 	if err != nil {
 		return fmt.Errorf("should have been able to derive modules graph: %w", err)
 	}
 
-	// Already validated by `ValidateTier1Request` above, so we can use the `Must...` version
-	ancestors, err := graph.AncestorsOf(outputModule)
-	if err != nil {
-		return fmt.Errorf("computing ancestors of %q: %w", outputModule, err)
+	if err := validateIndependentOutputs(graph, outputModules); err != nil {
+		return err
 	}
 
-	// We must only validate the input source against module that we are going to actually run. A Substreams
-	// could provide modules for multiple chain while executing only one of them in which case only the one
-	// run (and its dependencies transitively) should be checked.
-	for _, mod := range ancestors {
-		for _, input := range mod.Inputs {
-			if src := input.GetSource(); src != nil {
-				if src.Type != blockType && src.Type != "sf.substreams.v1.Clock" {
-					return fmt.Errorf("input source %q not supported, only %q and 'sf.substreams.v1.Clock' are valid", src, blockType)
+	for _, outputModule := range outputModules {
+		// Already validated by `ValidateTier1Request` above, so we can use the `Must...` version
+		ancestors, err := graph.AncestorsOf(outputModule)
+		if err != nil {
+			return fmt.Errorf("computing ancestors of %q: %w", outputModule, err)
+		}
+
+		// We must only validate the input source against module that we are going to actually run. A Substreams
+		// could provide modules for multiple chain while executing only one of them in which case only the one
+		// run (and its dependencies transitively) should be checked.
+		for _, mod := range ancestors {
+			for _, input := range mod.Inputs {
+				if src := input.GetSource(); src != nil {
+					if src.Type != blockType && src.Type != "sf.substreams.v1.Clock" {
+						return fmt.Errorf("input source %q not supported, only %q and 'sf.substreams.v1.Clock' are valid", src, blockType)
+					}
 				}
 			}
 		}
@@ -27,6 +27,9 @@ func Test_ValidateRequest(t *testing.T) {
 		{"single legacy map output module is accepted for none sub-request", req(1, testOutputMap), testBlockType, nil},
 		{"single map output module is accepted for none sub-request", req(1, testOutputMap), testBlockType, nil},
 		{"single store output module is not accepted for none sub-request", req(1, testOutputStore), testBlockType, fmt.Errorf("validate tier1 request: output module must be of kind 'map'")},
+		{"single store output module is accepted in production mode", req(1, testOutputStore, withProductionMode()), testBlockType, nil},
+		{"independent additional output module is accepted", req(1, testOutputMap, withAdditionalOutputModule("extra_mod")), testBlockType, nil},
+		{"additional output module depending on output module is not accepted", req(1, testOutputMap, withAdditionalOutputModule("extra_mod", "output_mod")), testBlockType, fmt.Errorf(`output module "extra_mod" depends on output module "output_mod"`)},
 		{"debug initial snapshots not accepted in production mode", req(1, testOutputMap, withDebugInitialSnapshotForModules([]string{"foo"}), withProductionMode()), "", fmt.Errorf(`validate tier1 request: cannot set 'debug-modules-initial-snapshot' in 'production-mode'`)},
 	}
 
@@ -51,6 +54,21 @@ func withOutputModule(outputModule, kind string) reqOption {
 	}
 }
 
+func withAdditionalOutputModule(name string, mapInputs ...string) reqOption {
+	return func(req *pbsubstreamsrpc.Request) *pbsubstreamsrpc.Request {
+		module := &pbsubstreams.Module{
+			Name: name,
+			Kind: &pbsubstreams.Module_KindMap_{},
+		}
+		for _, input := range mapInputs {
+			module.Inputs = append(module.Inputs, &pbsubstreams.Module_Input{Input: &pbsubstreams.Module_Input_Map_{Map: &pbsubstreams.Module_Input_Map{ModuleName: input}}})
+		}
+		req.Modules.Modules = append(req.Modules.Modules, module)
+		req.AdditionalOutputModules = append(req.AdditionalOutputModules, name)
+		return req
+	}
+}
+
 func withProductionMode() reqOption {
 	return func(req *pbsubstreamsrpc.Request) *pbsubstreamsrpc.Request {
 		req.ProductionMode = true
@@ -20,6 +20,7 @@ import (
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	"github.com/streamingfast/substreams/pipeline/cache"
 	"github.com/streamingfast/substreams/pipeline/exec"
+	"github.com/streamingfast/substreams/pipeline/outputfilter"
 	"github.com/streamingfast/substreams/pipeline/outputmodules"
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/service/config"
@@ -48,9 +49,11 @@ type Pipeline struct {
 	moduleExecutors [][]exec.ModuleExecutor // Staged module executors
 	executionStages outputmodules.ExecutionStages
 
-	mapModuleOutput         *pbsubstreamsrpc.MapModuleOutput
-	extraMapModuleOutputs   []*pbsubstreamsrpc.MapModuleOutput
-	extraStoreModuleOutputs []*pbsubstreamsrpc.StoreModuleOutput
+	mapModuleOutput            *pbsubstreamsrpc.MapModuleOutput
+	additionalMapModuleOutputs []*pbsubstreamsrpc.MapModuleOutput // indexed like the graph's additional output modules
+	extraMapModuleOutputs      []*pbsubstreamsrpc.MapModuleOutput
+	extraStoreModuleOutputs    []*pbsubstreamsrpc.StoreModuleOutput
+	outputSkipper              *outputfilter.Skipper
 
 	respFunc         substreams.ResponseFunc
 	lastProgressSent time.Time
@@ -164,13 +167,29 @@ func (p *Pipeline) InitTier1StoresAndBackprocess(ctx context.Context, reqPlan *p
 	return nil
 }
 
+// Backfill runs the parallel processing of `reqPlan` to cache the stores and the outputs
+// of the graph, without reading them back: only the progress is sent. `reqPlan` must not
+// have a ReadExecOut nor a LinearPipeline.
+func (p *Pipeline) Backfill(ctx context.Context, reqPlan *plan.RequestPlan) error {
+	if reqPlan.ReadExecOut != nil || reqPlan.LinearPipeline != nil {
+		return fmt.Errorf("backfill plan must only write, got %s", reqPlan)
+	}
+	if !reqPlan.RequiresParallelProcessing() {
+		return nil
+	}
+	if _, err := p.runParallelProcess(ctx, reqPlan); err != nil {
+		return fmt.Errorf("run_parallel_process failed: %w", err)
+	}
+	return nil
+}
+
 func (p *Pipeline) GetStoreMap() store.Map {
 	return p.stores.StoreMap
 }
 
 func (p *Pipeline) setupProcessingModule(reqDetails *reqctx.RequestDetails) {
 	for _, module := range reqDetails.Modules.Modules {
-		if reqDetails.IsOutputModule(module.Name) {
+		if module.Name == reqDetails.OutputModule {
 			p.processingModule = &processingModule{
 				name:            module.GetName(),
 				initialBlockNum: reqDetails.ResolvedStartBlockNum,
@@ -259,6 +278,7 @@ func (p *Pipeline) runParallelProcess(ctx context.Context, reqPlan *plan.Request
 		p.execoutStorage,
 		p.respFunc,
 		p.stores.configs,
+		p.outputSkipper,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("building parallel processor: %w", err)
@@ -328,12 +348,28 @@ func toRPCStoreModuleOutputs(in *pbssinternal.ModuleOutput) (out *pbsubstreamsrp
 	return &pbsubstreamsrpc.StoreModuleOutput{
 		Name:             in.ModuleName,
 		DebugStoreDeltas: toRPCDeltas(deltas),
-		DebugInfo: &pbsubstreamsrpc.OutputDebugInfo{
-			Logs:          in.Logs,
-			LogsTruncated: in.DebugLogsTruncated,
-			Cached:        in.Cached,
-		},
+		DebugInfo:        toRPCDebugInfo(in),
+	}
+}
+
+func toRPCDebugInfo(in *pbssinternal.ModuleOutput) *pbsubstreamsrpc.OutputDebugInfo {
+	out := &pbsubstreamsrpc.OutputDebugInfo{
+		LogsTruncated: in.DebugLogsTruncated,
+		Cached:        in.Cached,
 	}
+
+	if len(in.Logs) == 0 {
+		// tier2 instances that predate structured logs only fill the rendered logs
+		out.Logs = in.LegacyLogs
+		return out
+	}
+
+	for _, entry := range in.Logs {
+		rpcEntry := pbsubstreamsrpc.NewLogEntryFromInternal(entry)
+		out.StructuredLogs = append(out.StructuredLogs, rpcEntry)
+		out.Logs = append(out.Logs, rpcEntry.Render())
+	}
+	return out
 }
 
 func toRPCDeltas(in *pbsubstreams.StoreDeltas) (out []*pbsubstreamsrpc.StoreDelta) {
@@ -374,12 +410,23 @@ func toRPCMapModuleOutputs(in *pbssinternal.ModuleOutput) (out *pbsubstreamsrpc.
 	return &pbsubstreamsrpc.MapModuleOutput{
 		Name:      in.ModuleName,
 		MapOutput: data,
-		DebugInfo: &pbsubstreamsrpc.OutputDebugInfo{
-			Logs:          in.Logs,
-			LogsTruncated: in.DebugLogsTruncated,
-			Cached:        in.Cached,
-		},
+		DebugInfo: toRPCDebugInfo(in),
+	}
+}
+
+// toRPCStoreDeltasOutput returns the deltas of a store module requested as output module.
+func toRPCStoreDeltasOutput(in *pbssinternal.ModuleOutput) *pbsubstreamsrpc.MapModuleOutput {
+	deltas := in.GetStoreDeltas()
+	if deltas == nil {
+		return nil
 	}
+
+	out, err := pbsubstreamsrpc.NewStoreDeltasOutput(in.ModuleName, deltas)
+	if err != nil {
+		panic(err)
+	}
+	out.DebugInfo = toRPCDebugInfo(in)
+	return out
 }
 
 func (p *Pipeline) returnRPCModuleProgressOutputs(clock *pbsubstreams.Clock, forceOutput bool) error {
@@ -447,6 +494,9 @@ func (p *Pipeline) buildModuleExecutors(ctx context.Context) ([][]exec.ModuleExe
 	for _, stage := range p.executionStages {
 		for _, layer := range stage {
 			for _, module := range layer {
+				if module.GetKindMap().GetNamedOutputOf() != "" {
+					continue
+				}
 				if _, exists := loadedModules[module.BinaryIndex]; exists {
 					continue
 				}
@@ -467,6 +517,13 @@ func (p *Pipeline) buildModuleExecutors(ctx context.Context) ([][]exec.ModuleExe
 		for _, layer := range stage {
 			var moduleExecutors []exec.ModuleExecutor
 			for _, module := range layer {
+				if parent := module.GetKindMap().GetNamedOutputOf(); parent != "" {
+					outType := strings.TrimPrefix(module.Output.Type, "proto:")
+					outputName := strings.TrimPrefix(module.Name, parent+".")
+					moduleExecutors = append(moduleExecutors, exec.NewNamedOutputModuleExecutor(module.Name, parent, outputName, outType))
+					continue
+				}
+
 				inputs, err := p.renderWasmInputs(module)
 				if err != nil {
 					return nil, fmt.Errorf("module %q: get wasm inputs: %w", module.Name, err)
@@ -488,6 +545,13 @@ func (p *Pipeline) buildModuleExecutors(ctx context.Context) ([][]exec.ModuleExe
 						tracer,
 					)
 					executor := exec.NewMapperModuleExecutor(baseExecutor, outType)
+					if namedOutputs := kind.KindMap.NamedOutputs; len(namedOutputs) != 0 {
+						names := make([]string, len(namedOutputs))
+						for i, output := range namedOutputs {
+							names[i] = output.Name
+						}
+						executor.WithNamedOutputs(names)
+					}
 					moduleExecutors = append(moduleExecutors, executor)
 
 				case *pbsubstreams.Module_KindStore_:
@@ -541,27 +605,52 @@ func (p *Pipeline) cleanUpModuleExecutors(ctx context.Context) error {
 }
 
 func returnModuleDataOutputs(
+	ctx context.Context,
 	clock *pbsubstreams.Clock,
 	cursor *bstream.Cursor,
 	mapModuleOutput *pbsubstreamsrpc.MapModuleOutput,
+	additionalMapModuleOutputs []*pbsubstreamsrpc.MapModuleOutput,
 	extraMapModuleOutputs []*pbsubstreamsrpc.MapModuleOutput,
 	extraStoreModuleOutputs []*pbsubstreamsrpc.StoreModuleOutput,
+	outputSkipper *outputfilter.Skipper,
+	isLastBlock bool,
 	respFunc substreams.ResponseFunc,
-) error {
+) (sent bool, err error) {
+	var additionalOutputs []*pbsubstreamsrpc.MapModuleOutput
+	for _, output := range additionalMapModuleOutputs {
+		if output != nil {
+			additionalOutputs = append(additionalOutputs, output)
+		}
+	}
+
 	out := &pbsubstreamsrpc.BlockScopedData{
 		Clock:             clock,
 		Output:            mapModuleOutput,
+		AdditionalOutputs: additionalOutputs,
 		DebugMapOutputs:   extraMapModuleOutputs,
 		DebugStoreOutputs: extraStoreModuleOutputs,
 		Cursor:            cursor.ToOpaque(),
 		FinalBlockHeight:  cursor.LIB.Num(),
 	}
 
+	skip, heartbeat, err := outputSkipper.Skip(ctx, out, isLastBlock)
+	if err != nil {
+		return false, err
+	}
+	if heartbeat != nil {
+		if err := respFunc(substreams.NewHeartbeatResponse(heartbeat)); err != nil {
+			return false, fmt.Errorf("calling return func: %w", err)
+		}
+	}
+	if skip {
+		return false, nil
+	}
+
 	if err := respFunc(substreams.NewBlockScopedDataResponse(out)); err != nil {
-		return fmt.Errorf("calling return func: %w", err)
+		return false, fmt.Errorf("calling return func: %w", err)
 	}
 
-	return nil
+	return true, nil
 }
 
 func (p *Pipeline) renderWasmInputs(module *pbsubstreams.Module) (out []wasm.Argument, err error) {
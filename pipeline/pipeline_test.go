@@ -17,6 +17,7 @@ import (
 
 	"github.com/streamingfast/substreams/manifest"
 	"github.com/streamingfast/substreams/metrics"
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
 	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	pbsubstreamstest "github.com/streamingfast/substreams/pb/sf/substreams/v1/test"
@@ -126,6 +127,20 @@ func bstreamBlk(t *testing.T, blk *pbsubstreamstest.Block) *pbbstream.Block {
 	return bb
 }
 
+func TestToRPCDebugInfo(t *testing.T) {
+	structured := toRPCDebugInfo(&pbssinternal.ModuleOutput{
+		Logs:       []*pbssinternal.LogEntry{{Level: pbssinternal.LogLevel_LOG_LEVEL_WARN, Message: "slow"}},
+		LegacyLogs: []string{"[WARN] slow"},
+	})
+	assert.Equal(t, []string{"[WARN] slow"}, structured.Logs)
+	require.Len(t, structured.StructuredLogs, 1)
+	assert.Equal(t, pbsubstreamsrpc.LogLevel_LOG_LEVEL_WARN, structured.StructuredLogs[0].Level)
+
+	legacy := toRPCDebugInfo(&pbssinternal.ModuleOutput{LegacyLogs: []string{"hello"}})
+	assert.Equal(t, []string{"hello"}, legacy.Logs, "logs of a tier2 predating structured logs")
+	assert.Empty(t, legacy.StructuredLogs)
+}
+
 func TestSetupSubrequestStores(t *testing.T) {
 	t.Run("test store types depending on input", func(t *testing.T) {
 
@@ -182,7 +182,9 @@ func (p *Pipeline) handleStepUndo(ctx context.Context, clock *pbsubstreams.Clock
 		return fmt.Errorf("reverting outputs: %w", err)
 	}
 
-	if bstream.EqualsBlockRefs(p.insideReorgUpTo, reorgJunctionBlock) {
+	// with undo outputs, every reverted block is signaled with its outputs
+	undoOutputs := reqctx.Details(ctx).UndoOutputs
+	if !undoOutputs && bstream.EqualsBlockRefs(p.insideReorgUpTo, reorgJunctionBlock) {
 		return nil
 	}
 	p.insideReorgUpTo = reorgJunctionBlock
@@ -196,17 +198,52 @@ func (p *Pipeline) handleStepUndo(ctx context.Context, clock *pbsubstreams.Clock
 
 	targetClock := blockRefToPB(reorgJunctionBlock)
 
+	undoSignal := &pbsubstreamsrpc.BlockUndoSignal{
+		LastValidBlock:  targetClock,
+		LastValidCursor: targetCursor.ToOpaque(),
+	}
+	if undoOutputs {
+		undoSignal.RevertedBlock = p.revertedBlock(clock)
+	}
+
 	return p.respFunc(
 		&pbsubstreamsrpc.Response{
 			Message: &pbsubstreamsrpc.Response_BlockUndoSignal{
-				BlockUndoSignal: &pbsubstreamsrpc.BlockUndoSignal{
-					LastValidBlock:  targetClock,
-					LastValidCursor: targetCursor.ToOpaque(),
-				},
+				BlockUndoSignal: undoSignal,
 			},
 		})
 }
 
+// revertedBlock returns the outputs of the output modules sent for the reverted block at
+// `clock`, a block that was not sent has none.
+func (p *Pipeline) revertedBlock(clock *pbsubstreams.Clock) *pbsubstreamsrpc.RevertedBlock {
+	out := &pbsubstreamsrpc.RevertedBlock{Clock: clock}
+
+	outputModule := p.outputGraph.OutputModule()
+	additionalOutputs := make([]*pbsubstreamsrpc.MapModuleOutput, len(p.outputGraph.AdditionalOutputModules()))
+	for _, moduleOutput := range p.forkHandler.sentOutputsOf(clock.Id) {
+		if moduleOutput.ModuleName == outputModule.Name {
+			if outputModule.GetKindStore() != nil {
+				out.Output = toRPCStoreDeltasOutput(moduleOutput)
+			} else {
+				out.Output = toRPCMapModuleOutputs(moduleOutput)
+			}
+			continue
+		}
+		for i, mod := range p.outputGraph.AdditionalOutputModules() {
+			if mod.Name == moduleOutput.ModuleName {
+				additionalOutputs[i] = toRPCMapModuleOutputs(moduleOutput)
+			}
+		}
+	}
+	for _, output := range additionalOutputs {
+		if output != nil {
+			out.AdditionalOutputs = append(out.AdditionalOutputs, output)
+		}
+	}
+	return out
+}
+
 func (p *Pipeline) handleStepFinal(clock *pbsubstreams.Clock) error {
 	p.lastFinalClock = clock
 	p.insideReorgUpTo = nil
@@ -279,9 +316,14 @@ func (p *Pipeline) handleStepNew(ctx context.Context, clock *pbsubstreams.Clock,
 			}
 		}
 		p.pendingUndoMessage = nil
-		if err = returnModuleDataOutputs(clock, cursor, p.mapModuleOutput, p.extraMapModuleOutputs, p.extraStoreModuleOutputs, p.respFunc); err != nil {
+		isLastBlock := clock.Number+1 == reqDetails.StopBlockNum
+		sent, err := returnModuleDataOutputs(ctx, clock, cursor, p.mapModuleOutput, p.additionalMapModuleOutputs, p.extraMapModuleOutputs, p.extraStoreModuleOutputs, p.outputSkipper, isLastBlock, p.respFunc)
+		if err != nil {
 			return fmt.Errorf("failed to return module data output: %w", err)
 		}
+		if sent {
+			p.forkHandler.markSent(clock.Id)
+		}
 	}
 
 	p.stores.resetStores()
@@ -294,6 +336,7 @@ func (p *Pipeline) executeModules(ctx context.Context, execOutput execout.Execut
 	defer span.EndWithErr(&err)
 
 	p.mapModuleOutput = nil
+	p.additionalMapModuleOutputs = make([]*pbsubstreamsrpc.MapModuleOutput, len(p.outputGraph.AdditionalOutputModules()))
 	p.extraMapModuleOutputs = nil
 	p.extraStoreModuleOutputs = nil
 	moduleExecutors, err := p.buildModuleExecutors(ctx)
@@ -394,10 +437,20 @@ func (p *Pipeline) applyExecutionResult(ctx context.Context, executor exec.Modul
 }
 
 func (p *Pipeline) saveModuleOutput(output *pbssinternal.ModuleOutput, moduleName string, isProduction bool) {
-	if p.isOutputModule(moduleName) {
+	if moduleName == p.outputGraph.OutputModule().Name {
+		if p.outputGraph.OutputModule().GetKindStore() != nil {
+			p.mapModuleOutput = toRPCStoreDeltasOutput(output)
+			return
+		}
 		p.mapModuleOutput = toRPCMapModuleOutputs(output)
 		return
 	}
+	for i, mod := range p.outputGraph.AdditionalOutputModules() {
+		if mod.Name == moduleName {
+			p.additionalMapModuleOutputs[i] = toRPCMapModuleOutputs(output)
+			return
+		}
+	}
 	if isProduction {
 		return
 	}
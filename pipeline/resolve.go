@@ -31,9 +31,13 @@ func BuildRequestDetails(
 	req = &reqctx.RequestDetails{
 		Modules:                             request.Modules,
 		OutputModule:                        request.OutputModule,
+		AdditionalOutputModules:             request.AdditionalOutputModules,
 		DebugInitialStoreSnapshotForModules: request.DebugInitialStoreSnapshotForModules,
 		ProductionMode:                      request.ProductionMode,
 		StopBlockNum:                        request.StopBlockNum,
+		BatchMaxBlocks:                      uint64(request.BatchMaxBlocks),
+		BatchMaxBytes:                       request.BatchMaxBytes,
+		UndoOutputs:                         request.UndoOutputs,
 		UniqueID:                            nextUniqueID(),
 	}
 
@@ -50,9 +54,13 @@ func BuildRequestDetails(
 			return nil, nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid modules: %w", err))
 		}
 
-		moduleHasStatefulDependencies, err = graph.HasStatefulDependencies(request.OutputModule)
-		if err != nil {
-			return nil, nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid output module: %w", err))
+		moduleHasStatefulDependencies = false
+		for _, outputModule := range request.AllOutputModules() {
+			hasStatefulDependencies, err := graph.HasStatefulDependencies(outputModule)
+			if err != nil {
+				return nil, nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid output module: %w", err))
+			}
+			moduleHasStatefulDependencies = moduleHasStatefulDependencies || hasStatefulDependencies
 		}
 	}
 
@@ -73,15 +81,16 @@ func BuildRequestDetails(
 
 func BuildRequestDetailsFromSubrequest(request *pbssinternal.ProcessRangeRequest) (req *reqctx.RequestDetails) {
 	req = &reqctx.RequestDetails{
-		Modules:               request.Modules,
-		OutputModule:          request.OutputModule,
-		ProductionMode:        true,
-		IsTier2Request:        true,
-		Tier2Stage:            int(request.Stage),
-		StopBlockNum:          request.StopBlockNum,
-		LinearHandoffBlockNum: request.StopBlockNum,
-		ResolvedStartBlockNum: request.StartBlockNum,
-		UniqueID:              nextUniqueID(),
+		Modules:                 request.Modules,
+		OutputModule:            request.OutputModule,
+		AdditionalOutputModules: request.AdditionalOutputModules,
+		ProductionMode:          true,
+		IsTier2Request:          true,
+		Tier2Stage:              int(request.Stage),
+		StopBlockNum:            request.StopBlockNum,
+		LinearHandoffBlockNum:   request.StopBlockNum,
+		ResolvedStartBlockNum:   request.StartBlockNum,
+		UniqueID:                nextUniqueID(),
 	}
 	return req
 }
@@ -1,6 +1,7 @@
 package reqctx
 
 import (
+	"slices"
 	"strconv"
 
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
@@ -13,6 +14,7 @@ type RequestDetails struct {
 
 	DebugInitialStoreSnapshotForModules []string
 	OutputModule                        string
+	AdditionalOutputModules             []string
 	// What the user requested, derived from either the Request.StartBlockNum or Request.Cursor
 	ResolvedStartBlockNum uint64
 	ResolvedCursor        string
@@ -22,6 +24,9 @@ type RequestDetails struct {
 	StopBlockNum          uint64
 	MaxParallelJobs       uint64
 	CacheTag              string
+	BatchMaxBlocks        uint64
+	BatchMaxBytes         uint64
+	UndoOutputs           bool
 	UniqueID              uint64
 
 	ProductionMode bool
@@ -34,7 +39,7 @@ func (d *RequestDetails) UniqueIDString() string {
 }
 
 func (d *RequestDetails) IsOutputModule(modName string) bool {
-	return modName == d.OutputModule
+	return modName == d.OutputModule || slices.Contains(d.AdditionalOutputModules, modName)
 }
 
 func (d *RequestDetails) ShouldReturnWrittenPartials(modName string) bool {
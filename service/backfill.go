@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"connectrpc.com/connect"
+	bsstream "github.com/streamingfast/bstream/stream"
+	tracing "github.com/streamingfast/sf-tracing"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/metrics"
+	"github.com/streamingfast/substreams/orchestrator/plan"
+	"github.com/streamingfast/substreams/outputencoding"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/pipeline"
+	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+// Backfill caches the stores and the outputs of the requested module graph up to a final
+// block by scheduling their tier2 jobs, as a production mode request would, without
+// streaming the outputs back: only the session and the progress are sent. The request is
+// validated, metered and failed fast like the equivalent `Blocks` request.
+func (s *Tier1Service) Backfill(
+	ctx context.Context,
+	req *connect.Request[pbsubstreamsrpc.BackfillRequest],
+	stream *connect.ServerStream[pbsubstreamsrpc.Response],
+) error {
+	var err error
+
+	logger := reqctx.Logger(ctx).Named("tier1")
+
+	ctx = s.requestContext(ctx, logger)
+
+	ctx, span := reqctx.WithSpan(ctx, "substreams/tier1/backfill")
+	defer span.EndWithErr(&err)
+
+	mut := sync.Mutex{}
+	respContext, cancel := context.WithCancel(ctx)
+	defer func() {
+		mut.Lock()
+		cancel()
+		mut.Unlock()
+	}()
+	respFunc := tier1ResponseHandler(respContext, &mut, logger, stream, outputencoding.NewEncoder(nil))
+
+	if req.Msg.StopBlockNum == 0 {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("missing stop block in request"))
+	}
+	// the backfill processes the blocks of a production mode request, up to a final block
+	request := &pbsubstreamsrpc.Request{
+		StartBlockNum:   int64(req.Msg.StartBlockNum),
+		StopBlockNum:    req.Msg.StopBlockNum,
+		FinalBlocksOnly: true,
+		Modules:         req.Msg.Modules,
+		OutputModule:    req.Msg.OutputModule,
+		ProductionMode:  true,
+	}
+	outputGraph, err := s.validateRequest(request)
+	if err != nil {
+		return err
+	}
+
+	if request.StartBlockNum == 0 {
+		request.StartBlockNum = int64(outputGraph.OutputModule().InitialBlock)
+	}
+	request.StartBlockNum = max(request.StartBlockNum, int64(outputGraph.LowestInitBlock()))
+	if uint64(request.StartBlockNum) >= request.StopBlockNum {
+		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("stop block %d must be greater than start block %d", request.StopBlockNum, request.StartBlockNum))
+	}
+
+	logger.Info("incoming Substreams Backfill request",
+		zap.String("output_module", request.OutputModule),
+		zap.String("output_module_hash", outputGraph.ModuleHashes().Get(request.OutputModule)),
+		zap.Int64("start_block", request.StartBlockNum),
+		zap.Uint64("stop_block", request.StopBlockNum),
+	)
+	metrics.SubstreamsCounter.Inc()
+	metrics.ActiveSubstreams.Inc()
+	defer metrics.ActiveSubstreams.Dec()
+
+	return s.runRequest(ctx, "Backfill", request, outputGraph, logger, func(ctx context.Context) error {
+		err = s.backfill(ctx, request, outputGraph, respFunc)
+		return err
+	})
+}
+
+func (s *Tier1Service) backfill(ctx context.Context, request *pbsubstreamsrpc.Request, outputGraph *outputmodules.Graph, respFunc substreams.ResponseFunc) (err error) {
+	logger := reqctx.Logger(ctx)
+
+	requestDetails, _, err := s.resolveRequestDetails(ctx, request, outputGraph)
+	if err != nil {
+		return err
+	}
+	if requestDetails.LinearHandoffBlockNum < request.StopBlockNum {
+		return bsstream.NewErrInvalidArg("stop block %d is above the last final block %d", request.StopBlockNum, requestDetails.LinearHandoffBlockNum)
+	}
+
+	var requestStats *metrics.Stats
+	ctx, requestStats = setupRequestStats(ctx, requestDetails, outputGraph, false)
+	defer requestStats.LogAndClose()
+
+	respFunc(&pbsubstreamsrpc.Response{
+		Message: &pbsubstreamsrpc.Response_Session{
+			Session: &pbsubstreamsrpc.SessionInit{
+				TraceId:            tracing.GetTraceID(ctx).String(),
+				ResolvedStartBlock: requestDetails.ResolvedStartBlockNum,
+				LinearHandoffBlock: requestDetails.LinearHandoffBlockNum,
+				MaxParallelWorkers: requestDetails.MaxParallelJobs,
+			},
+		},
+	})
+
+	ctx = reqctx.WithRequest(ctx, requestDetails)
+
+	if err := s.writePackage(ctx, request, outputGraph); err != nil {
+		logger.Warn("cannot write package", zap.Error(err))
+	}
+
+	cacheStore, err := s.requestCacheStore(ctx, requestDetails.CacheTag)
+	if err != nil {
+		return err
+	}
+
+	failures, err := s.checkRecordedFailures(ctx, cacheStore, outputGraph, true, requestDetails.ResolvedStartBlockNum, request.StopBlockNum, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		s.persistFailure(ctx, failures, outputGraph, err, logger)
+	}()
+
+	execOutputConfigs, err := execout.NewConfigs(cacheStore, outputGraph.UsedModules(), outputGraph.ModuleHashes(), s.runtimeConfig.StateBundleSize, logger)
+	if err != nil {
+		return fmt.Errorf("new config map: %w", err)
+	}
+	storeConfigs, err := store.NewConfigMap(cacheStore, outputGraph.Stores(), outputGraph.ModuleHashes())
+	if err != nil {
+		return fmt.Errorf("configuring stores: %w", err)
+	}
+	stores := pipeline.NewStores(ctx, storeConfigs, s.runtimeConfig.StateBundleSize, requestDetails.LinearHandoffBlockNum, request.StopBlockNum, false)
+
+	// the outputs are only written, the pipeline neither executes modules nor reads outputs
+	pipe := pipeline.New(ctx, outputGraph, stores, execOutputConfigs, nil, nil, s.runtimeConfig, respFunc)
+
+	reqPlan, err := plan.BuildTier1RequestPlan(
+		true,
+		s.runtimeConfig.StateBundleSize,
+		outputGraph.LowestInitBlock(),
+		requestDetails.ResolvedStartBlockNum,
+		requestDetails.LinearHandoffBlockNum,
+		requestDetails.StopBlockNum,
+		outputGraph.StagedUsedModules()[0].LastLayer().IsStoreLayer(),
+	)
+	if err != nil {
+		return fmt.Errorf("error building request plan: %w", err)
+	}
+	reqPlan.ReadExecOut = nil
+
+	logger.Debug("backfilling", zap.Stringer("plan", reqPlan))
+	return pipe.Backfill(ctx, reqPlan)
+}
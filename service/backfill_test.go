@@ -0,0 +1,103 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/shutter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	ssconnect "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2/pbsubstreamsrpcconnect"
+	"github.com/streamingfast/substreams/service/config"
+	"github.com/streamingfast/substreams/storage/failure"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+func TestTier1Service_Backfill(t *testing.T) {
+	ctx := context.Background()
+	baseStore, err := dstore.NewStore("file://"+t.TempDir(), "", "", true)
+	require.NoError(t, err)
+	cacheStore, err := baseStore.SubStore("tag")
+	require.NoError(t, err)
+
+	lastBlock := func() (uint64, error) { return 100, nil }
+	s := &Tier1Service{
+		Shutter:             shutter.New(),
+		blockType:           "test.Block",
+		failedRequests:      make(map[string]*recordedFailure),
+		runtimeConfig:       config.RuntimeConfig{BaseObjectStore: baseStore, DefaultCacheTag: "tag", StateBundleSize: 10, WASMRuntime: "wazero"},
+		getRecentFinalBlock: lastBlock,
+		getHeadBlock:        lastBlock,
+	}
+	mux := http.NewServeMux()
+	mux.Handle(ssconnect.NewStreamHandler(s))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := ssconnect.NewStreamClient(server.Client(), server.URL)
+
+	backfill := func(startBlock, stopBlock uint64) (*pbsubstreamsrpc.SessionInit, error) {
+		stream, err := client.Backfill(ctx, connect.NewRequest(&pbsubstreamsrpc.BackfillRequest{
+			StartBlockNum: startBlock,
+			StopBlockNum:  stopBlock,
+			Modules:       testFailureModules(),
+			OutputModule:  "map_c",
+		}))
+		require.NoError(t, err)
+		defer stream.Close()
+
+		var session *pbsubstreamsrpc.SessionInit
+		for stream.Receive() {
+			if msg := stream.Msg().GetSession(); msg != nil {
+				session = msg
+			}
+		}
+		return session, stream.Err()
+	}
+
+	// the stores and the outputs of map_c are cached: nothing is scheduled
+	graph := testFailureGraph(t, true)
+	storeHash := graph.ModuleHashes().Get("store_b")
+	outputHash := graph.ModuleHashes().Get("map_c")
+	for end := uint64(10); end <= 40; end += 10 {
+		require.NoError(t, cacheStore.WriteObject(ctx, storeHash+"/states/"+store.NewCompleteFileInfo("store_b", 0, end).Filename, bytes.NewReader(nil)))
+	}
+	for end := uint64(30); end <= 40; end += 10 {
+		require.NoError(t, cacheStore.WriteObject(ctx, fmt.Sprintf("%s/outputs/%010d-%010d.output", outputHash, end-10, end), bytes.NewReader(nil)))
+	}
+
+	session, err := backfill(20, 40)
+	require.NoError(t, err)
+	require.NotNil(t, session)
+	assert.Equal(t, uint64(20), session.ResolvedStartBlock)
+	exists, err := cacheStore.FileExists(ctx, outputHash+"/substreams.partial.spkg")
+	require.NoError(t, err)
+	assert.True(t, exists, "package written")
+	storeConfigs, err := store.NewConfigMap(cacheStore, graph.Stores(), graph.ModuleHashes())
+	require.NoError(t, err)
+	manifest, err := storeConfigs["store_b"].ReadManifest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &store.Manifest{Interval: 10, UpTo: 40}, manifest)
+
+	_, err = backfill(0, 0)
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), "missing stop block")
+
+	_, err = backfill(20, 200)
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), "stop block above the last final block")
+	_, retryErr := backfill(20, 200)
+	assert.Equal(t, err.Error(), retryErr.Error(), "failed fast on this instance")
+
+	records, err := failure.NewRecords(cacheStore, nil)
+	require.NoError(t, err)
+	require.NoError(t, records.Put(ctx, &failure.Record{ModuleHash: storeHash, ModuleName: "store_b", Block: 15, Runtime: s.failureRuntime(), At: time.Now()}))
+	_, err = backfill(30, 40)
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), "bound to hit a recorded failure")
+}
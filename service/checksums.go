@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"connectrpc.com/connect"
+	"github.com/streamingfast/dauth"
+	"go.uber.org/zap"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/storage/execout"
+)
+
+// OutputChecksums implements the Checksums service: it returns the checksums of the output files
+// of the requested module within the requested range, written by tier2 along the outputs, for the
+// files covering a whole segment. Nothing is processed.
+func (s *Tier1Service) OutputChecksums(ctx context.Context, req *connect.Request[pbsubstreamsrpc.OutputChecksumsRequest]) (*connect.Response[pbsubstreamsrpc.OutputChecksumsResponse], error) {
+	request := req.Msg
+	if request.Modules == nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("missing modules in request"))
+	}
+	if request.StopBlockNum <= request.StartBlockNum {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("stop block %d must be greater than start block %d", request.StopBlockNum, request.StartBlockNum))
+	}
+
+	graph, err := outputmodules.NewOutputModuleGraph(request.Module, true, request.Modules)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	module := graph.OutputModule()
+	moduleHash := graph.ModuleHashes().Get(module.Name)
+
+	cacheTag, err := s.requestCacheTag(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := reqctx.Logger(ctx).Named("tier1")
+	logger.Info("incoming output checksums request",
+		zap.String("module", module.Name),
+		zap.String("module_hash", moduleHash),
+		zap.Uint64("start_block", request.StartBlockNum),
+		zap.Uint64("stop_block", request.StopBlockNum),
+	)
+
+	cacheStore, err := s.runtimeConfig.BaseObjectStore.SubStore(cacheTag)
+	if err != nil {
+		return nil, fmt.Errorf("internal error setting store: %w", err)
+	}
+	config, err := execout.NewConfig(module.Name, module.InitialBlock, module.ModuleKind(), moduleHash, cacheStore, logger)
+	if err != nil {
+		return nil, fmt.Errorf("new exec output config: %w", err)
+	}
+
+	checksums, err := config.ListChecksums(ctx, request.StartBlockNum, request.StopBlockNum, s.runtimeConfig.StateBundleSize)
+	if err != nil {
+		return nil, fmt.Errorf("listing checksums: %w", err)
+	}
+
+	resp := &pbsubstreamsrpc.OutputChecksumsResponse{ModuleHash: moduleHash}
+	for _, checksum := range checksums {
+		resp.Segments = append(resp.Segments, &pbsubstreamsrpc.SegmentChecksum{
+			StartBlock: checksum.Range.StartBlock,
+			EndBlock:   checksum.Range.ExclusiveEndBlock,
+			Checksum:   checksum.Checksum,
+		})
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// requestCacheTag returns the cache tag of the request, overridden by the auth layer.
+func (s *Tier1Service) requestCacheTag(ctx context.Context) (string, error) {
+	if auth := dauth.FromContext(ctx); auth != nil {
+		if tag := auth.Get("X-Sf-Substreams-Cache-Tag"); tag != "" {
+			if !IsValidCacheTag(tag) {
+				return "", connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid value for X-Sf-Substreams-Cache-Tag %s, should only contain letters, numbers, hyphens and undescores", tag))
+			}
+			return tag, nil
+		}
+	}
+	return s.runtimeConfig.DefaultCacheTag, nil
+}
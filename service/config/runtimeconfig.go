@@ -1,7 +1,10 @@
 package config
 
 import (
+	"time"
+
 	"github.com/streamingfast/substreams/orchestrator/work"
+	"github.com/streamingfast/substreams/wasm"
 
 	"github.com/streamingfast/dstore"
 )
@@ -19,9 +22,18 @@ type RuntimeConfig struct {
 	BaseObjectStore dstore.Store
 	DefaultCacheTag string // appended to BaseObjectStore unless overriden by auth layer
 	WorkerFactory   work.WorkerFactory
+	Tier2Capacity   *work.Tier2Capacity // capacity advertised by the remote tier2 instances, nil when the workers don't report it
+	FairShare       *work.FairShare     // shares the jobs of all the requests between their tenants, nil to send them as soon as a worker is free
+	StragglerFactor float64             // duplicate the jobs this many times slower per block than the median of their stage, 0 to disable
+
+	TargetJobDuration time.Duration // size the jobs of each stage from its measured per-block cost to take about this long, 0 for single-segment jobs
+	MaxJobSegments    uint64        // most segments grouped in a job when sizing them from TargetJobDuration
 
 	ModuleExecutionTracing bool
 	MaxConcurrentRequests  int64
+
+	WASMRuntime           string // name of the registered wasm runtime executing the modules, see `wasm.RegisterModuleFactory`
+	WASMInstanceSnapshots bool   // reuse wasm instances across blocks, restoring their memory snapshot between executions
 }
 
 func NewTier1RuntimeConfig(
@@ -43,9 +55,12 @@ func NewTier1RuntimeConfig(
 		WorkerFactory:              workerFactory,
 		// overridden by Tier Options
 		ModuleExecutionTracing: false,
+		WASMRuntime:            wasm.DefaultRuntime,
 	}
 }
 
 func NewTier2RuntimeConfig() RuntimeConfig {
-	return RuntimeConfig{} //values overridden by options
+	return RuntimeConfig{
+		WASMRuntime: wasm.DefaultRuntime,
+	} //values overridden by options
 }
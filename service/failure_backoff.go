@@ -1,11 +1,25 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/streamingfast/bstream"
+	bsstream "github.com/streamingfast/bstream/stream"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/storage/failure"
 )
 
 // fail fast when the exact same request has already failed twice, preventing waste of tier2 resources
@@ -16,6 +30,17 @@ var FailureBlacklistDuration = time.Minute * 10
 var FailureForcedBackoffIncrement = time.Millisecond * 500
 var FailureForcedBackoffLimit = time.Second * 30
 
+// bounds the write of a deterministic failure record, the request context being done by then
+var FailurePersistTimeout = time.Second * 30
+
+// past this age, a recorded deterministic failure does not fail fast the requests anymore,
+// in case its cause was operational after all
+var FailureRecordMaxAge = time.Hour * 24 * 7
+
+// the failure records checked by the requests are kept in memory for this long, the records
+// persisted by other tier1 instances fail fast the requests once it expires
+var FailureRecordCacheTTL = time.Minute
+
 type recordedFailure struct {
 	lastAt        time.Time
 	atBlock       uint64
@@ -24,37 +49,66 @@ type recordedFailure struct {
 	lastError     error
 }
 
+// errorFromRecordedFailure returns the error of the recorded failure of the request of `id`
+// while it is blacklisted, after a forced backoff increasing with every retry.
 func (s *Tier1Service) errorFromRecordedFailure(id string, isProductionMode bool, startBlock int64, startCursor string) error {
-	if startBlock < 0 {
+	s.failedRequestsLock.Lock()
+	failure, expired := s.blacklistedFailure(id, isProductionMode, startBlock, startCursor)
+	if expired {
+		delete(s.failedRequests, id)
+	}
+	if failure == nil {
+		s.failedRequestsLock.Unlock()
 		return nil
 	}
+	backoff := failure.forcedBackoff
+	if failure.forcedBackoff < FailureForcedBackoffLimit {
+		failure.forcedBackoff += FailureForcedBackoffIncrement
+	}
+	err := failure.lastError
+	s.failedRequestsLock.Unlock()
+
+	time.Sleep(backoff)
+	return err
+}
+
+// recordedFailureError returns the error of the recorded failure of the request of `id`
+// while it is blacklisted, like errorFromRecordedFailure but without backing off nor
+// updating the recorded failures.
+func (s *Tier1Service) recordedFailureError(id string, isProductionMode bool, startBlock int64, startCursor string) error {
 	s.failedRequestsLock.RLock()
 	defer s.failedRequestsLock.RUnlock()
-	if failure, ok := s.failedRequests[id]; ok {
-		if failure.count > FailureBlacklistMinimalCount {
-			if time.Since(failure.lastAt) < FailureBlacklistDuration {
-
-				// dev-mode requests below the failure point will still be processed on tier1
-				if !isProductionMode {
-					if uint64(startBlock) < failure.atBlock {
-						cur, err := bstream.CursorFromOpaque(startCursor)
-						if err != nil || cur.Block.Num() < failure.atBlock {
-							return nil
-						}
-					}
-				}
-
-				time.Sleep(failure.forcedBackoff)
-				if failure.forcedBackoff < FailureForcedBackoffLimit {
-					failure.forcedBackoff += FailureForcedBackoffIncrement
-				}
-				return failure.lastError
-			} else {
-				delete(s.failedRequests, id)
+	if failure, _ := s.blacklistedFailure(id, isProductionMode, startBlock, startCursor); failure != nil {
+		return failure.lastError
+	}
+	return nil
+}
+
+// blacklistedFailure returns the recorded failure of the request of `id` while it is
+// blacklisted, `expired` tells that the blacklisting is over. The caller holds
+// failedRequestsLock.
+func (s *Tier1Service) blacklistedFailure(id string, isProductionMode bool, startBlock int64, startCursor string) (failure *recordedFailure, expired bool) {
+	if startBlock < 0 {
+		return nil, false
+	}
+	failure, ok := s.failedRequests[id]
+	if !ok || failure.count <= FailureBlacklistMinimalCount {
+		return nil, false
+	}
+	if time.Since(failure.lastAt) >= FailureBlacklistDuration {
+		return nil, true
+	}
+
+	// dev-mode requests below the failure point will still be processed on tier1
+	if !isProductionMode {
+		if uint64(startBlock) < failure.atBlock {
+			cur, err := bstream.CursorFromOpaque(startCursor)
+			if err != nil || cur.Block.Num() < failure.atBlock {
+				return nil, false
 			}
 		}
 	}
-	return nil
+	return failure, false
 }
 
 // Error: rpc error: code = InvalidArgument desc = step new irr: handler step new: execute modules: applying executor results ... store wasm call: block 300: module "store_eth_stats": wasm execution failed ...
@@ -80,3 +134,111 @@ func (s *Tier1Service) recordFailure(requestID string, err error) {
 	failure.lastError = err
 	failure.count++
 }
+
+// failureCheckRanges returns, by module hash, the blocks in which a recorded deterministic
+// failure of the module would fail the request. Stores and their ancestors are processed
+// from their initial block on tier2. In development mode, the blocks streamed by tier1 are
+// left out so the request still gets the outputs preceding the failure.
+func failureCheckRanges(outputGraph *outputmodules.Graph, productionMode bool, startBlock, stopBlock uint64) map[string]*block.Range {
+	endBlock := stopBlock
+	if endBlock == 0 {
+		endBlock = math.MaxUint64
+	}
+
+	modules := make(map[string]*pbsubstreams.Module)
+	for _, module := range outputGraph.UsedModules() {
+		modules[module.Name] = module
+	}
+	historical := make(map[string]bool)
+	var markHistorical func(name string)
+	markHistorical = func(name string) {
+		module := modules[name]
+		if module == nil || historical[name] {
+			return
+		}
+		historical[name] = true
+		for _, input := range module.Inputs {
+			if v := input.GetMap(); v != nil {
+				markHistorical(v.ModuleName)
+			} else if v := input.GetStore(); v != nil {
+				markHistorical(v.ModuleName)
+			}
+		}
+	}
+	for _, store := range outputGraph.Stores() {
+		markHistorical(store.Name)
+	}
+
+	out := make(map[string]*block.Range)
+	for _, module := range outputGraph.UsedModules() {
+		hash := outputGraph.ModuleHashes().Get(module.Name)
+		switch {
+		case historical[module.Name] && productionMode:
+			out[hash] = block.NewRange(0, endBlock)
+		case historical[module.Name]:
+			out[hash] = block.NewRange(0, startBlock)
+		case productionMode:
+			out[hash] = block.NewRange(startBlock, endBlock)
+		}
+	}
+	return out
+}
+
+// failureRuntime identifies the wasm runtime and configuration executing the modules: a
+// recorded failure only fails fast the requests executed the same way.
+func (s *Tier1Service) failureRuntime() string {
+	var extensions []string
+	for namespace, functions := range s.wasmExtensions {
+		for name := range functions {
+			extensions = append(extensions, namespace+"."+name)
+		}
+	}
+	sort.Strings(extensions)
+	return fmt.Sprintf("%s fuel=%d snapshots=%t extensions=%s", s.runtimeConfig.WASMRuntime, s.runtimeConfig.MaxWasmFuel, s.runtimeConfig.WASMInstanceSnapshots, strings.Join(extensions, ","))
+}
+
+// checkRecordedFailures fails fast with an invalid argument error when the request is bound
+// to hit a deterministic failure recorded in `cacheStore`, returning the records to persist
+// the failure of the request into, see persistFailure.
+func (s *Tier1Service) checkRecordedFailures(ctx context.Context, cacheStore dstore.Store, outputGraph *outputmodules.Graph, productionMode bool, startBlock, stopBlock uint64, logger *zap.Logger) (*failure.Records, error) {
+	records, err := failure.NewRecords(cacheStore, s.failureRecordsCache)
+	if err != nil {
+		return nil, fmt.Errorf("internal error setting failure records: %w", err)
+	}
+	if err := records.Check(ctx, failureCheckRanges(outputGraph, productionMode, startBlock, stopBlock), s.failureRuntime(), FailureRecordMaxAge); err != nil {
+		var knownFailure *failure.Error
+		if errors.As(err, &knownFailure) {
+			return nil, bsstream.NewErrInvalidArg("%s", knownFailure.Error())
+		}
+		logger.Warn("cannot check recorded module failures", zap.Error(err))
+	}
+	return records, nil
+}
+
+// persistFailure records the deterministic module failure wrapped in `err`, if any, for
+// all the tier1 instances to fail fast on the requests bound to hit it.
+func (s *Tier1Service) persistFailure(ctx context.Context, records *failure.Records, outputGraph *outputmodules.Graph, err error, logger *zap.Logger) {
+	var deterministic *failure.DeterministicError
+	if !errors.As(err, &deterministic) {
+		return
+	}
+	record := &failure.Record{
+		ModuleHash: outputGraph.ModuleHashes().Get(deterministic.ModuleName),
+		ModuleName: deterministic.ModuleName,
+		Block:      deterministic.Block,
+		Runtime:    s.failureRuntime(),
+		Error:      err.Error(),
+		At:         time.Now(),
+	}
+	if record.ModuleHash == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), FailurePersistTimeout)
+	defer cancel()
+	if err := records.Put(ctx, record); err != nil {
+		logger.Warn("cannot persist module failure", zap.String("module", record.ModuleName), zap.Uint64("block", record.Block), zap.Error(err))
+		return
+	}
+	logger.Info("persisted deterministic module failure", zap.String("module", record.ModuleName), zap.String("module_hash", record.ModuleHash), zap.Uint64("block", record.Block))
+}
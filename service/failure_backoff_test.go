@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	bsstream "github.com/streamingfast/bstream/stream"
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/streamingfast/substreams/block"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/service/config"
+	"github.com/streamingfast/substreams/storage/failure"
+)
+
+// testFailureModules is map_c reading store_b, which is built from map_a.
+func testFailureModules() *pbsubstreams.Modules {
+	mapModule := func(name string, inputs ...*pbsubstreams.Module_Input) *pbsubstreams.Module {
+		return &pbsubstreams.Module{
+			Name:   name,
+			Kind:   &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{OutputType: "proto:test.Output"}},
+			Inputs: inputs,
+		}
+	}
+	return &pbsubstreams.Modules{
+		Modules: []*pbsubstreams.Module{
+			mapModule("map_a", &pbsubstreams.Module_Input{Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "test.Block"}}}),
+			{
+				Name:   "store_b",
+				Kind:   &pbsubstreams.Module_KindStore_{KindStore: &pbsubstreams.Module_KindStore{UpdatePolicy: pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, ValueType: "string"}},
+				Inputs: []*pbsubstreams.Module_Input{{Input: &pbsubstreams.Module_Input_Map_{Map: &pbsubstreams.Module_Input_Map{ModuleName: "map_a"}}}},
+			},
+			mapModule("map_c", &pbsubstreams.Module_Input{Input: &pbsubstreams.Module_Input_Store_{Store: &pbsubstreams.Module_Input_Store{ModuleName: "store_b", Mode: pbsubstreams.Module_Input_Store_GET}}}),
+		},
+		Binaries: []*pbsubstreams.Binary{{Type: "wasm/rust-v1"}},
+	}
+}
+
+func testFailureGraph(t *testing.T, productionMode bool) *outputmodules.Graph {
+	t.Helper()
+
+	graph, err := outputmodules.NewOutputModuleGraph("map_c", productionMode, testFailureModules())
+	require.NoError(t, err)
+	return graph
+}
+
+func TestFailureCheckRanges(t *testing.T) {
+	tests := []struct {
+		name           string
+		productionMode bool
+		stopBlock      uint64
+		expect         map[string]*block.Range
+	}{
+		{
+			name:           "production",
+			productionMode: true,
+			stopBlock:      200,
+			expect: map[string]*block.Range{
+				"map_a":   block.NewRange(0, 200),
+				"store_b": block.NewRange(0, 200),
+				"map_c":   block.NewRange(100, 200),
+			},
+		},
+		{
+			name:           "production without stop block",
+			productionMode: true,
+			expect: map[string]*block.Range{
+				"map_a":   block.NewRange(0, math.MaxUint64),
+				"store_b": block.NewRange(0, math.MaxUint64),
+				"map_c":   block.NewRange(100, math.MaxUint64),
+			},
+		},
+		{
+			name:      "development streams the blocks from the start block",
+			stopBlock: 200,
+			expect: map[string]*block.Range{
+				"map_a":   block.NewRange(0, 100),
+				"store_b": block.NewRange(0, 100),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			graph := testFailureGraph(t, test.productionMode)
+			expect := make(map[string]*block.Range)
+			for name, rng := range test.expect {
+				expect[graph.ModuleHashes().Get(name)] = rng
+			}
+			assert.Equal(t, expect, failureCheckRanges(graph, test.productionMode, 100, test.stopBlock))
+		})
+	}
+}
+
+func TestTier1Service_RecordedFailures(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	// unlike the mock store, its sub stores share their files
+	cacheStore, err := dstore.NewStore("file://"+t.TempDir(), "", "", true)
+	require.NoError(t, err)
+	graph := testFailureGraph(t, true)
+	s := &Tier1Service{runtimeConfig: config.RuntimeConfig{WASMRuntime: "wazero"}}
+
+	records, err := s.checkRecordedFailures(ctx, cacheStore, graph, true, 100, 200, logger)
+	require.NoError(t, err)
+
+	s.persistFailure(ctx, records, graph, errors.New("block 50: module \"store_b\": connection reset"), logger)
+	list, err := records.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, list, "not a deterministic failure")
+
+	// failed on tier2, the failure crossed the connection as a gRPC error
+	deterministic := failure.NewDeterministicError("store_b", 50, errors.New(`block 50: module "store_b": wasm execution failed deterministically: panic`))
+	err = fmt.Errorf("scheduler run: %w", failure.FromGRPCError(failure.GRPCError(codes.InvalidArgument, deterministic)))
+	s.persistFailure(ctx, records, graph, err, logger)
+	list, err = records.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, graph.ModuleHashes().Get("store_b"), list[0].ModuleHash)
+	assert.Equal(t, uint64(50), list[0].Block)
+
+	var invalidArg *bsstream.ErrInvalidArg
+	_, err = s.checkRecordedFailures(ctx, cacheStore, graph, true, 100, 200, logger)
+	require.True(t, errors.As(err, &invalidArg), "fails fast, the store is built from its initial block")
+
+	_, err = s.checkRecordedFailures(ctx, cacheStore, graph, false, 40, 200, logger)
+	assert.NoError(t, err, "development mode streams the blocks before the failure")
+
+	other := &Tier1Service{runtimeConfig: config.RuntimeConfig{WASMRuntime: "wazero", MaxWasmFuel: 1000}}
+	_, err = other.checkRecordedFailures(ctx, cacheStore, graph, true, 100, 200, logger)
+	assert.NoError(t, err, "recorded with another runtime configuration")
+}
+
+func TestBlocksRequestID(t *testing.T) {
+	graph := testFailureGraph(t, true)
+	newRequest := func() *pbsubstreamsrpc.Request {
+		return &pbsubstreamsrpc.Request{StartBlockNum: 25, StopBlockNum: 40, Modules: testFailureModules(), OutputModule: "map_c", ProductionMode: true}
+	}
+	id := blocksRequestID(newRequest(), graph)
+	assert.Equal(t, id, blocksRequestID(newRequest(), graph))
+
+	for name, change := range map[string]func(request *pbsubstreamsrpc.Request){
+		"skip_empty_outputs": func(request *pbsubstreamsrpc.Request) { request.SkipEmptyOutputs = true },
+		"undo_outputs":       func(request *pbsubstreamsrpc.Request) { request.UndoOutputs = true },
+		"batch_max_blocks":   func(request *pbsubstreamsrpc.Request) { request.BatchMaxBlocks = 10 },
+		"batch_max_bytes":    func(request *pbsubstreamsrpc.Request) { request.BatchMaxBytes = 1024 },
+		"output_encodings":   func(request *pbsubstreamsrpc.Request) { request.AcceptedOutputEncodings = []string{"zstd"} },
+	} {
+		request := newRequest()
+		change(request)
+		assert.NotEqual(t, id, blocksRequestID(request, graph), name)
+	}
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"time"
+
+	"github.com/streamingfast/substreams/orchestrator/work"
 	"github.com/streamingfast/substreams/wasm"
 )
 
@@ -19,6 +22,32 @@ func WithMaxWasmFuelPerBlockModule(maxFuel uint64) Option {
 	}
 }
 
+// WithWASMRuntime selects the wasm runtime executing the modules, by the name it was
+// registered with through `wasm.RegisterModuleFactory`.
+func WithWASMRuntime(runtimeName string) Option {
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier1Service:
+			s.runtimeConfig.WASMRuntime = runtimeName
+		case *Tier2Service:
+			s.runtimeConfig.WASMRuntime = runtimeName
+		}
+	}
+}
+
+// WithWASMInstanceSnapshots reuses wasm instances across blocks, restoring the memory
+// they had after instantiation between each execution, which keeps it deterministic.
+func WithWASMInstanceSnapshots() Option {
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier1Service:
+			s.runtimeConfig.WASMInstanceSnapshots = true
+		case *Tier2Service:
+			s.runtimeConfig.WASMInstanceSnapshots = true
+		}
+	}
+}
+
 func WithModuleExecutionTracing() Option {
 	return func(a anyTierService) {
 		switch s := a.(type) {
@@ -69,3 +98,60 @@ func WithReadinessFunc(f func(bool)) Option {
 		}
 	}
 }
+
+// WithLocalTier2 processes the subrequests of tier1 in-process with `tier2`, instead of
+// sending them to the remote tier2 of the subrequests endpoint. At most
+// `maxConcurrentRequests` subrequests are processed at once, across all requests.
+func WithLocalTier2(tier2 *Tier2Service, maxConcurrentRequests uint64) Option {
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier1Service:
+			s.runtimeConfig.WorkerFactory = work.NewLocalWorkerFactory(tier2.LocalProcessRange, maxConcurrentRequests)
+			s.runtimeConfig.Tier2Capacity = nil
+		case *Tier2Service:
+			// not used
+		}
+	}
+}
+
+// WithSpeculativeJobs sends a duplicate of the jobs running `factor` times slower per
+// block than the median of the completed jobs of their stage to another worker, keeping
+// the result of the first one to finish. A `factor` of 0 disables it.
+func WithSpeculativeJobs(factor float64) Option {
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier1Service:
+			s.runtimeConfig.StragglerFactor = factor
+		case *Tier2Service:
+			// not used
+		}
+	}
+}
+
+// WithAdaptiveJobSizes groups consecutive segments in the jobs of the cheap stages, so
+// each job takes about `target` from the per-block cost measured on the previous jobs of
+// its stage, with at most `maxSegments` segments per job.
+func WithAdaptiveJobSizes(target time.Duration, maxSegments uint64) Option {
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier1Service:
+			s.runtimeConfig.TargetJobDuration = target
+			s.runtimeConfig.MaxJobSegments = maxSegments
+		case *Tier2Service:
+			// not used
+		}
+	}
+}
+
+// WithFairShare shares the jobs sent to tier2 by all the requests between their tenants,
+// see `work.FairShare`.
+func WithFairShare(config work.FairShareConfig) Option {
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier1Service:
+			s.runtimeConfig.FairShare = work.NewFairShare(config)
+		case *Tier2Service:
+			// not used
+		}
+	}
+}
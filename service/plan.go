@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"connectrpc.com/connect"
+	"github.com/streamingfast/dauth"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/orchestrator"
+	"github.com/streamingfast/substreams/orchestrator/plan"
+	"github.com/streamingfast/substreams/orchestrator/stage"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+// Plan resolves the request as `Blocks` does and returns the work it would schedule: the
+// segments of each stage already cached and the tier2 jobs processing the others. Nothing
+// is processed nor written to the cache. The request is refused as `Blocks` would, when
+// it is bound to hit a recorded failure.
+func (s *Tier1Service) Plan(ctx context.Context, req *connect.Request[pbsubstreamsrpc.Request]) (*connect.Response[pbsubstreamsrpc.PlanResponse], error) {
+	request := req.Msg
+	outputGraph, err := s.validateRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recordedFailureError(blocksRequestID(request, outputGraph), request.ProductionMode, request.StartBlockNum, request.StartCursor); err != nil {
+		return nil, err
+	}
+
+	requestDetails, _, err := s.resolveRequestDetails(ctx, request, outputGraph)
+	if err != nil {
+		return nil, toConnectError(ctx, err)
+	}
+
+	logger := reqctx.Logger(ctx).Named("tier1")
+	logger.Info("incoming plan request",
+		zap.String("output_module", request.OutputModule),
+		zap.String("output_module_hash", outputGraph.ModuleHashes().Get(request.OutputModule)),
+		zap.Uint64("resolved_start_block", requestDetails.ResolvedStartBlockNum),
+		zap.Uint64("stop_block", requestDetails.StopBlockNum),
+		zap.Bool("production_mode", request.ProductionMode),
+	)
+	ctx = reqctx.WithRequest(ctx, requestDetails)
+
+	cacheStore, err := s.runtimeConfig.BaseObjectStore.SubStore(requestDetails.CacheTag)
+	if err != nil {
+		return nil, fmt.Errorf("internal error setting store: %w", err)
+	}
+	if _, err := s.checkRecordedFailures(ctx, cacheStore, outputGraph, request.ProductionMode, requestDetails.ResolvedStartBlockNum, request.StopBlockNum, logger); err != nil {
+		return nil, toConnectError(ctx, err)
+	}
+	execOutputConfigs, err := execout.NewConfigs(cacheStore, outputGraph.UsedModules(), outputGraph.ModuleHashes(), s.runtimeConfig.StateBundleSize, logger)
+	if err != nil {
+		return nil, fmt.Errorf("new config map: %w", err)
+	}
+	storeConfigs, err := store.NewConfigMap(cacheStore, outputGraph.Stores(), outputGraph.ModuleHashes())
+	if err != nil {
+		return nil, fmt.Errorf("configuring stores: %w", err)
+	}
+
+	reqPlan, err := plan.BuildTier1RequestPlan(
+		requestDetails.ProductionMode,
+		s.runtimeConfig.StateBundleSize,
+		outputGraph.LowestInitBlock(),
+		requestDetails.ResolvedStartBlockNum,
+		requestDetails.LinearHandoffBlockNum,
+		requestDetails.StopBlockNum,
+		outputGraph.StagedUsedModules()[0].LastLayer().IsStoreLayer(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error building request plan: %w", err)
+	}
+
+	stagePlans, err := orchestrator.PlanStages(ctx, reqPlan, s.runtimeConfig, outputGraph, execOutputConfigs, storeConfigs)
+	if err != nil {
+		return nil, fmt.Errorf("planning stages: %w", err)
+	}
+
+	resp := &pbsubstreamsrpc.PlanResponse{
+		ResolvedStartBlock: requestDetails.ResolvedStartBlockNum,
+		LinearHandoffBlock: requestDetails.LinearHandoffBlockNum,
+		StopBlock:          requestDetails.StopBlockNum,
+		SegmentSize:        s.runtimeConfig.StateBundleSize,
+		MaxParallelWorkers: requestDetails.MaxParallelJobs,
+	}
+	for _, stagePlan := range stagePlans {
+		resp.Stages = append(resp.Stages, &pbsubstreamsrpc.StagePlan{
+			Modules:         stagePlan.Modules,
+			IsStore:         stagePlan.Kind == stage.KindStore,
+			Segments:        uint64(stagePlan.Segments),
+			CachedSegments:  uint64(stagePlan.Cached),
+			PartialSegments: uint64(stagePlan.Partials),
+			Jobs:            uint64(stagePlan.Jobs),
+			Blocks:          stagePlan.Blocks,
+		})
+		resp.Tier2Jobs += uint64(stagePlan.Jobs)
+		resp.Tier2Blocks += stagePlan.Blocks
+	}
+	if reqPlan.LinearPipeline != nil && requestDetails.StopBlockNum > requestDetails.LinearHandoffBlockNum {
+		resp.LinearBlocks = requestDetails.StopBlockNum - requestDetails.LinearHandoffBlockNum
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// requestMaxParallelJobs returns the number of parallel jobs of the request, overridden by
+// the auth layer.
+func (s *Tier1Service) requestMaxParallelJobs(ctx context.Context) uint64 {
+	if auth := dauth.FromContext(ctx); auth != nil {
+		if parallelJobs := auth.Get("X-Sf-Substreams-Parallel-Jobs"); parallelJobs != "" {
+			if ll, err := strconv.ParseUint(parallelJobs, 10, 64); err == nil {
+				return ll
+			}
+		}
+	}
+	return s.runtimeConfig.DefaultParallelSubrequests
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/service/config"
+	"github.com/streamingfast/substreams/storage/failure"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+func TestTier1Service_Plan(t *testing.T) {
+	ctx := context.Background()
+	baseStore, err := dstore.NewStore("file://"+t.TempDir(), "", "", true)
+	require.NoError(t, err)
+	cacheStore, err := baseStore.SubStore("tag")
+	require.NoError(t, err)
+
+	lastBlock := func() (uint64, error) { return 100, nil }
+	s := &Tier1Service{
+		blockType:           "test.Block",
+		failedRequests:      make(map[string]*recordedFailure),
+		runtimeConfig:       config.RuntimeConfig{BaseObjectStore: baseStore, DefaultCacheTag: "tag", StateBundleSize: 10, WASMRuntime: "wazero"},
+		getRecentFinalBlock: lastBlock,
+		getHeadBlock:        lastBlock,
+	}
+	newRequest := func() *connect.Request[pbsubstreamsrpc.Request] {
+		return connect.NewRequest(&pbsubstreamsrpc.Request{
+			StartBlockNum:  25,
+			StopBlockNum:   40,
+			Modules:        testFailureModules(),
+			OutputModule:   "map_c",
+			ProductionMode: true,
+		})
+	}
+
+	graph := testFailureGraph(t, true)
+	storeConfigs, err := store.NewConfigMap(cacheStore, graph.Stores(), graph.ModuleHashes())
+	require.NoError(t, err)
+	states, err := cacheStore.SubStore(graph.ModuleHashes().Get("store_b") + "/states")
+	require.NoError(t, err)
+	for _, file := range []*store.FileInfo{store.NewCompleteFileInfo("store_b", 0, 10), store.NewCompleteFileInfo("store_b", 0, 20), store.NewCompleteFileInfo("store_b", 0, 22)} {
+		require.NoError(t, states.WriteObject(ctx, file.Filename, bytes.NewReader(nil)))
+	}
+
+	resp, err := s.Plan(ctx, newRequest())
+	require.NoError(t, err)
+	require.Len(t, resp.Msg.Stages, 2)
+	assert.Equal(t, uint64(2), resp.Msg.Stages[0].CachedSegments)
+	assert.Equal(t, uint64(18), resp.Msg.Stages[0].Blocks, "resumed from the checkpoint at 22")
+
+	manifest, err := storeConfigs["store_b"].ReadManifest(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, manifest, "planning writes nothing")
+	exists, err := states.FileExists(ctx, "0000000022-0000000000.kv")
+	require.NoError(t, err)
+	assert.True(t, exists, "superseded checkpoint kept")
+
+	records, err := failure.NewRecords(cacheStore, nil)
+	require.NoError(t, err)
+	require.NoError(t, records.Put(ctx, &failure.Record{ModuleHash: graph.ModuleHashes().Get("store_b"), ModuleName: "store_b", Block: 15, Runtime: s.failureRuntime(), At: time.Now()}))
+	_, err = s.Plan(ctx, newRequest())
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), "bound to hit a recorded failure")
+	require.NoError(t, records.Clear(ctx, graph.ModuleHashes().Get("store_b")))
+
+	failed := connect.NewError(connect.CodeInvalidArgument, errors.New("block 30: module \"map_c\": failed"))
+	s.recordFailure(blocksRequestID(newRequest().Msg, graph), failed)
+	s.failedRequests[blocksRequestID(newRequest().Msg, graph)].forcedBackoff = time.Hour
+	_, err = s.Plan(ctx, newRequest())
+	assert.Equal(t, failed, err, "blacklisted on this instance")
+	assert.Equal(t, time.Hour, s.failedRequests[blocksRequestID(newRequest().Msg, graph)].forcedBackoff, "planning neither backs off nor increases the backoff")
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	bsstream "github.com/streamingfast/bstream/stream"
+
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/client"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/wasm"
+)
+
+// newModulesProfiler validates the value of the `client.ProfileModulesHeader` against the request.
+func newModulesProfiler(headerValue string, request *pbsubstreamsrpc.Request) (*wasm.Profiler, error) {
+	if request.ProductionMode {
+		return nil, bsstream.NewErrInvalidArg("%s header is only available in development mode", client.ProfileModulesHeader)
+	}
+
+	known := make(map[string]bool, len(request.Modules.Modules))
+	for _, module := range request.Modules.Modules {
+		known[module.Name] = true
+	}
+
+	var moduleNames []string
+	for _, name := range strings.Split(headerValue, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, bsstream.NewErrInvalidArg("%s header: module %q not found in request", client.ProfileModulesHeader, name)
+		}
+		moduleNames = append(moduleNames, name)
+	}
+
+	return wasm.NewProfiler(moduleNames), nil
+}
+
+func sendModuleProfiles(profiler *wasm.Profiler, respFunc substreams.ResponseFunc) error {
+	for _, moduleName := range profiler.ModuleNames() {
+		pprof, err := profiler.Profile(moduleName)
+		if err != nil {
+			return fmt.Errorf("rendering profile of module %q: %w", moduleName, err)
+		}
+
+		if err := respFunc(&pbsubstreamsrpc.Response{
+			Message: &pbsubstreamsrpc.Response_DebugModuleProfile{
+				DebugModuleProfile: &pbsubstreamsrpc.ModuleProfile{
+					ModuleName: moduleName,
+					Pprof:      pprof,
+				},
+			},
+		}); err != nil {
+			return fmt.Errorf("sending profile of module %q: %w", moduleName, err)
+		}
+	}
+	return nil
+}
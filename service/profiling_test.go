@@ -0,0 +1,62 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/test-go/testify/assert"
+	"github.com/test-go/testify/require"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func TestNewModulesProfiler(t *testing.T) {
+	modules := &pbsubstreams.Modules{
+		Modules: []*pbsubstreams.Module{{Name: "map_a"}, {Name: "store_b"}},
+	}
+
+	cases := []struct {
+		name           string
+		headerValue    string
+		productionMode bool
+		expectedNames  []string
+		expectedErr    string
+	}{
+		{
+			name:          "single module",
+			headerValue:   "map_a",
+			expectedNames: []string{"map_a"},
+		},
+		{
+			name:          "multiple modules with spaces",
+			headerValue:   "store_b, map_a,",
+			expectedNames: []string{"map_a", "store_b"},
+		},
+		{
+			name:        "unknown module",
+			headerValue: "map_a,map_c",
+			expectedErr: `X-Sf-Substreams-Profile-Modules header: module "map_c" not found in request`,
+		},
+		{
+			name:           "production mode",
+			headerValue:    "map_a",
+			productionMode: true,
+			expectedErr:    "X-Sf-Substreams-Profile-Modules header is only available in development mode",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			profiler, err := newModulesProfiler(c.headerValue, &pbsubstreamsrpc.Request{
+				Modules:        modules,
+				ProductionMode: c.productionMode,
+			})
+			if c.expectedErr != "" {
+				require.EqualError(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expectedNames, profiler.ModuleNames())
+		})
+	}
+}
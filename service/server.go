@@ -51,13 +51,18 @@ func ListenTier1(
 	options = append(options, dgrpcserver.WithConnectInterceptor(dauthconnect.NewAuthInterceptor(auth, logger)))
 	options = append(options, dgrpcserver.WithConnectStrictContentType(false))
 	options = append(options, dgrpcserver.WithReflection(ssconnect.StreamName))
+	options = append(options, dgrpcserver.WithReflection(ssconnect.ChecksumsName))
 
 	streamHandlerGetter := func(opts ...connect_go.HandlerOption) (string, http.Handler) {
 		return ssconnect.NewStreamHandler(svc, opts...)
 	}
 
+	checksumsHandlerGetter := func(opts ...connect_go.HandlerOption) (string, http.Handler) {
+		return ssconnect.NewChecksumsHandler(svc, opts...)
+	}
+
 	options = append(options, dgrpcserver.WithPermissiveCORS())
-	srv := connectweb.New([]connectweb.HandlerGetter{streamHandlerGetter}, options...)
+	srv := connectweb.New([]connectweb.HandlerGetter{streamHandlerGetter, checksumsHandlerGetter}, options...)
 	addr = strings.ReplaceAll(addr, "*", "")
 	srv.Launch(addr)
 	<-srv.Terminated()
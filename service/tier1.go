@@ -8,7 +8,6 @@ import (
 	"io"
 	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -32,16 +31,19 @@ import (
 	"github.com/streamingfast/substreams/metrics"
 	"github.com/streamingfast/substreams/orchestrator/plan"
 	"github.com/streamingfast/substreams/orchestrator/work"
+	"github.com/streamingfast/substreams/outputencoding"
 	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 	ssconnect "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2/pbsubstreamsrpcconnect"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	"github.com/streamingfast/substreams/pipeline"
 	"github.com/streamingfast/substreams/pipeline/cache"
 	"github.com/streamingfast/substreams/pipeline/exec"
+	"github.com/streamingfast/substreams/pipeline/outputfilter"
 	"github.com/streamingfast/substreams/pipeline/outputmodules"
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/service/config"
 	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/failure"
 	"github.com/streamingfast/substreams/storage/store"
 	"github.com/streamingfast/substreams/wasm"
 	"go.opentelemetry.io/otel/attribute"
@@ -67,6 +69,8 @@ type Tier1Service struct {
 	tracer             ttrace.Tracer
 	logger             *zap.Logger
 
+	failureRecordsCache *failure.Cache // nil when not caching the failure records
+
 	getRecentFinalBlock func() (uint64, error)
 	resolveCursor       pipeline.CursorResolver
 	getHeadBlock        func() (uint64, error)
@@ -135,6 +139,7 @@ func NewTier1(
 ) (*Tier1Service, error) {
 
 	clientFactory := client.NewInternalClientFactory(substreamsClientConfig)
+	tier2Capacity := work.NewTier2Capacity()
 
 	runtimeConfig := config.NewTier1RuntimeConfig(
 		stateBundleSize,
@@ -144,9 +149,10 @@ func NewTier1(
 		stateStore,
 		defaultCacheTag,
 		func(logger *zap.Logger) work.Worker {
-			return work.NewRemoteWorker(clientFactory, logger)
+			return work.NewRemoteWorker(clientFactory, tier2Capacity, logger)
 		},
 	)
+	runtimeConfig.Tier2Capacity = tier2Capacity
 
 	sf := &StreamFactory{
 		mergedBlocksStore: mergedBlocksStore,
@@ -172,6 +178,7 @@ func NewTier1(
 		blockType:              blockType,
 		tracer:                 tracing.GetTracer(),
 		failedRequests:         make(map[string]*recordedFailure),
+		failureRecordsCache:    failure.NewCache(FailureRecordCacheTTL),
 		resolveCursor:          pipeline.NewCursorResolver(hub, mergedBlocksStore, forkedBlocksStore),
 		logger:                 logger,
 		tier2RequestParameters: tier2RequestParameters,
@@ -187,6 +194,10 @@ func NewTier1(
 		opt(s)
 	}
 
+	if err := wasm.ValidateRuntime(s.runtimeConfig.WASMRuntime); err != nil {
+		return nil, fmt.Errorf("invalid wasm runtime: %w", err)
+	}
+
 	return s, nil
 }
 
@@ -201,11 +212,7 @@ func (s *Tier1Service) Blocks(
 
 	logger := reqctx.Logger(ctx).Named("tier1")
 
-	ctx = logging.WithLogger(ctx, logger)
-	ctx = reqctx.WithTracer(ctx, s.tracer)
-	ctx = dmetering.WithBytesMeter(ctx)
-	ctx = dmetering.WithCounter(ctx, "wasm_input_bytes")
-	ctx = reqctx.WithTier2RequestParameters(ctx, s.tier2RequestParameters)
+	ctx = s.requestContext(ctx, logger)
 
 	ctx, span := reqctx.WithSpan(ctx, "substreams/tier1/request")
 	defer span.EndWithErr(&err)
@@ -220,22 +227,14 @@ func (s *Tier1Service) Blocks(
 		mut.Unlock()
 	}()
 
-	respFunc := tier1ResponseHandler(respContext, &mut, logger, stream)
+	respFunc := tier1ResponseHandler(respContext, &mut, logger, stream, outputencoding.NewEncoder(req.Msg.AcceptedOutputEncodings))
 
 	span.SetAttributes(attribute.Int64("substreams.tier", 1))
 
 	request := req.Msg
-	if request.Modules == nil {
-		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("missing modules in request"))
-	}
-
-	if err := outputmodules.ValidateTier1Request(request, s.blockType); err != nil {
-		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("validate request: %w", err))
-	}
-
-	outputGraph, err := outputmodules.NewOutputModuleGraph(request.OutputModule, request.ProductionMode, request.Modules)
+	outputGraph, err := s.validateRequest(request)
 	if err != nil {
-		return bsstream.NewErrInvalidArg(err.Error())
+		return err
 	}
 	outputModuleHash := outputGraph.ModuleHashes().Get(request.OutputModule)
 
@@ -251,6 +250,21 @@ func (s *Tier1Service) Blocks(
 		zap.String("output_module", request.OutputModule),
 		zap.String("output_module_hash", outputModuleHash),
 	}
+	if len(request.AdditionalOutputModules) > 0 {
+		fields = append(fields, zap.Strings("additional_output_modules", request.AdditionalOutputModules))
+	}
+	if request.OutputFilter != nil {
+		fields = append(fields, zap.String("output_filter", request.OutputFilter.Expression))
+	}
+	if request.SkipEmptyOutputs {
+		fields = append(fields, zap.Bool("skip_empty_outputs", true))
+	}
+	if request.UndoOutputs {
+		fields = append(fields, zap.Bool("undo_outputs", true))
+	}
+	if len(request.AcceptedOutputEncodings) > 0 {
+		fields = append(fields, zap.Strings("accepted_output_encodings", request.AcceptedOutputEncodings))
+	}
 	fields = append(fields, zap.Bool("production_mode", request.ProductionMode))
 	if auth := dauth.FromContext(ctx); auth != nil {
 		fields = append(fields,
@@ -274,23 +288,49 @@ func (s *Tier1Service) Blocks(
 	metrics.ActiveSubstreams.Inc()
 	defer metrics.ActiveSubstreams.Dec()
 
-	requestID := fmt.Sprintf("%s:%d:%d:%s:%t:%t:%s",
-		outputModuleHash,
-		request.StartBlockNum,
-		request.StopBlockNum,
-		request.StartCursor,
-		request.ProductionMode,
-		request.FinalBlocksOnly,
-		strings.Join(request.DebugInitialStoreSnapshotForModules, ","),
-	)
+	return s.runRequest(ctx, "Blocks", request, outputGraph, logger, func(ctx context.Context) error {
+		err = s.blocks(ctx, request, outputGraph, respFunc)
+		return err
+	})
+}
 
-	//	s.resolveCursor
+// requestContext returns `ctx` set up for the processing of a request: its logger and
+// tracer, the meters of its bytes and wasm inputs and the parameters of its tier2 jobs.
+func (s *Tier1Service) requestContext(ctx context.Context, logger *zap.Logger) context.Context {
+	ctx = logging.WithLogger(ctx, logger)
+	ctx = reqctx.WithTracer(ctx, s.tracer)
+	ctx = dmetering.WithBytesMeter(ctx)
+	ctx = dmetering.WithCounter(ctx, "wasm_input_bytes")
+	ctx = reqctx.WithTier2RequestParameters(ctx, s.tier2RequestParameters)
+	return ctx
+}
+
+// validateRequest checks `request` and returns the graph of its modules.
+func (s *Tier1Service) validateRequest(request *pbsubstreamsrpc.Request) (*outputmodules.Graph, error) {
+	if request.Modules == nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("missing modules in request"))
+	}
+	if err := outputmodules.ValidateTier1Request(request, s.blockType); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("validate request: %w", err))
+	}
+	outputGraph, err := outputmodules.NewOutputModuleGraph(request.OutputModule, request.ProductionMode, request.Modules, request.AdditionalOutputModules...)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	return outputGraph, nil
+}
+
+// runRequest runs `run`, processing `request` for the `rpc` handler, and returns its error
+// as a connect error. The requests failing with an invalid argument are recorded to fail
+// fast when retried, see errorFromRecordedFailure. `run` is canceled on app shutdown.
+func (s *Tier1Service) runRequest(ctx context.Context, rpc string, request *pbsubstreamsrpc.Request, outputGraph *outputmodules.Graph, logger *zap.Logger, run func(ctx context.Context) error) error {
+	requestID := blocksRequestID(request, outputGraph)
 	if err := s.errorFromRecordedFailure(requestID, request.ProductionMode, request.StartBlockNum, request.StartCursor); err != nil {
 		logger.Debug("failing fast on known failing request", zap.String("request_id", requestID))
 		return err
 	}
 
-	// On app shutdown, we cancel the running '.blocks()' command,
+	// On app shutdown, we cancel the running request,
 	// we catch this situation via IsTerminating() to return a special error.
 	runningContext, cancelRunning := context.WithCancelCause(ctx)
 	go func() {
@@ -302,7 +342,7 @@ func (s *Tier1Service) Blocks(
 		}
 	}()
 
-	err = s.blocks(runningContext, request, outputGraph, respFunc)
+	err := run(runningContext)
 
 	if connectError := toConnectError(runningContext, err); connectError != nil {
 		switch connect.CodeOf(connectError) {
@@ -312,17 +352,83 @@ func (s *Tier1Service) Blocks(
 			logger.Debug("recording failure on request", zap.String("request_id", requestID))
 			s.recordFailure(requestID, connectError)
 		case connect.CodeCanceled:
-			logger.Info("Blocks request canceled by user", zap.Error(connectError))
+			logger.Info(rpc+" request canceled by user", zap.Error(connectError))
 		default:
-			logger.Warn("Blocks request completed with error", zap.Error(connectError))
+			logger.Warn(rpc+" request completed with error", zap.Error(connectError))
 		}
 		return connectError
 	}
 
-	logger.Debug("Blocks request completed witout error")
+	logger.Debug(rpc + " request completed without error")
 	return nil
 }
 
+// resolveRequestDetails resolves the blocks processed for `request` along with the parallel
+// jobs and the cache tag granted to it by the auth layer.
+func (s *Tier1Service) resolveRequestDetails(ctx context.Context, request *pbsubstreamsrpc.Request, outputGraph *outputmodules.Graph) (*reqctx.RequestDetails, *pbsubstreamsrpc.BlockUndoSignal, error) {
+	if err := adjustStartBlock(request); err != nil {
+		return nil, nil, err
+	}
+	requestDetails, undoSignal, err := pipeline.BuildRequestDetails(ctx, request, s.getRecentFinalBlock, s.resolveCursor, s.getHeadBlock)
+	if err != nil {
+		return nil, nil, fmt.Errorf("build request details: %w", err)
+	}
+	if err := outputGraph.ValidateRequestStartBlock(requestDetails.ResolvedStartBlockNum); err != nil {
+		return nil, nil, bsstream.NewErrInvalidArg(err.Error())
+	}
+
+	requestDetails.MaxParallelJobs = s.requestMaxParallelJobs(ctx)
+	requestDetails.CacheTag, err = s.requestCacheTag(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return requestDetails, undoSignal, nil
+}
+
+// requestCacheStore returns the store caching the modules data under `cacheTag`, metering
+// the bytes read and written by the request.
+func (s *Tier1Service) requestCacheStore(ctx context.Context, cacheTag string) (dstore.Store, error) {
+	cacheStore, err := s.runtimeConfig.BaseObjectStore.SubStore(cacheTag)
+	if err != nil {
+		return nil, fmt.Errorf("internal error setting store: %w", err)
+	}
+	if clonableStore, ok := cacheStore.(dstore.Clonable); ok {
+		cloned, err := clonableStore.Clone(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cloning store: %w", err)
+		}
+		cloned.SetMeter(dmetering.GetBytesMeter(ctx))
+		cacheStore = cloned
+	}
+	return cacheStore, nil
+}
+
+// blocksRequestID identifies the request in the recorded failures of this instance, see
+// errorFromRecordedFailure. The output settings are part of it: they change the responses
+// sent, so a request failing with some of them is not known to fail with others.
+func blocksRequestID(request *pbsubstreamsrpc.Request, outputGraph *outputmodules.Graph) string {
+	additionalOutputModuleHashes := make([]string, len(request.AdditionalOutputModules))
+	for i, name := range request.AdditionalOutputModules {
+		additionalOutputModuleHashes[i] = outputGraph.ModuleHashes().Get(name)
+	}
+	return fmt.Sprintf("%s:%s:%d:%d:%s:%t:%t:%s:%s:%t:%t:%d:%d:%s",
+		outputGraph.ModuleHashes().Get(request.OutputModule),
+		strings.Join(additionalOutputModuleHashes, ","),
+		request.StartBlockNum,
+		request.StopBlockNum,
+		request.StartCursor,
+		request.ProductionMode,
+		request.FinalBlocksOnly,
+		strings.Join(request.DebugInitialStoreSnapshotForModules, ","),
+		request.GetOutputFilter().GetExpression(),
+		request.SkipEmptyOutputs,
+		request.UndoOutputs,
+		request.BatchMaxBlocks,
+		request.BatchMaxBytes,
+		strings.Join(request.AcceptedOutputEncodings, ","),
+	)
+}
+
 func (s *Tier1Service) writePackage(ctx context.Context, request *pbsubstreamsrpc.Request, outputGraph *outputmodules.Graph) error {
 	asPackage := &pbsubstreams.Package{
 		Modules:    request.Modules,
@@ -353,41 +459,32 @@ func (s *Tier1Service) writePackage(ctx context.Context, request *pbsubstreamsrp
 
 var IsValidCacheTag = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`).MatchString
 
-func (s *Tier1Service) blocks(ctx context.Context, request *pbsubstreamsrpc.Request, outputGraph *outputmodules.Graph, respFunc substreams.ResponseFunc) error {
-	chainFirstStreamableBlock := bstream.GetProtocolFirstStreamableBlock
-	if request.StartBlockNum > 0 && request.StartBlockNum < int64(chainFirstStreamableBlock) {
-		return bsstream.NewErrInvalidArg("invalid start block %d, must be >= %d (the first streamable block of the chain)", request.StartBlockNum, chainFirstStreamableBlock)
-	} else if request.StartBlockNum < 0 && request.StopBlockNum > 0 {
-		if int64(request.StopBlockNum)+int64(request.StartBlockNum) < int64(chainFirstStreamableBlock) {
-			request.StartBlockNum = int64(chainFirstStreamableBlock)
+func (s *Tier1Service) blocks(ctx context.Context, request *pbsubstreamsrpc.Request, outputGraph *outputmodules.Graph, respFunc substreams.ResponseFunc) (err error) {
+	logger := reqctx.Logger(ctx)
+
+	var outputFilter *outputfilter.Filter
+	if request.OutputFilter != nil {
+		f, err := outputfilter.New(request.OutputFilter.Expression, outputGraph.OutputModule(), request.OutputFilter.ProtoFiles)
+		if err != nil {
+			return bsstream.NewErrInvalidArg(err.Error())
 		}
-	} else if request.StartBlockNum == 0 {
-		request.StartBlockNum = int64(chainFirstStreamableBlock)
+		outputFilter = f
 	}
+	outputSkipper := outputfilter.NewSkipper(outputFilter, request.SkipEmptyOutputs, time.Duration(request.HeartbeatIntervalSeconds)*time.Second)
 
-	logger := reqctx.Logger(ctx)
-
-	requestDetails, undoSignal, err := pipeline.BuildRequestDetails(ctx, request, s.getRecentFinalBlock, s.resolveCursor, s.getHeadBlock)
+	requestDetails, undoSignal, err := s.resolveRequestDetails(ctx, request, outputGraph)
 	if err != nil {
-		return fmt.Errorf("build request details: %w", err)
+		return err
 	}
 
-	requestDetails.MaxParallelJobs = s.runtimeConfig.DefaultParallelSubrequests
-	requestDetails.CacheTag = s.runtimeConfig.DefaultCacheTag
+	var profiler *wasm.Profiler
 	if auth := dauth.FromContext(ctx); auth != nil {
-		if parallelJobs := auth.Get("X-Sf-Substreams-Parallel-Jobs"); parallelJobs != "" {
-			if ll, err := strconv.ParseUint(parallelJobs, 10, 64); err == nil {
-				requestDetails.MaxParallelJobs = ll
+		if profiledModules := auth.Get(client.ProfileModulesHeader); profiledModules != "" {
+			profiler, err = newModulesProfiler(profiledModules, request)
+			if err != nil {
+				return err
 			}
 		}
-		if cacheTag := auth.Get("X-Sf-Substreams-Cache-Tag"); cacheTag != "" {
-			if IsValidCacheTag(cacheTag) {
-				requestDetails.CacheTag = cacheTag
-			} else {
-				return fmt.Errorf("invalid value for X-Sf-Substreams-Cache-Tag %s, should only contain letters, numbers, hyphens and undescores", cacheTag)
-			}
-		}
-
 	}
 
 	var requestStats *metrics.Stats
@@ -410,30 +507,31 @@ func (s *Tier1Service) blocks(ctx context.Context, request *pbsubstreamsrpc.Requ
 	if s.runtimeConfig.ModuleExecutionTracing {
 		ctx = reqctx.WithModuleExecutionTracing(ctx)
 	}
+	if profiler != nil {
+		ctx = wasm.WithProfiler(ctx, profiler)
+	}
 
 	if err := s.writePackage(ctx, request, outputGraph); err != nil {
 		logger.Warn("cannot write package", zap.Error(err))
 	}
 
-	if err := outputGraph.ValidateRequestStartBlock(requestDetails.ResolvedStartBlockNum); err != nil {
-		return bsstream.NewErrInvalidArg(err.Error())
+	wasmRuntime := wasm.NewRegistryWithRuntime(s.runtimeConfig.WASMRuntime, s.wasmExtensions, s.runtimeConfig.MaxWasmFuel)
+	if s.runtimeConfig.WASMInstanceSnapshots {
+		wasmRuntime.EnableInstanceSnapshots()
 	}
 
-	wasmRuntime := wasm.NewRegistry(s.wasmExtensions, s.runtimeConfig.MaxWasmFuel)
-
-	cacheStore, err := s.runtimeConfig.BaseObjectStore.SubStore(requestDetails.CacheTag)
+	cacheStore, err := s.requestCacheStore(ctx, requestDetails.CacheTag)
 	if err != nil {
-		return fmt.Errorf("internal error setting store: %w", err)
+		return err
 	}
 
-	if clonableStore, ok := cacheStore.(dstore.Clonable); ok {
-		cloned, err := clonableStore.Clone(ctx)
-		if err != nil {
-			return fmt.Errorf("cloning store: %w", err)
-		}
-		cloned.SetMeter(dmetering.GetBytesMeter(ctx))
-		cacheStore = cloned
+	failures, err := s.checkRecordedFailures(ctx, cacheStore, outputGraph, request.ProductionMode, requestDetails.ResolvedStartBlockNum, request.StopBlockNum, logger)
+	if err != nil {
+		return err
 	}
+	defer func() {
+		s.persistFailure(ctx, failures, outputGraph, err, logger)
+	}()
 
 	execOutputConfigs, err := execout.NewConfigs(cacheStore, outputGraph.UsedModules(), outputGraph.ModuleHashes(), s.runtimeConfig.StateBundleSize, logger)
 	if err != nil {
@@ -465,6 +563,9 @@ func (s *Tier1Service) blocks(ctx context.Context, request *pbsubstreamsrpc.Requ
 	if request.FinalBlocksOnly {
 		opts = append(opts, pipeline.WithFinalBlocksOnly())
 	}
+	if outputSkipper != nil {
+		opts = append(opts, pipeline.WithOutputSkipper(outputSkipper))
+	}
 
 	pipe := pipeline.New(
 		ctx,
@@ -553,10 +654,33 @@ func (s *Tier1Service) blocks(ctx context.Context, request *pbsubstreamsrpc.Requ
 	streamErr = blockStream.Run(ctx)
 	span.EndWithErr(&streamErr)
 
-	return pipe.OnStreamTerminated(ctx, streamErr)
+	if err := pipe.OnStreamTerminated(ctx, streamErr); err != nil {
+		return err
+	}
+
+	if profiler != nil {
+		return sendModuleProfiles(profiler, respFunc)
+	}
+	return nil
 }
 
-func tier1ResponseHandler(ctx context.Context, mut *sync.Mutex, logger *zap.Logger, streamSrv *connect.ServerStream[pbsubstreamsrpc.Response]) substreams.ResponseFunc {
+// adjustStartBlock moves the start block of `request` to the first streamable block of the
+// chain when it would be below it.
+func adjustStartBlock(request *pbsubstreamsrpc.Request) error {
+	chainFirstStreamableBlock := bstream.GetProtocolFirstStreamableBlock
+	if request.StartBlockNum > 0 && request.StartBlockNum < int64(chainFirstStreamableBlock) {
+		return bsstream.NewErrInvalidArg("invalid start block %d, must be >= %d (the first streamable block of the chain)", request.StartBlockNum, chainFirstStreamableBlock)
+	} else if request.StartBlockNum < 0 && request.StopBlockNum > 0 {
+		if int64(request.StopBlockNum)+int64(request.StartBlockNum) < int64(chainFirstStreamableBlock) {
+			request.StartBlockNum = int64(chainFirstStreamableBlock)
+		}
+	} else if request.StartBlockNum == 0 {
+		request.StartBlockNum = int64(chainFirstStreamableBlock)
+	}
+	return nil
+}
+
+func tier1ResponseHandler(ctx context.Context, mut *sync.Mutex, logger *zap.Logger, streamSrv *connect.ServerStream[pbsubstreamsrpc.Response], outputEncoder *outputencoding.Encoder) substreams.ResponseFunc {
 	auth := dauth.FromContext(ctx)
 	userID := auth.UserID()
 	apiKeyID := auth.APIKeyID()
@@ -574,6 +698,18 @@ func tier1ResponseHandler(ctx context.Context, mut *sync.Mutex, logger *zap.Logg
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
+
+		// encoded under the lock, dictionaries must reach the client before the outputs using them
+		dictionaries, err := outputEncoder.EncodeResponse(resp)
+		if err != nil {
+			return fmt.Errorf("encoding outputs: %w", err)
+		}
+		for _, dictionary := range dictionaries {
+			if err := streamSrv.Send(&pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_OutputDictionary{OutputDictionary: dictionary}}); err != nil {
+				logger.Info("unable to send output dictionary probably due to client disconnecting", zap.Error(err))
+				return connect.NewError(connect.CodeUnavailable, err)
+			}
+		}
 		if err := streamSrv.Send(resp); err != nil {
 			logger.Info("unable to send block probably due to client disconnecting", zap.Error(err))
 			return connect.NewError(connect.CodeUnavailable, err)
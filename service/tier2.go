@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"os"
 	"sync"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/streamingfast/substreams"
 	"github.com/streamingfast/substreams/block"
 	"github.com/streamingfast/substreams/metrics"
+	"github.com/streamingfast/substreams/orchestrator/work"
 	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	"github.com/streamingfast/substreams/pipeline"
@@ -28,6 +31,7 @@ import (
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/service/config"
 	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/failure"
 	"github.com/streamingfast/substreams/storage/store"
 	"github.com/streamingfast/substreams/wasm"
 	"go.opentelemetry.io/otel/attribute"
@@ -51,6 +55,9 @@ type Tier2Service struct {
 	connectionCountMutex      sync.RWMutex
 
 	tier2RequestParameters *reqctx.Tier2RequestParameters
+
+	// instanceID identifies this instance in its capacity advertisements
+	instanceID string
 }
 
 const protoPkfPrefix = "type.googleapis.com/"
@@ -65,6 +72,7 @@ func NewTier2(
 		runtimeConfig: runtimeConfig,
 		tracer:        tracing.GetTracer(),
 		logger:        logger,
+		instanceID:    fmt.Sprintf("%016x", rand.Uint64()),
 	}
 
 	metrics.RegisterMetricSet(logger)
@@ -73,6 +81,10 @@ func NewTier2(
 		opt(s)
 	}
 
+	if err := wasm.ValidateRuntime(s.runtimeConfig.WASMRuntime); err != nil {
+		return nil, fmt.Errorf("invalid wasm runtime: %w", err)
+	}
+
 	return s, nil
 }
 
@@ -105,17 +117,27 @@ func (s *Tier2Service) setOverloaded() {
 	s.setReadyFunc(!overloaded)
 }
 
+// advertiseCapacity sends the number of requests processed by this instance and its maximum
+// in the response headers, from which tier1 sizes its number of jobs, see `work.Tier2Capacity`.
+func (s *Tier2Service) advertiseCapacity(setHeader func(metadata.MD) error) {
+	s.connectionCountMutex.RLock()
+	md := work.CapacityHeaders(s.instanceID, s.currentConcurrentRequests, s.runtimeConfig.MaxConcurrentRequests)
+	s.connectionCountMutex.RUnlock()
+
+	if err := setHeader(md); err != nil {
+		s.logger.Warn("cannot send capacity headers", zap.Error(err))
+	}
+}
+
 func (s *Tier2Service) ProcessRange(request *pbssinternal.ProcessRangeRequest, streamSrv pbssinternal.Substreams_ProcessRangeServer) (grpcError error) {
 	metrics.Tier2ActiveRequests.Inc()
 	metrics.Tier2RequestCounter.Inc()
 	defer metrics.Tier2ActiveRequests.Dec()
 
-	// We keep `err` here as the unaltered error from `blocks` call, this is used in the EndSpan to record the full error
-	// and not only the `grpcError` one which is a subset view of the full `err`.
-	var err error
 	ctx := streamSrv.Context()
 
 	if s.isOverloaded() {
+		s.advertiseCapacity(streamSrv.SetHeader)
 		return connect.NewError(connect.CodeUnavailable, fmt.Errorf("service currently overloaded"))
 	}
 
@@ -123,7 +145,42 @@ func (s *Tier2Service) ProcessRange(request *pbssinternal.ProcessRangeRequest, s
 	defer func() {
 		s.decrementConcurrentRequests()
 	}()
+	s.advertiseCapacity(streamSrv.SetHeader)
+
+	// `err` is the unaltered error of the request, `grpcError` is a subset view of it.
+	err := s.serveProcessRange(ctx, request, "substreams/tier2/request", streamSrv.SetHeader, streamSrv.Send)
+	grpcError = toGRPCError(ctx, err)
 
+	switch status.Code(grpcError) {
+	case codes.Unknown, codes.Internal, codes.Unavailable:
+		logger := reqctx.Logger(ctx).Named("tier2").With(
+			zap.String("stage", request.OutputModule),
+			zap.String("segment", fmt.Sprintf("%d:%d", request.StartBlockNum, request.StopBlockNum)),
+		)
+		logger.Info("unexpected termination of stream of blocks", zap.Error(err))
+	}
+
+	return grpcError
+}
+
+// LocalProcessRange processes `request` like ProcessRange, in the caller's process: the
+// responses are given to `respFunc` instead of being sent to a remote tier1. It is used by
+// the tier1 workers of `WithLocalTier2`.
+func (s *Tier2Service) LocalProcessRange(ctx context.Context, request *pbssinternal.ProcessRangeRequest, respFunc substreams.ResponseFunc) error {
+	metrics.Tier2ActiveRequests.Inc()
+	metrics.Tier2RequestCounter.Inc()
+	defer metrics.Tier2ActiveRequests.Dec()
+
+	send := func(resp *pbssinternal.ProcessRangeResponse) error {
+		return respFunc(resp)
+	}
+	return s.serveProcessRange(ctx, request, "substreams/tier2/local_request", nil, send)
+}
+
+// serveProcessRange sets up the logger, the tracing span and the metering of `request`,
+// validates it and processes it, sending the responses with `send`. The hostname is
+// advertised with `setHeader` when it is set.
+func (s *Tier2Service) serveProcessRange(ctx context.Context, request *pbssinternal.ProcessRangeRequest, spanName string, setHeader func(metadata.MD) error, send func(*pbssinternal.ProcessRangeResponse) error) (err error) {
 	// TODO: use stage and segment numbers when implemented
 	stage := request.OutputModule
 	segment := fmt.Sprintf("%d:%d",
@@ -140,12 +197,14 @@ func (s *Tier2Service) ProcessRange(request *pbssinternal.ProcessRangeRequest, s
 	ctx = dmetering.WithCounter(ctx, "wasm_input_bytes")
 	ctx = reqctx.WithTracer(ctx, s.tracer)
 
-	ctx, span := reqctx.WithSpan(ctx, "substreams/tier2/request")
+	ctx, span := reqctx.WithSpan(ctx, spanName)
 	defer span.EndWithErr(&err)
 	span.SetAttributes(attribute.Int64("substreams.tier", 2))
 
-	hostname := updateStreamHeadersHostname(streamSrv.SetHeader, logger)
-	span.SetAttributes(attribute.String("hostname", hostname))
+	if setHeader != nil {
+		hostname := updateStreamHeadersHostname(setHeader, logger)
+		span.SetAttributes(attribute.String("hostname", hostname))
+	}
 
 	if request.Modules == nil {
 		return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("missing modules in request"))
@@ -201,16 +260,7 @@ func (s *Tier2Service) ProcessRange(request *pbssinternal.ProcessRangeRequest, s
 
 	ctx = context.WithValue(ctx, "event_emitter", emitter)
 
-	respFunc := tier2ResponseHandler(ctx, logger, streamSrv)
-	err = s.processRange(ctx, request, respFunc)
-	grpcError = toGRPCError(ctx, err)
-
-	switch status.Code(grpcError) {
-	case codes.Unknown, codes.Internal, codes.Unavailable:
-		logger.Info("unexpected termination of stream of blocks", zap.Error(err))
-	}
-
-	return grpcError
+	return s.processRange(ctx, request, tier2ResponseHandler(ctx, logger, send))
 }
 
 func (s *Tier2Service) processRange(ctx context.Context, request *pbssinternal.ProcessRangeRequest, respFunc substreams.ResponseFunc) error {
@@ -247,7 +297,7 @@ func (s *Tier2Service) processRange(ctx context.Context, request *pbssinternal.P
 
 	// FIXME: here, we validate that we have only modules on the same
 	// stage, otherwise we fall back.
-	outputGraph, err := outputmodules.NewOutputModuleGraph(request.OutputModule, true, request.Modules)
+	outputGraph, err := outputmodules.NewOutputModuleGraph(request.OutputModule, true, request.Modules, request.AdditionalOutputModules...)
 	if err != nil {
 		return stream.NewErrInvalidArg(err.Error())
 	}
@@ -285,7 +335,10 @@ func (s *Tier2Service) processRange(ctx context.Context, request *pbssinternal.P
 		}
 		exts = x
 	}
-	wasmRuntime := wasm.NewRegistry(exts, s.runtimeConfig.MaxWasmFuel)
+	wasmRuntime := wasm.NewRegistryWithRuntime(s.runtimeConfig.WASMRuntime, exts, s.runtimeConfig.MaxWasmFuel)
+	if s.runtimeConfig.WASMInstanceSnapshots {
+		wasmRuntime.EnableInstanceSnapshots()
+	}
 
 	cacheStore, err := stateStore.SubStore(requestDetails.CacheTag)
 	if err != nil {
@@ -311,11 +364,14 @@ func (s *Tier2Service) processRange(ctx context.Context, request *pbssinternal.P
 		return fmt.Errorf("configuring stores: %w", err)
 	}
 	stores := pipeline.NewStores(ctx, storeConfigs, request.StateBundleSize, requestDetails.ResolvedStartBlockNum, request.StopBlockNum, true)
-	isCompleteRange := request.StopBlockNum%request.StateBundleSize == 0
+	// Execution outputs are cached per segment: a job resuming a segment from a store
+	// checkpoint neither writes them nor finds them under its own range.
+	startsSegment := request.StartBlockNum%request.StateBundleSize == 0 || request.StartBlockNum <= stageLowestInitBlock(outputGraph, request.Stage)
+	isCompleteRange := startsSegment && request.StopBlockNum%request.StateBundleSize == 0
 
 	// note all modules that are not in 'modulesRequiredToRun' are still iterated in 'pipeline.executeModules', but they will skip actual execution when they see that the cache provides the data
 	// This way, stores get updated at each block from the cached execouts without the actual execution of the module
-	modulesRequiredToRun, existingExecOuts, execOutWriters, err := evaluateModulesRequiredToRun(ctx, logger, outputGraph, request.Stage, request.StartBlockNum, request.StopBlockNum, isCompleteRange, request.OutputModule, execOutputConfigs, storeConfigs)
+	modulesRequiredToRun, existingExecOuts, execOutWriters, err := evaluateModulesRequiredToRun(ctx, logger, outputGraph, request.Stage, request.StartBlockNum, request.StopBlockNum, request.StateBundleSize, startsSegment, isCompleteRange, append([]string{request.OutputModule}, request.AdditionalOutputModules...), execOutputConfigs, storeConfigs)
 	if err != nil {
 		return fmt.Errorf("evaluating required modules: %w", err)
 	}
@@ -433,8 +489,10 @@ func evaluateModulesRequiredToRun(
 	stage uint32,
 	startBlock uint64,
 	stopBlock uint64,
+	segmentSize uint64,
+	startsSegment bool,
 	isCompleteRange bool,
-	outputModule string,
+	outputModules []string,
 	execoutConfigs *execout.Configs,
 	storeConfigs store.ConfigMap,
 ) (requiredModules map[string]*pbsubstreams.Module, existingExecOuts map[string]*execout.File, execoutWriters map[string]*execout.Writer, err error) {
@@ -446,6 +504,12 @@ func evaluateModulesRequiredToRun(
 		usedModules[module.Name] = module
 	}
 
+	isOutputModule := make(map[string]bool, len(outputModules))
+	for _, name := range outputModules {
+		isOutputModule[name] = true
+	}
+	existingOutputs := 0
+
 	stageUsedModules := outputGraph.StagedUsedModules()[stage]
 	runningLastStage := stageUsedModules.IsLastStage()
 	stageUsedModulesName := make(map[string]bool)
@@ -459,7 +523,14 @@ func evaluateModulesRequiredToRun(
 			continue
 		}
 
-		file, readErr := c.ReadFile(ctx, &block.Range{StartBlock: startBlock, ExclusiveEndBlock: stopBlock})
+		readRange := &block.Range{StartBlock: startBlock, ExclusiveEndBlock: stopBlock}
+		var file *execout.File
+		var readErr error
+		if startsSegment {
+			file, readErr = c.ReadFile(ctx, readRange)
+		} else {
+			file, readErr = c.ReadSegmentFile(ctx, readRange, segmentSize)
+		}
 		if readErr != nil {
 			requiredModules[name] = usedModules[name]
 			continue
@@ -467,11 +538,8 @@ func evaluateModulesRequiredToRun(
 		existingExecOuts[name] = file
 
 		if c.ModuleKind() == pbsubstreams.ModuleKindMap {
-			if runningLastStage && name == outputModule {
-				// WARNING be careful, if we want to force producing module outputs/stores states for ALL STAGES on the first block range,
-				// this optimization will be in our way..
-				logger.Info("found existing exec output for output_module, skipping run", zap.String("output_module", name))
-				return nil, nil, nil, nil
+			if runningLastStage && isOutputModule[name] {
+				existingOutputs++
 			}
 			continue
 		}
@@ -493,12 +561,23 @@ func evaluateModulesRequiredToRun(
 		}
 	}
 
+	if runningLastStage && existingOutputs == len(outputModules) {
+		// WARNING be careful, if we want to force producing module outputs/stores states for ALL STAGES on the first block range,
+		// this optimization will be in our way..
+		logger.Info("found existing exec output for all output modules, skipping run", zap.Strings("output_modules", outputModules))
+		return nil, nil, nil, nil
+	}
+
 	for name, module := range requiredModules {
 		if _, exists := existingExecOuts[name]; exists {
 			continue // for stores that need to be run for the partials, but already have cached execution outputs
 		}
-		if !isCompleteRange && name != outputModule {
-			// if we are not running a complete range, we can skip writing the outputs of every module except the requested outputModule if it's in our stage
+		if !startsSegment {
+			// the outputs would not cover the segment, readers would never find them
+			continue
+		}
+		if !isCompleteRange && !isOutputModule[name] {
+			// if we are not running a complete range, we can skip writing the outputs of every module except the requested output modules if they are in our stage
 			continue
 		}
 		if module.ModuleKind() == pbsubstreams.ModuleKindStore {
@@ -519,6 +598,18 @@ func evaluateModulesRequiredToRun(
 
 }
 
+// stageLowestInitBlock returns the lowest initial block of the modules of `stage`, where
+// the first segment of its jobs starts.
+func stageLowestInitBlock(outputGraph *outputmodules.Graph, stage uint32) uint64 {
+	var lowest uint64 = math.MaxUint64
+	for _, layer := range outputGraph.StagedUsedModules()[stage] {
+		for _, mod := range layer {
+			lowest = min(lowest, mod.InitialBlock)
+		}
+	}
+	return lowest
+}
+
 func canSkipBlockSource(existingExecOuts map[string]*execout.File, requiredModules map[string]*pbsubstreams.Module, blockType string) bool {
 	if len(existingExecOuts) == 0 {
 		return false
@@ -544,7 +635,7 @@ func canSkipBlockSource(existingExecOuts map[string]*execout.File, requiredModul
 //	return
 //}
 
-func tier2ResponseHandler(ctx context.Context, logger *zap.Logger, streamSrv pbssinternal.Substreams_ProcessRangeServer) substreams.ResponseFunc {
+func tier2ResponseHandler(ctx context.Context, logger *zap.Logger, send func(*pbssinternal.ProcessRangeResponse) error) substreams.ResponseFunc {
 	meter := dmetering.GetBytesMeter(ctx)
 	auth := dauth.FromContext(ctx)
 	userID := auth.UserID()
@@ -554,7 +645,7 @@ func tier2ResponseHandler(ctx context.Context, logger *zap.Logger, streamSrv pbs
 
 	return func(respAny substreams.ResponseFromAnyTier) error {
 		resp := respAny.(*pbssinternal.ProcessRangeResponse)
-		if err := streamSrv.Send(resp); err != nil {
+		if err := send(resp); err != nil {
 			logger.Info("unable to send block probably due to client disconnecting", zap.Error(err))
 			return connect.NewError(connect.CodeUnavailable, err)
 		}
@@ -634,7 +725,7 @@ func toGRPCError(ctx context.Context, err error) error {
 		return status.Error(codes.InvalidArgument, err.Error())
 	}
 	if errors.Is(err, exec.ErrWasmDeterministicExec) {
-		return status.Error(codes.InvalidArgument, err.Error())
+		return failure.GRPCError(codes.InvalidArgument, err)
 	}
 
 	var errInvalidArg *stream.ErrInvalidArg
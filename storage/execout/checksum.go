@@ -0,0 +1,116 @@
+package execout
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/streamingfast/derr"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+)
+
+var checksumFilenameRegex = regexp.MustCompile(`^([\d]+)-([\d]+)\.checksum$`)
+
+// SegmentChecksum is the checksum of the outputs of a module saved in the file covering `Range`.
+type SegmentChecksum struct {
+	Range    *block.Range
+	Checksum string
+}
+
+// Checksum returns the hex encoded SHA-256 of the outputs in the file, hashed in block order as
+// their block number, block ID and payload. It only depends on the outputs, so providers
+// running the same module over the same blocks compute the same checksum.
+func (c *File) Checksum() string {
+	hash := sha256.New()
+	var buf [8]byte
+	for _, item := range c.SortedItems() {
+		binary.BigEndian.PutUint64(buf[:], item.BlockNum)
+		hash.Write(buf[:])
+		binary.BigEndian.PutUint64(buf[:], uint64(len(item.BlockId)))
+		hash.Write(buf[:])
+		hash.Write([]byte(item.BlockId))
+		binary.BigEndian.PutUint64(buf[:], uint64(len(item.Payload)))
+		hash.Write(buf[:])
+		hash.Write(item.Payload)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *File) saveChecksum(ctx context.Context) error {
+	if c.checksumStore == nil {
+		return nil
+	}
+
+	filename := computeChecksumFilename(c.Range.StartBlock, c.Range.ExclusiveEndBlock)
+	checksum := []byte(c.Checksum())
+	return derr.RetryContext(ctx, 5, func(ctx context.Context) error {
+		return c.checksumStore.WriteObject(ctx, filename, bytes.NewReader(checksum))
+	})
+}
+
+// ListChecksums returns the checksums of the output files fully within [startBlock, exclusiveEndBlock)
+// covering a whole segment of `interval` blocks. Files covering part of a segment are skipped, their
+// checksums cannot be compared with the ones of other providers.
+func (c *Config) ListChecksums(ctx context.Context, startBlock, exclusiveEndBlock, interval uint64) (out []*SegmentChecksum, err error) {
+	var ranges block.Ranges
+	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		ranges = nil
+		return c.checksumStore.WalkFrom(ctx, "", computeChecksumFilename(startBlock, 0), func(filename string) error {
+			res := checksumFilenameRegex.FindStringSubmatch(filename)
+			if res == nil {
+				c.logger.Warn("seen checksum file that we don't know how to parse", zap.String("filename", filename))
+				return nil
+			}
+			rng := block.NewRange(uint64(mustAtoi(res[1])), uint64(mustAtoi(res[2])))
+			if rng.ExclusiveEndBlock > exclusiveEndBlock {
+				return dstore.StopIteration
+			}
+			if rng.StartBlock >= startBlock && c.coversSegment(rng, interval) {
+				ranges = append(ranges, rng)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking checksum files: %w", err)
+	}
+
+	for _, rng := range ranges {
+		filename := computeChecksumFilename(rng.StartBlock, rng.ExclusiveEndBlock)
+		var checksum []byte
+		err := derr.RetryContext(ctx, 5, func(ctx context.Context) error {
+			reader, err := c.checksumStore.OpenObject(ctx, filename)
+			if err != nil {
+				return fmt.Errorf("opening checksum file %s: %w", filename, err)
+			}
+			defer reader.Close()
+			checksum, err = io.ReadAll(reader)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &SegmentChecksum{Range: rng, Checksum: string(checksum)})
+	}
+	return out, nil
+}
+
+// coversSegment returns whether `rng` starts and ends on the boundaries of a single segment.
+func (c *Config) coversSegment(rng *block.Range, interval uint64) bool {
+	if rng.StartBlock != c.moduleInitialBlock && rng.StartBlock%interval != 0 {
+		return false
+	}
+	return rng.ExclusiveEndBlock == (rng.StartBlock/interval+1)*interval
+}
+
+func computeChecksumFilename(startBlock, stopBlock uint64) string {
+	return fmt.Sprintf("%010d-%010d.checksum", startBlock, stopBlock)
+}
@@ -0,0 +1,64 @@
+package execout
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func TestFile_Checksum(t *testing.T) {
+	config, err := NewConfig("A", 0, pbsubstreams.ModuleKindMap, "abc", dstore.NewMockStore(nil), zap.NewNop())
+	require.NoError(t, err)
+
+	fileA := config.NewFile(block.NewRange(0, 10))
+	fileA.SetItem(&pbsubstreams.Clock{Number: 1, Id: "1a"}, []byte("one"))
+	fileA.SetItem(&pbsubstreams.Clock{Number: 2, Id: "2a"}, []byte("two"))
+
+	fileB := config.NewFile(block.NewRange(0, 10))
+	fileB.SetItem(&pbsubstreams.Clock{Number: 2, Id: "2a"}, []byte("two"))
+	fileB.SetItem(&pbsubstreams.Clock{Number: 1, Id: "1a"}, []byte("one"))
+	assert.Equal(t, fileA.Checksum(), fileB.Checksum(), "insertion order does not matter")
+
+	fileB.SetItem(&pbsubstreams.Clock{Number: 2, Id: "2a"}, []byte("tw"))
+	assert.NotEqual(t, fileA.Checksum(), fileB.Checksum())
+
+	fileC := config.NewFile(block.NewRange(0, 10))
+	fileC.SetItem(&pbsubstreams.Clock{Number: 1, Id: "1a"}, []byte("onet"))
+	fileC.SetItem(&pbsubstreams.Clock{Number: 2, Id: "2a"}, []byte("wo"))
+	assert.NotEqual(t, fileA.Checksum(), fileC.Checksum(), "payloads are delimited")
+}
+
+func TestConfig_ListChecksums(t *testing.T) {
+	ctx := context.Background()
+	config, err := NewConfig("A", 0, pbsubstreams.ModuleKindMap, "abc", dstore.NewMockStore(nil), zap.NewNop())
+	require.NoError(t, err)
+
+	checksums := map[uint64]string{}
+	for _, start := range []uint64{0, 10, 20, 30} {
+		file := config.NewFile(block.NewRange(start, start+10))
+		file.SetItem(&pbsubstreams.Clock{Number: start + 1, Id: "a"}, []byte{byte(start)})
+		require.NoError(t, file.Save(ctx))
+		checksums[start] = file.Checksum()
+	}
+	// files not covering a whole segment are not listed
+	for _, rng := range []*block.Range{block.NewRange(15, 20), block.NewRange(20, 25)} {
+		file := config.NewFile(rng)
+		file.SetItem(&pbsubstreams.Clock{Number: rng.StartBlock, Id: "a"}, []byte{byte(rng.StartBlock)})
+		require.NoError(t, file.Save(ctx))
+	}
+
+	out, err := config.ListChecksums(ctx, 10, 35, 10)
+	require.NoError(t, err)
+	require.Len(t, out, 2)
+	assert.Equal(t, "[10, 20)", out[0].Range.String())
+	assert.Equal(t, checksums[10], out[0].Checksum)
+	assert.Equal(t, "[20, 30)", out[1].Range.String())
+	assert.Equal(t, checksums[20], out[1].Checksum)
+}
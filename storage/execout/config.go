@@ -15,9 +15,10 @@ import (
 )
 
 type Config struct {
-	name       string
-	moduleHash string
-	objStore   dstore.Store
+	name          string
+	moduleHash    string
+	objStore      dstore.Store
+	checksumStore dstore.Store
 
 	modKind            pbsubstreams.ModuleKind
 	moduleInitialBlock uint64
@@ -30,10 +31,15 @@ func NewConfig(name string, moduleInitialBlock uint64, modKind pbsubstreams.Modu
 	if err != nil {
 		return nil, fmt.Errorf("creating sub store: %w", err)
 	}
+	checksumStore, err := baseStore.SubStore(fmt.Sprintf("%s/checksums", moduleHash))
+	if err != nil {
+		return nil, fmt.Errorf("creating checksum sub store: %w", err)
+	}
 
 	return &Config{
 		name:               name,
 		objStore:           subStore,
+		checksumStore:      checksumStore,
 		modKind:            modKind,
 		moduleInitialBlock: moduleInitialBlock,
 		moduleHash:         moduleHash,
@@ -43,11 +49,12 @@ func NewConfig(name string, moduleInitialBlock uint64, modKind pbsubstreams.Modu
 
 func (c *Config) NewFile(targetRange *block.Range) *File {
 	return &File{
-		kv:         make(map[string]*pboutput.Item),
-		ModuleName: c.name,
-		store:      c.objStore,
-		Range:      targetRange,
-		logger:     c.logger,
+		kv:            make(map[string]*pboutput.Item),
+		ModuleName:    c.name,
+		store:         c.objStore,
+		checksumStore: c.checksumStore,
+		Range:         targetRange,
+		logger:        c.logger,
 	}
 }
 
@@ -81,6 +88,37 @@ func (c *Config) ListSnapshotFiles(ctx context.Context, inRange *bstream.Range)
 	return files, nil
 }
 
+// ReadSegmentFile loads the outputs cached for the segment of `interval` blocks that
+// contains `rng`, for a range starting past the start of its segment, like a store job
+// resuming a segment from a checkpoint. The file read is the one ending with `rng` and
+// starting at or before it, within the segment.
+func (c *Config) ReadSegmentFile(ctx context.Context, rng *block.Range, interval uint64) (*File, error) {
+	segmentStart := rng.StartBlock - rng.StartBlock%interval
+
+	var found *block.Range
+	err := c.objStore.WalkFrom(ctx, "", computeDBinFilename(segmentStart, 0), func(filename string) error {
+		fileInfo, err := parseFileName(filename)
+		if err != nil {
+			return nil
+		}
+		if fileInfo.BlockRange.StartBlock > rng.StartBlock {
+			return dstore.StopIteration
+		}
+		if fileInfo.BlockRange.ExclusiveEndBlock == rng.ExclusiveEndBlock {
+			found = fileInfo.BlockRange
+			return dstore.StopIteration
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking files: %w", err)
+	}
+	if found == nil {
+		return nil, dstore.ErrNotFound
+	}
+	return c.ReadFile(ctx, found)
+}
+
 func (c *Config) ReadFile(ctx context.Context, inrange *block.Range) (*File, error) {
 
 	file := c.NewFile(inrange)
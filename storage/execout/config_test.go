@@ -0,0 +1,41 @@
+package execout
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func TestConfig_ReadSegmentFile(t *testing.T) {
+	ctx := context.Background()
+	config, err := NewConfig("A", 0, pbsubstreams.ModuleKindMap, "abc", dstore.NewMockStore(nil), zap.NewNop())
+	require.NoError(t, err)
+
+	for _, rng := range []*block.Range{block.NewRange(0, 100), block.NewRange(100, 200), block.NewRange(200, 250)} {
+		file := config.NewFile(rng)
+		file.SetItem(&pbsubstreams.Clock{Number: rng.StartBlock + 1, Id: "a"}, []byte("out"))
+		require.NoError(t, file.Save(ctx))
+	}
+
+	file, err := config.ReadSegmentFile(ctx, block.NewRange(150, 200), 100)
+	require.NoError(t, err)
+	assert.Equal(t, "[100, 200)", file.Range.String())
+	_, found := file.Get(&pbsubstreams.Clock{Number: 101, Id: "a"})
+	assert.True(t, found)
+
+	file, err = config.ReadSegmentFile(ctx, block.NewRange(220, 250), 100)
+	require.NoError(t, err)
+	assert.Equal(t, "[200, 250)", file.Range.String())
+
+	_, err = config.ReadSegmentFile(ctx, block.NewRange(220, 300), 100)
+	assert.ErrorIs(t, err, dstore.ErrNotFound)
+	_, err = config.ReadSegmentFile(ctx, block.NewRange(350, 400), 100)
+	assert.ErrorIs(t, err, dstore.ErrNotFound)
+}
@@ -33,6 +33,9 @@ type File struct {
 	kv         map[string]*pboutput.Item
 	store      dstore.Store
 	logger     *zap.Logger
+
+	// checksumStore receives the checksum of the outputs when the file is saved, see Checksum.
+	checksumStore dstore.Store
 }
 
 func (c *File) Filename() string {
@@ -151,11 +154,19 @@ func (c *File) Save(ctx context.Context) error {
 	}
 
 	c.logger.Info("writing execution output file", zap.String("filename", filename))
-	return derr.RetryContext(ctx, 10, func(ctx context.Context) error { // more than the usual 5 retries here because if we fail, we have to reprocess the whole segment
+	err = derr.RetryContext(ctx, 10, func(ctx context.Context) error { // more than the usual 5 retries here because if we fail, we have to reprocess the whole segment
 		reader := bytes.NewReader(cnt)
 		err := c.store.WriteObject(ctx, filename, reader)
 		return err
 	})
+	if err != nil {
+		return err
+	}
+
+	if err := c.saveChecksum(ctx); err != nil {
+		return fmt.Errorf("writing checksum of file %s: %w", filename, err)
+	}
+	return nil
 }
 
 func (c *File) String() string {
@@ -9,11 +9,13 @@ import (
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 )
 
-// The Writer writes a single file with executionOutputs that will be read by the LinearExecOutReader.
+// The Writer writes the files with executionOutputs that will be read by the LinearExecOutReader,
+// one per segment of the range it covers.
 // `initialBlockBoundary` is expected to be on a boundary, or to be the module's initial block.
 type Writer struct {
 	wg *sync.WaitGroup
 
+	walker       *FileWalker
 	currentFile  *File
 	outputModule string
 }
@@ -25,16 +27,32 @@ func NewWriter(initialBlockBoundary, exclusiveEndBlock uint64, outputModule stri
 	}
 
 	segmenter := block.NewSegmenter(configs.execOutputSaveInterval, initialBlockBoundary, exclusiveEndBlock)
-	walker := configs.NewFileWalker(outputModule, segmenter)
-	w.currentFile = walker.File()
+	w.walker = configs.NewFileWalker(outputModule, segmenter)
+	w.currentFile = w.walker.File()
 
 	return w
 }
 
-func (w *Writer) Write(clock *pbsubstreams.Clock, buffer *Buffer) {
+func (w *Writer) Write(ctx context.Context, clock *pbsubstreams.Clock, buffer *Buffer) error {
+	for clock.Number >= w.currentFile.ExclusiveEndBlock && w.walker.segment < w.walker.segmenter.LastIndex() {
+		if err := w.rotate(ctx); err != nil {
+			return err
+		}
+	}
 	if val, found := buffer.values[w.outputModule]; found {
 		w.currentFile.SetItem(clock, val)
 	}
+	return nil
+}
+
+// rotate saves the current file and moves to the next segment's file.
+func (w *Writer) rotate(ctx context.Context) error {
+	if err := w.currentFile.Save(ctx); err != nil {
+		return fmt.Errorf("saving exec output segment: %w", err)
+	}
+	w.walker.Next()
+	w.currentFile = w.walker.File()
+	return nil
 }
 
 func (w *Writer) Close(ctx context.Context) error {
@@ -1,10 +1,17 @@
 package execout
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
+	"github.com/streamingfast/dstore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 )
 
 var testConfigs = &Configs{
@@ -27,3 +34,30 @@ func TestNewExecOutputWriterIsSubRequest(t *testing.T) {
 	require.NotNil(t, res)
 	assert.Equal(t, 15, int(res.currentFile.ExclusiveEndBlock))
 }
+
+func TestWriter_Segments(t *testing.T) {
+	ctx := context.Background()
+	config, err := NewConfig("A", 0, pbsubstreams.ModuleKindMap, "abc", dstore.NewMockStore(nil), zap.NewNop())
+	require.NoError(t, err)
+	configs := &Configs{execOutputSaveInterval: 10, ConfigMap: map[string]*Config{"A": config}}
+
+	w := NewWriter(10, 35, "A", configs)
+	for num := uint64(10); num < 35; num++ {
+		require.NoError(t, w.Write(ctx, &pbsubstreams.Clock{Number: num, Id: fmt.Sprintf("%d", num)}, &Buffer{values: map[string][]byte{"A": {byte(num)}}}))
+	}
+	require.NoError(t, w.Close(ctx))
+
+	out, err := config.ListChecksums(ctx, 10, 40, 10)
+	require.NoError(t, err)
+	require.Len(t, out, 2, "one file per segment, the partial last segment is not listed")
+	assert.Equal(t, "[10, 20)", out[0].Range.String())
+	assert.Equal(t, "[20, 30)", out[1].Range.String())
+
+	last := config.NewFile(block.NewRange(30, 35))
+	require.NoError(t, last.Load(ctx))
+	assert.Len(t, last.SortedItems(), 5)
+
+	file := config.NewFile(block.NewRange(20, 30))
+	require.NoError(t, file.Load(ctx))
+	assert.Len(t, file.SortedItems(), 10)
+}
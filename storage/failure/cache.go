@@ -0,0 +1,68 @@
+package failure
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Cache keeps the failure records of the modules in memory for `ttl`, sparing a walk of
+// the records on every request. It is shared by the Records of a process: the records
+// persisted by another process are seen once the cached ones expire.
+type Cache struct {
+	ttl time.Duration
+
+	lock    sync.Mutex
+	entries map[string]*cacheEntry // by records store and module hash
+}
+
+type cacheEntry struct {
+	records  []*Record
+	loadedAt time.Time
+}
+
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: make(map[string]*cacheEntry)}
+}
+
+func (c *Cache) get(key string) ([]*Record, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, found := c.entries[key]
+	if !found || time.Since(entry.loadedAt) >= c.ttl {
+		return nil, false
+	}
+	return entry.records, true
+}
+
+func (c *Cache) set(key string, records []*Record) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[key] = &cacheEntry{records: records, loadedAt: time.Now()}
+}
+
+// add adds `record` to the cached records of its module, if any, replacing the record at
+// the same block.
+func (c *Cache) add(key string, record *Record) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, found := c.entries[key]
+	if !found {
+		return
+	}
+	records := make([]*Record, 0, len(entry.records)+1)
+	for _, existing := range entry.records {
+		if existing.Block != record.Block {
+			records = append(records, existing)
+		}
+	}
+	records = append(records, record)
+	sort.Slice(records, func(i, j int) bool { return records[i].Block < records[j].Block })
+	entry.records = records
+}
+
+func (c *Cache) remove(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, key)
+}
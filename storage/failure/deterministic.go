@@ -0,0 +1,80 @@
+package failure
+
+import (
+	"errors"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DeterministicError is the failure of a module at a block that processing the block again
+// with the same module and runtime fails the same way.
+type DeterministicError struct {
+	ModuleName string
+	Block      uint64
+	Err        error
+}
+
+func NewDeterministicError(moduleName string, block uint64, err error) *DeterministicError {
+	return &DeterministicError{ModuleName: moduleName, Block: block, Err: err}
+}
+
+func (e *DeterministicError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DeterministicError) Unwrap() error {
+	return e.Err
+}
+
+const (
+	errorInfoDomain = "substreams.streamingfast.io"
+	errorInfoReason = "DETERMINISTIC_MODULE_FAILURE"
+)
+
+// GRPCError returns `err` as a gRPC error of `code`, carrying the module and block of the
+// DeterministicError it wraps, if any, as an ErrorInfo detail so they cross the tier2
+// connection.
+func GRPCError(code codes.Code, err error) error {
+	st := status.New(code, err.Error())
+
+	var deterministic *DeterministicError
+	if !errors.As(err, &deterministic) {
+		return st.Err()
+	}
+	withDetails, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: errorInfoReason,
+		Domain: errorInfoDomain,
+		Metadata: map[string]string{
+			"module": deterministic.ModuleName,
+			"block":  strconv.FormatUint(deterministic.Block, 10),
+		},
+	})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// FromGRPCError returns `err` wrapped in a DeterministicError when it is a gRPC error
+// carrying the detail added by GRPCError, `err` unchanged otherwise.
+func FromGRPCError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != errorInfoDomain || info.Reason != errorInfoReason {
+			continue
+		}
+		block, parseErr := strconv.ParseUint(info.Metadata["block"], 10, 64)
+		if parseErr != nil || info.Metadata["module"] == "" {
+			continue
+		}
+		return NewDeterministicError(info.Metadata["module"], block, err)
+	}
+	return err
+}
@@ -0,0 +1,207 @@
+// Package failure persists the deterministic failures of modules in the state store, so
+// every tier1 replica fails fast on the requests bound to hit them instead of retrying
+// them on tier2.
+package failure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/streamingfast/derr"
+	"github.com/streamingfast/dstore"
+
+	"github.com/streamingfast/substreams/block"
+)
+
+// Record is the deterministic failure of a module, identified by its hash, at a block,
+// when executed by `Runtime`.
+type Record struct {
+	ModuleHash string    `json:"module_hash"`
+	ModuleName string    `json:"module_name"`
+	Block      uint64    `json:"block"`
+	Runtime    string    `json:"runtime"`
+	Error      string    `json:"error"`
+	At         time.Time `json:"at"`
+}
+
+func (r *Record) String() string {
+	return fmt.Sprintf("module %q (%s) failed deterministically at block %d: %s", r.ModuleName, r.ModuleHash, r.Block, r.Error)
+}
+
+// Records holds the failure records of a cache store, under `failures/`, one per module
+// hash and failing block. A record is never rewritten with other content, so tier1
+// instances recording failures concurrently do not need conditional writes.
+type Records struct {
+	store dstore.Store
+	cache *Cache
+}
+
+// NewRecords returns the failure records of `cacheStore`, read through `cache` when it
+// is not nil.
+func NewRecords(cacheStore dstore.Store, cache *Cache) (*Records, error) {
+	store, err := cacheStore.SubStore("failures")
+	if err != nil {
+		return nil, fmt.Errorf("creating failures sub store: %w", err)
+	}
+	return &Records{store: store, cache: cache}, nil
+}
+
+func (r *Records) cacheKey(moduleHash string) string {
+	return r.store.BaseURL().String() + "/" + moduleHash
+}
+
+// filename sorts the records of a module by block.
+func filename(moduleHash string, block uint64) string {
+	return fmt.Sprintf("%s/%010d.json", moduleHash, block)
+}
+
+// Put records `record`, replacing the record of its module at the same block.
+func (r *Records) Put(ctx context.Context, record *Record) error {
+	content, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshalling failure record: %w", err)
+	}
+	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		return r.store.WriteObject(ctx, filename(record.ModuleHash, record.Block), bytes.NewReader(content))
+	})
+	if err != nil {
+		return err
+	}
+	if r.cache != nil {
+		r.cache.add(r.cacheKey(record.ModuleHash), record)
+	}
+	return nil
+}
+
+// Get returns the failure records of the module of `moduleHash`, by block.
+func (r *Records) Get(ctx context.Context, moduleHash string) (out []*Record, err error) {
+	if r.cache == nil {
+		return r.list(ctx, moduleHash+"/")
+	}
+	key := r.cacheKey(moduleHash)
+	if records, found := r.cache.get(key); found {
+		return records, nil
+	}
+	if out, err = r.list(ctx, moduleHash+"/"); err != nil {
+		return nil, err
+	}
+	r.cache.set(key, out)
+	return out, nil
+}
+
+func (r *Records) read(ctx context.Context, name string) (*Record, error) {
+	reader, err := r.store.OpenObject(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	out := &Record{}
+	if err := json.Unmarshal(content, out); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s: %w", name, err)
+	}
+	return out, nil
+}
+
+// List returns all the failure records, by module hash and block.
+func (r *Records) List(ctx context.Context) (out []*Record, err error) {
+	return r.list(ctx, "")
+}
+
+func (r *Records) list(ctx context.Context, prefix string) (out []*Record, err error) {
+	names, err := r.walk(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		record, err := r.read(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("reading failure record %s: %w", name, err)
+		}
+		out = append(out, record)
+	}
+	return out, nil
+}
+
+func (r *Records) walk(ctx context.Context, prefix string) (names []string, err error) {
+	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		names = nil
+		return r.store.Walk(ctx, prefix, func(filename string) error {
+			if strings.HasSuffix(filename, ".json") {
+				names = append(names, filename)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking failure records: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Clear removes the failure records of the module of `moduleHash`.
+func (r *Records) Clear(ctx context.Context, moduleHash string) error {
+	names, err := r.walk(ctx, moduleHash+"/")
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := r.store.DeleteObject(ctx, name); err != nil {
+			return fmt.Errorf("deleting failure record %s: %w", name, err)
+		}
+	}
+	if r.cache != nil {
+		r.cache.remove(r.cacheKey(moduleHash))
+	}
+	return nil
+}
+
+// Error is returned for the requests bound to hit a recorded failure.
+type Error struct {
+	Record *Record
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("failing fast on known failure: %s", e.Record)
+}
+
+// Check returns an *Error when one of the modules of `ranges`, by module hash, has a
+// failure record within its range, recorded with `runtime` less than `maxAge` ago: the
+// module could succeed with another runtime or once the failure cause, like an
+// operational limit, is lifted.
+func (r *Records) Check(ctx context.Context, ranges map[string]*block.Range, runtime string, maxAge time.Duration) error {
+	hashes := make([]string, 0, len(ranges))
+	for hash := range ranges {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+
+	for _, hash := range hashes {
+		rng := ranges[hash]
+		if rng.StartBlock >= rng.ExclusiveEndBlock {
+			continue
+		}
+		records, err := r.Get(ctx, hash)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if record.Runtime == runtime && time.Since(record.At) < maxAge && rng.Contains(record.Block) {
+				return &Error{Record: record}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package failure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/streamingfast/substreams/block"
+)
+
+func TestRecords(t *testing.T) {
+	ctx := context.Background()
+	testStore := dstore.NewMockStore(nil)
+	testStore.SetOverwrite(true)
+	records, err := NewRecords(testStore, nil)
+	require.NoError(t, err)
+
+	list, err := records.Get(ctx, "abc")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	now := time.Now()
+	require.NoError(t, records.Put(ctx, &Record{ModuleHash: "abc", ModuleName: "map_a", Block: 3000, Runtime: "r1", At: now}))
+	require.NoError(t, records.Put(ctx, &Record{ModuleHash: "abc", ModuleName: "map_a", Block: 2000, Runtime: "r2", At: now}))
+	require.NoError(t, records.Put(ctx, &Record{ModuleHash: "def", ModuleName: "store_b", Block: 500, Runtime: "r1", At: now.Add(-2 * time.Hour)}))
+
+	list, err = records.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, uint64(2000), list[0].Block, "by module hash and block")
+	assert.Equal(t, uint64(3000), list[1].Block)
+	assert.Equal(t, "def", list[2].ModuleHash)
+
+	var knownFailure *Error
+	err = records.Check(ctx, map[string]*block.Range{"abc": block.NewRange(0, 3000), "def": block.NewRange(1000, 5000)}, "r1", time.Hour)
+	assert.NoError(t, err, "the failure at 2000 is of another runtime")
+	err = records.Check(ctx, map[string]*block.Range{"abc": block.NewRange(0, 3000)}, "r2", time.Hour)
+	require.True(t, errors.As(err, &knownFailure))
+	assert.Equal(t, uint64(2000), knownFailure.Record.Block)
+	err = records.Check(ctx, map[string]*block.Range{"abc": block.NewRange(1000, 3001)}, "r1", time.Hour)
+	require.True(t, errors.As(err, &knownFailure))
+	assert.Equal(t, uint64(3000), knownFailure.Record.Block)
+
+	err = records.Check(ctx, map[string]*block.Range{"def": block.NewRange(0, 1000)}, "r1", time.Hour)
+	assert.NoError(t, err, "expired")
+	err = records.Check(ctx, map[string]*block.Range{"def": block.NewRange(0, 1000)}, "r1", 3*time.Hour)
+	assert.Error(t, err)
+
+	require.NoError(t, records.Clear(ctx, "abc"))
+	list, err = records.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "def", list[0].ModuleHash)
+}
+
+// walkCountingStore counts the walks of the failure records.
+type walkCountingStore struct {
+	*dstore.MockStore
+	walks int
+}
+
+func (s *walkCountingStore) Walk(ctx context.Context, prefix string, f func(filename string) error) error {
+	s.walks++
+	return s.MockStore.Walk(ctx, prefix, f)
+}
+
+func TestRecords_Cache(t *testing.T) {
+	ctx := context.Background()
+	testStore := &walkCountingStore{MockStore: dstore.NewMockStore(nil)}
+	testStore.SetOverwrite(true)
+	records := &Records{store: testStore, cache: NewCache(time.Hour)}
+	other := &Records{store: testStore}
+
+	ranges := map[string]*block.Range{"abc": block.NewRange(0, 3000)}
+	require.NoError(t, records.Check(ctx, ranges, "r1", time.Hour))
+	require.NoError(t, records.Check(ctx, ranges, "r1", time.Hour))
+	assert.Equal(t, 1, testStore.walks, "the records of a module are walked once")
+
+	require.NoError(t, other.Put(ctx, &Record{ModuleHash: "abc", Block: 1000, Runtime: "r1", At: time.Now()}))
+	assert.NoError(t, records.Check(ctx, ranges, "r1", time.Hour), "recorded by another instance, the cached records are not expired")
+
+	var knownFailure *Error
+	require.NoError(t, records.Put(ctx, &Record{ModuleHash: "abc", Block: 2000, Runtime: "r1", At: time.Now()}))
+	require.ErrorAs(t, records.Check(ctx, ranges, "r1", time.Hour), &knownFailure)
+	assert.Equal(t, uint64(2000), knownFailure.Record.Block, "its own records are cached")
+	assert.Equal(t, 1, testStore.walks)
+
+	records.cache.ttl = 0
+	require.ErrorAs(t, records.Check(ctx, ranges, "r1", time.Hour), &knownFailure)
+	assert.Equal(t, uint64(1000), knownFailure.Record.Block, "expired, the records are walked again")
+	assert.Equal(t, 2, testStore.walks)
+
+	records.cache.ttl = time.Hour
+	require.NoError(t, records.Clear(ctx, "abc"))
+	assert.NoError(t, records.Check(ctx, ranges, "r1", time.Hour))
+}
+
+func TestGRPCError(t *testing.T) {
+	cause := fmt.Errorf("block 12: module %q: %w: out of bounds", "map_a", errors.New("wasm execution failed deterministically"))
+	err := GRPCError(codes.InvalidArgument, fmt.Errorf("step new irr: %w", NewDeterministicError("map_a", 12, cause)))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	var deterministic *DeterministicError
+	require.True(t, errors.As(FromGRPCError(err), &deterministic))
+	assert.Equal(t, "map_a", deterministic.ModuleName)
+	assert.Equal(t, uint64(12), deterministic.Block)
+	assert.Equal(t, err, deterministic.Err)
+
+	other := GRPCError(codes.InvalidArgument, cause)
+	assert.Equal(t, other, FromGRPCError(other), "the failure is not known to be deterministic")
+
+	notGRPC := errors.New("connection reset")
+	assert.Equal(t, notGRPC, FromGRPCError(notGRPC))
+}
@@ -105,6 +105,15 @@ func (c *Config) ExistsPartialKV(ctx context.Context, from, to uint64) (bool, er
 	return c.objStore.FileExists(ctx, filename)
 }
 
+// DeleteFullKV deletes the full KV `file`, it is used for the checkpoints saved off the
+// save interval, once a later full KV of their segment supersedes them.
+func (c *Config) DeleteFullKV(ctx context.Context, file *FileInfo) error {
+	if err := c.objStore.DeleteObject(ctx, file.Filename); err != nil {
+		return fmt.Errorf("deleting full kv %q: %w", file.Filename, err)
+	}
+	return nil
+}
+
 func (c *Config) NewPartialKV(initialBlock uint64, logger *zap.Logger) *PartialKV {
 	return &PartialKV{
 		baseStore:    c.newBaseStore(logger),
@@ -130,18 +139,79 @@ func (c *Config) FileSize(ctx context.Context, fileInfo *FileInfo) (int64, error
 	return size, nil
 }
 
+// ListSnapshotFiles lists all the snapshot files of the store starting below `below`.
 func (c *Config) ListSnapshotFiles(ctx context.Context, below uint64) (files []*FileInfo, err error) {
 	if below == 0 {
 		return nil, nil
 	}
+	return c.walkSnapshotFiles(ctx, "", below)
+}
 
+// ListUsableSnapshotFiles lists the snapshot files of the store starting below `below`
+// that a request segmented on `interval` can use. When the store has a manifest for that
+// interval, the files it supersedes are left out and only the files past it are walked.
+func (c *Config) ListUsableSnapshotFiles(ctx context.Context, below, interval uint64) (files []*FileInfo, err error) {
+	files, _, err = c.listUsableSnapshotFiles(ctx, below, interval)
+	return files, err
+}
+
+// RefreshManifest lists the snapshot files like ListUsableSnapshotFiles, and rewrites the
+// manifest of the store when the listed full KVs extend it, so the next listings skip them.
+func (c *Config) RefreshManifest(ctx context.Context, below, interval uint64) (files []*FileInfo, err error) {
+	files, manifest, err := c.listUsableSnapshotFiles(ctx, below, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed := NewManifest(files, c.moduleInitialBlock, interval, below)
+	if refreshed == nil || (manifest != nil && refreshed.UpTo <= manifest.UpTo) {
+		return files, nil
+	}
+	if err := c.WriteManifest(ctx, refreshed); err != nil {
+		logging.Logger(ctx, zlog).Warn("cannot write manifest", zap.String("store", c.name), zap.Error(err))
+	}
+	return files, nil
+}
+
+// listUsableSnapshotFiles also returns the manifest for `interval` the listing used, if any.
+func (c *Config) listUsableSnapshotFiles(ctx context.Context, below, interval uint64) (files []*FileInfo, manifest *Manifest, err error) {
+	if below == 0 {
+		return nil, nil, nil
+	}
+
+	manifest, err = c.ReadManifest(ctx)
+	if err != nil {
+		logging.Logger(ctx, zlog).Warn("ignoring unreadable manifest, listing all files", zap.String("store", c.name), zap.Error(err))
+		manifest = nil
+	}
+	if manifest != nil && manifest.Interval != interval {
+		manifest = nil
+	}
+
+	startingPoint := ""
+	if manifest != nil {
+		files = manifest.Files(c.name, c.moduleInitialBlock, below)
+		startingPoint = manifest.walkStartingPoint()
+	}
+
+	walkedFiles, err := c.walkSnapshotFiles(ctx, startingPoint, below)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(files, walkedFiles...), manifest, nil
+}
+
+func (c *Config) walkSnapshotFiles(ctx context.Context, startingPoint string, below uint64) (files []*FileInfo, err error) {
 	logger := logging.Logger(ctx, zlog)
 	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
 		// We need to clear each time we start because a previous retry could have accumulated a partial state
 		files = nil
 
 		deletedOldFiles := 0
-		return c.objStore.Walk(ctx, "", func(filename string) (err error) {
+		return c.objStore.WalkFrom(ctx, "", startingPoint, func(filename string) (err error) {
+			if filename == ManifestFilename {
+				return nil
+			}
 			fileInfo, ok := parseFileName(c.Name(), filename)
 			if !ok {
 				logger.Warn("seen snapshot file that we don't know how to parse", zap.String("filename", filename))
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/streamingfast/derr"
+)
+
+// ManifestFilename is the object, next to the snapshots of a store, listing them.
+const ManifestFilename = "manifest.json"
+
+// Manifest records that the store has a full KV on every boundary of the save `Interval`
+// up to `UpTo`, so listing its snapshots only requires walking the files past it instead
+// of the whole store, which is slow on object stores holding thousands of segments.
+//
+// Those full KVs are immutable and never deleted, and every file ending below `UpTo`
+// (partials, checkpoints saved off the interval) is superseded by them. The manifest thus
+// stays true whichever files are written or deleted below it, and concurrent writers can
+// only lower `UpTo`, making the next listings walk more files.
+type Manifest struct {
+	Interval uint64 `json:"interval"`
+	UpTo     uint64 `json:"up_to"`
+}
+
+// NewManifest returns the manifest of the full KVs of `files` starting at
+// `moduleInitialBlock` and ending on consecutive boundaries of `interval`, up to `below`,
+// nil if there is none.
+func NewManifest(files []*FileInfo, moduleInitialBlock, interval, below uint64) *Manifest {
+	if interval == 0 {
+		return nil
+	}
+	fullKVEnds := make(map[uint64]bool)
+	for _, file := range files {
+		if !file.Partial && !file.WithTraceID && file.Range.StartBlock == moduleInitialBlock {
+			fullKVEnds[file.Range.ExclusiveEndBlock] = true
+		}
+	}
+
+	m := &Manifest{Interval: interval}
+	for end := firstBoundary(moduleInitialBlock, interval); end <= below && fullKVEnds[end]; end += interval {
+		m.UpTo = end
+	}
+	if m.UpTo == 0 {
+		return nil
+	}
+	return m
+}
+
+// Files returns the full KVs listed in the manifest ending at or below `below`.
+func (m *Manifest) Files(moduleName string, moduleInitialBlock, below uint64) (out []*FileInfo) {
+	for end := firstBoundary(moduleInitialBlock, m.Interval); end <= m.UpTo && end <= below; end += m.Interval {
+		out = append(out, NewCompleteFileInfo(moduleName, moduleInitialBlock, end))
+	}
+	return out
+}
+
+// walkStartingPoint is the first filename past the files superseded by the manifest.
+func (m *Manifest) walkStartingPoint() string {
+	return fmt.Sprintf("%010d", m.UpTo+1)
+}
+
+func firstBoundary(moduleInitialBlock, interval uint64) uint64 {
+	return moduleInitialBlock - moduleInitialBlock%interval + interval
+}
+
+// ReadManifest returns the manifest of the store, nil if it has none.
+func (c *Config) ReadManifest(ctx context.Context) (out *Manifest, err error) {
+	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		out = nil
+		exists, err := c.objStore.FileExists(ctx, ManifestFilename)
+		if err != nil || !exists {
+			return err
+		}
+
+		reader, err := c.objStore.OpenObject(ctx, ManifestFilename)
+		if err != nil {
+			return fmt.Errorf("opening manifest: %w", err)
+		}
+		defer reader.Close()
+
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return fmt.Errorf("reading manifest: %w", err)
+		}
+		out = &Manifest{}
+		return json.Unmarshal(content, out)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest of store %q: %w", c.name, err)
+	}
+	return out, nil
+}
+
+// WriteManifest replaces the manifest of the store with `manifest`.
+func (c *Config) WriteManifest(ctx context.Context, manifest *Manifest) error {
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("marshalling manifest: %w", err)
+	}
+	return derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		return c.objStore.WriteObject(ctx, ManifestFilename, bytes.NewReader(content))
+	})
+}
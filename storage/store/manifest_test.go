@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_RefreshManifest(t *testing.T) {
+	ctx := context.Background()
+	testStore := dstore.NewMockStore(nil)
+	testStore.SetOverwrite(true)
+	for _, filename := range []string{
+		"0000001000-0000000000.kv",
+		"0000002000-0000000000.kv",
+		"0000002000-0000001000.partial", // merged into the full KV
+		"0000002500-0000000000.kv",      // checkpoint
+		"0000003000-0000002000.partial",
+		"0000004000-0000000000.kv",
+		"0000004000-0000003000.partial",
+	} {
+		testStore.Files[filename] = []byte("content")
+	}
+	c := &Config{name: "A", objStore: testStore}
+
+	files, err := c.RefreshManifest(ctx, 10000, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, "[0, 1000),[0, 2000),[1000, 2000),[0, 2500),[2000, 3000),[0, 4000),[3000, 4000)", FileInfos(files).String())
+
+	manifest, err := c.ReadManifest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &Manifest{Interval: 1000, UpTo: 2000}, manifest, "no full KV at 3000")
+
+	// the files below the manifest are superseded: they are not listed anymore, even
+	// when written later, while the files past it are walked every time
+	delete(testStore.Files, "0000003000-0000002000.partial")
+	testStore.Files["0000003000-0000000000.kv"] = []byte("content")
+	testStore.Files["0000001000-0000000500.partial"] = []byte("content")
+
+	files, err = c.ListUsableSnapshotFiles(ctx, 10000, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, "[0, 1000),[0, 2000),[0, 2500),[0, 3000),[0, 4000),[3000, 4000)", FileInfos(files).String())
+
+	_, err = c.RefreshManifest(ctx, 3500, 1000)
+	require.NoError(t, err)
+	manifest, err = c.ReadManifest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &Manifest{Interval: 1000, UpTo: 3000}, manifest)
+
+	files, err = c.ListUsableSnapshotFiles(ctx, 10000, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, "[0, 1000),[0, 2000),[0, 3000),[0, 4000),[3000, 4000)", FileInfos(files).String())
+
+	// a manifest of another interval is ignored
+	files, err = c.ListUsableSnapshotFiles(ctx, 10000, 500)
+	require.NoError(t, err)
+	assert.Len(t, files, 8)
+
+	// the complete listing ignores the manifest
+	files, err = c.ListSnapshotFiles(ctx, 10000)
+	require.NoError(t, err)
+	assert.Len(t, files, 8)
+}
+
+func TestNewManifest(t *testing.T) {
+	files := []*FileInfo{
+		NewCompleteFileInfo("A", 1500, 2000),
+		NewCompleteFileInfo("A", 1500, 3000),
+		NewCompleteFileInfo("A", 1500, 3500),
+		NewPartialFileInfo("A", 3000, 4000),
+		NewCompleteFileInfo("A", 1500, 5000),
+	}
+	assert.Equal(t, &Manifest{Interval: 1000, UpTo: 3000}, NewManifest(files, 1500, 1000, 10000))
+	assert.Equal(t, &Manifest{Interval: 1000, UpTo: 2000}, NewManifest(files, 1500, 1000, 2999))
+	assert.Nil(t, NewManifest(files, 1500, 1000, 1999))
+	assert.Equal(t, &Manifest{Interval: 500, UpTo: 2000}, NewManifest(files, 1500, 500, 10000), "no full KV at 2500")
+
+	m := &Manifest{Interval: 1000, UpTo: 3000}
+	assert.Equal(t, "[1500, 2000),[1500, 3000)", FileInfos(m.Files("A", 1500, 10000)).String())
+	assert.Equal(t, "[1500, 2000)", FileInfos(m.Files("A", 1500, 2500)).String())
+}
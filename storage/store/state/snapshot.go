@@ -8,10 +8,14 @@ import (
 	"github.com/streamingfast/substreams/storage/store"
 )
 
-func listSnapshots(ctx context.Context, storeConfig *store.Config, below uint64) (*storeSnapshots, error) {
+func listSnapshots(ctx context.Context, storeConfig *store.Config, below, interval uint64, readOnly bool) (*storeSnapshots, error) {
 	out := &storeSnapshots{}
 
-	files, err := storeConfig.ListSnapshotFiles(ctx, below)
+	list := storeConfig.RefreshManifest
+	if readOnly {
+		list = storeConfig.ListUsableSnapshotFiles
+	}
+	files, err := list(ctx, below, interval)
 	if err != nil {
 		return nil, fmt.Errorf("list snapshots: %w", err)
 	}
@@ -23,7 +23,9 @@ func (s *storeSnapshotsMap) String() string {
 	return strings.Join(out, ", ")
 }
 
-func FetchState(ctx context.Context, storeConfigMap store.ConfigMap, below uint64) (*storeSnapshotsMap, error) {
+// FetchState lists the snapshots of the stores usable by a request segmented on `interval`,
+// refreshing their manifest unless `readOnly`.
+func FetchState(ctx context.Context, storeConfigMap store.ConfigMap, below, interval uint64, readOnly bool) (*storeSnapshotsMap, error) {
 	state := &storeSnapshotsMap{
 		Snapshots: map[string]*storeSnapshots{},
 	}
@@ -39,7 +41,7 @@ func FetchState(ctx context.Context, storeConfigMap store.ConfigMap, below uint6
 		storeConfig := config
 
 		eg.Go(func() error {
-			snapshots, err := listSnapshots(ctx, storeConfig, below)
+			snapshots, err := listSnapshots(ctx, storeConfig, below, interval, readOnly)
 			if err != nil {
 				return err
 			}
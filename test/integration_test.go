@@ -204,6 +204,7 @@ func TestOneStoreOneMap(t *testing.T) {
 				"ebd5bb65aaf4471e468efea126f27dbddb37b59e/states/0000000010-0000000001.kv", // store states
 				"ebd5bb65aaf4471e468efea126f27dbddb37b59e/states/0000000020-0000000001.kv",
 				//				"states/0000000025-0000000020.partial", // produced, then deleted
+				"ebd5bb65aaf4471e468efea126f27dbddb37b59e/states/0000000025-0000000001.kv", // checkpoint at the linear handoff
 			},
 		},
 		{
@@ -219,6 +220,7 @@ func TestOneStoreOneMap(t *testing.T) {
 				"ebd5bb65aaf4471e468efea126f27dbddb37b59e/states/0000000010-0000000001.kv", // store states
 				"ebd5bb65aaf4471e468efea126f27dbddb37b59e/states/0000000020-0000000001.kv",
 				// "states/0000000025-0000000020.partial", // produced, then deleted
+				"ebd5bb65aaf4471e468efea126f27dbddb37b59e/states/0000000025-0000000001.kv", // checkpoint at the linear handoff
 				//"states/0000000030-0000000001.kv", // Again, backprocess wouldn't save this one, nor does it need to.
 			},
 		},
@@ -235,6 +237,7 @@ func TestOneStoreOneMap(t *testing.T) {
 				"ebd5bb65aaf4471e468efea126f27dbddb37b59e/outputs/0000000010-0000000020.output",
 				"ebd5bb65aaf4471e468efea126f27dbddb37b59e/states/0000000010-0000000001.kv",
 				"ebd5bb65aaf4471e468efea126f27dbddb37b59e/states/0000000020-0000000001.kv",
+				"ebd5bb65aaf4471e468efea126f27dbddb37b59e/states/0000000027-0000000001.kv", // checkpoint at the linear handoff
 			},
 		},
 		{
@@ -279,6 +282,7 @@ func TestOneStoreOneMap(t *testing.T) {
 			expectedResponseCount: 2,
 			expectFiles: []string{
 				"3574de26d590713344b911bbc1c3bf3305ccb906/outputs/0000000001-0000000008.output",
+				"ebd5bb65aaf4471e468efea126f27dbddb37b59e/states/0000000008-0000000001.kv", // checkpoint at the linear handoff
 			},
 		},
 		{
@@ -291,6 +295,7 @@ func TestOneStoreOneMap(t *testing.T) {
 			expectFiles: []string{
 				//"states/0000000010-0000000001.kv", // TODO: not sure why this would have been produced with the prior code..
 				"3574de26d590713344b911bbc1c3bf3305ccb906/outputs/0000000001-0000000008.output",
+				"ebd5bb65aaf4471e468efea126f27dbddb37b59e/states/0000000008-0000000001.kv", // checkpoint at the linear handoff
 			},
 		},
 		{
@@ -336,7 +341,18 @@ func TestOneStoreOneMap(t *testing.T) {
 				return res
 			}
 
-			assertFiles(t, run.TempDir, withZST(test.expectFiles)...)
+			// each output file is saved with the checksum of its outputs
+			withChecksums := func(s []string) []string {
+				res := append([]string(nil), s...)
+				for _, v := range s {
+					if dir, filename, found := strings.Cut(v, "/outputs/"); found {
+						res = append(res, dir+"/checksums/"+strings.TrimSuffix(filename, ".output")+".checksum")
+					}
+				}
+				return res
+			}
+
+			assertFiles(t, run.TempDir, withZST(withChecksums(test.expectFiles))...)
 		})
 	}
 }
@@ -32,6 +32,7 @@ import (
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/service"
 	"github.com/streamingfast/substreams/service/config"
+	"github.com/streamingfast/substreams/wasm"
 )
 
 type testPreWork func(t *testing.T, run *testRun, workerFactory work.WorkerFactory)
@@ -258,6 +259,7 @@ func processInternalRequest(
 		BaseObjectStore:            baseStoreStore,
 		DefaultCacheTag:            "tag",
 		WorkerFactory:              workerFactory,
+		WASMRuntime:                wasm.DefaultRuntime,
 	}
 	svc := service.TestNewServiceTier2(runtimeConfig, tr.StreamFactory)
 
@@ -299,6 +301,7 @@ func processRequest(
 		DefaultCacheTag:            "tag",
 		WorkerFactory:              workerFactory,
 		MaxJobsAhead:               10,
+		WASMRuntime:                wasm.DefaultRuntime,
 	}
 
 	svc := service.TestNewService(runtimeConfig, linearHandoffBlockNum, tr.StreamFactory)
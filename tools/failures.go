@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/dstore"
+
+	"github.com/streamingfast/substreams/storage/failure"
+)
+
+var failuresCmd = &cobra.Command{
+	Use:   "failures",
+	Short: "Manages the deterministic module failures recorded by tier1 in a state store",
+	Long: `Manages the deterministic module failures recorded by tier1 in a state store.
+
+The <cache_store_url> is the state store of tier1 followed by the cache tag, for example
+'gs://my-bucket/substreams-states/v1'.`,
+	SilenceUsage: true,
+}
+
+var failuresListCmd = &cobra.Command{
+	Use:   "list <cache_store_url>",
+	Short: "Lists the recorded module failures, requests hitting them fail fast",
+	Args:  cobra.ExactArgs(1),
+	RunE:  failuresListE,
+}
+
+var failuresClearCmd = &cobra.Command{
+	Use:   "clear <cache_store_url> [<module_hash>...]",
+	Short: "Clears the recorded failures of the given modules, so requests are processed again",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  failuresClearE,
+}
+
+func init() {
+	failuresClearCmd.Flags().Bool("all", false, "Clear the failures of all the modules")
+
+	failuresCmd.AddCommand(failuresListCmd)
+	failuresCmd.AddCommand(failuresClearCmd)
+	Cmd.AddCommand(failuresCmd)
+}
+
+func newFailureRecords(cacheStoreURL string) (*failure.Records, error) {
+	cacheStore, err := dstore.NewStore(cacheStoreURL, "zst", "zstd", false)
+	if err != nil {
+		return nil, fmt.Errorf("could not create store from %s: %w", cacheStoreURL, err)
+	}
+	return failure.NewRecords(cacheStore, nil)
+}
+
+func failuresListE(cmd *cobra.Command, args []string) error {
+	records, err := newFailureRecords(args[0])
+	if err != nil {
+		return err
+	}
+
+	list, err := records.List(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Println("No recorded failures")
+		return nil
+	}
+	for _, record := range list {
+		fmt.Printf("%s  %-30s  block %-12d  %s  %s\n", record.ModuleHash, record.ModuleName, record.Block, record.At.Format("2006-01-02 15:04:05"), record.Runtime)
+		fmt.Printf("    %s\n", record.Error)
+	}
+	return nil
+}
+
+func failuresClearE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return err
+	}
+
+	moduleHashes := args[1:]
+	if all == (len(moduleHashes) > 0) {
+		return fmt.Errorf("specify either module hashes or --all")
+	}
+
+	records, err := newFailureRecords(args[0])
+	if err != nil {
+		return err
+	}
+
+	if all {
+		list, err := records.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, record := range list {
+			moduleHashes = append(moduleHashes, record.ModuleHash)
+		}
+	}
+
+	for _, hash := range moduleHashes {
+		if err := records.Clear(ctx, hash); err != nil {
+			return fmt.Errorf("clearing failure of module %s: %w", hash, err)
+		}
+		fmt.Printf("Cleared failure of module %s\n", hash)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/cli"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/streamingfast/substreams/client"
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <manifest_url> <module> <endpoint_a> <endpoint_b>",
+	Short: "Compares the checksums of the cached outputs of a module on two endpoints",
+	Long: cli.Dedent(`
+		Compares, segment by segment, the checksums of the outputs of a module cached by two endpoints
+		serving the same package. Only the segments already processed by both endpoints are compared.
+		Exits with an error if any checksum differs.
+	`),
+	Example: ExamplePrefixed("substreams tools verify", `
+		./substreams.yaml map_transfers mainnet.eth.streamingfast.io:443 eth.other-provider.io:443 --start-block 17000000 --stop-block 17100000
+	`),
+	Args:         cobra.ExactArgs(4),
+	RunE:         verifyE,
+	SilenceUsage: true,
+}
+
+func init() {
+	verifyCmd.Flags().String("substreams-api-token-envvar", "SUBSTREAMS_API_TOKEN", "name of variable containing Substreams Authentication token")
+	verifyCmd.Flags().String("substreams-api-key-envvar", "SUBSTREAMS_API_KEY", "Name of variable containing Substreams Api Key")
+	verifyCmd.Flags().Bool("insecure", false, "Skip certificate validation on GRPC connection")
+	verifyCmd.Flags().Bool("plaintext", false, "Establish GRPC connection in plaintext")
+	verifyCmd.Flags().StringArrayP("params", "p", nil, "Set a params for parameterizable modules. Can be specified multiple times. Ex: -p module1=valA -p module2=valX&valY")
+	verifyCmd.Flags().Uint64("start-block", 0, "Start block of the range to verify, defaults to the module's initial block")
+	verifyCmd.Flags().Uint64("stop-block", 0, "Exclusive stop block of the range to verify")
+	verifyCmd.MarkFlagRequired("stop-block")
+
+	Cmd.AddCommand(verifyCmd)
+}
+
+func verifyE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	manifestPath := args[0]
+	moduleName := args[1]
+
+	manifestReader, err := manifest.NewReader(manifestPath)
+	if err != nil {
+		return fmt.Errorf("manifest reader: %w", err)
+	}
+
+	pkg, _, err := manifestReader.Read()
+	if err != nil {
+		return fmt.Errorf("read manifest %q: %w", manifestPath, err)
+	}
+
+	params, err := manifest.ParseParams(mustGetStringArray(cmd, "params"))
+	if err != nil {
+		return fmt.Errorf("parsing params: %w", err)
+	}
+
+	if err := manifest.ApplyParams(params, pkg); err != nil {
+		return fmt.Errorf("apply params: %w", err)
+	}
+
+	startBlock := mustGetUint64(cmd, "start-block")
+	if startBlock == 0 {
+		for _, mod := range pkg.Modules.Modules {
+			if mod.Name == moduleName {
+				startBlock = mod.InitialBlock
+			}
+		}
+	}
+	req := &pbsubstreamsrpc.OutputChecksumsRequest{
+		Modules:       pkg.Modules,
+		Module:        moduleName,
+		StartBlockNum: startBlock,
+		StopBlockNum:  mustGetUint64(cmd, "stop-block"),
+	}
+
+	var checksums [2]*pbsubstreamsrpc.OutputChecksumsResponse
+	for i, endpoint := range args[2:] {
+		checksums[i], err = fetchOutputChecksums(ctx, cmd, endpoint, req)
+		if err != nil {
+			return fmt.Errorf("endpoint %s: %w", endpoint, err)
+		}
+	}
+	if checksums[0].ModuleHash != checksums[1].ModuleHash {
+		return fmt.Errorf("module hashes differ: %s on %s, %s on %s", checksums[0].ModuleHash, args[2], checksums[1].ModuleHash, args[3])
+	}
+
+	fmt.Printf("Module %s (hash %s)\n", moduleName, checksums[0].ModuleHash)
+	compared, mismatches := compareOutputChecksums(checksums[0].Segments, checksums[1].Segments)
+	for _, mismatch := range mismatches {
+		fmt.Printf("  [%d, %d): %s != %s\n", mismatch[0].StartBlock, mismatch[0].EndBlock, mismatch[0].Checksum, mismatch[1].Checksum)
+	}
+	fmt.Printf("%d segment(s) compared, %d only on %s, %d only on %s, %d mismatch(es)\n",
+		compared, len(checksums[0].Segments)-compared, args[2], len(checksums[1].Segments)-compared, args[3], len(mismatches))
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("outputs differ on %d segment(s)", len(mismatches))
+	}
+	return nil
+}
+
+func fetchOutputChecksums(ctx context.Context, cmd *cobra.Command, endpoint string, req *pbsubstreamsrpc.OutputChecksumsRequest) (*pbsubstreamsrpc.OutputChecksumsResponse, error) {
+	authToken, authType := GetAuth(cmd, "substreams-api-key-envvar", "substreams-api-token-envvar")
+	clientConfig := client.NewSubstreamsClientConfig(
+		endpoint,
+		authToken,
+		authType,
+		mustGetBool(cmd, "insecure"),
+		mustGetBool(cmd, "plaintext"),
+	)
+	checksumsClient, connClose, callOpts, headers, err := client.NewChecksumsClient(clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("substreams client setup: %w", err)
+	}
+	defer connClose()
+
+	if headers.IsSet() {
+		ctx = metadata.AppendToOutgoingContext(ctx, headers.ToArray()...)
+	}
+	resp, err := checksumsClient.OutputChecksums(ctx, req, callOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("call sf.substreams.rpc.v2.Checksums/OutputChecksums: %w", err)
+	}
+	return resp, nil
+}
+
+// compareOutputChecksums compares the segments present in both `a` and `b`, both in block order.
+func compareOutputChecksums(a, b []*pbsubstreamsrpc.SegmentChecksum) (compared int, mismatches [][2]*pbsubstreamsrpc.SegmentChecksum) {
+	type segment struct{ start, end uint64 }
+	inB := make(map[segment]*pbsubstreamsrpc.SegmentChecksum, len(b))
+	for _, s := range b {
+		inB[segment{s.StartBlock, s.EndBlock}] = s
+	}
+	for _, s := range a {
+		other, found := inB[segment{s.StartBlock, s.EndBlock}]
+		if !found {
+			continue
+		}
+		compared++
+		if s.Checksum != other.Checksum {
+			mismatches = append(mismatches, [2]*pbsubstreamsrpc.SegmentChecksum{s, other})
+		}
+	}
+	return
+}
@@ -25,13 +25,14 @@ func (ui *TUI) decoratedBlockScopedData(
 	var s []string
 
 	for _, out := range append([]*pbsubstreamsrpc.MapModuleOutput{output}, debugMapOutputs...) {
+		if out == nil {
+			continue
+		}
 		if _, ok := ui.msgTypes[out.Name]; !ok {
 			continue
 		}
-		if out.DebugInfo != nil {
-			for _, log := range out.DebugInfo.Logs {
-				s = append(s, fmt.Sprintf("%s: log: %s\n", out.Name, log))
-			}
+		for _, entry := range out.DebugInfo.LogEntries(ui.minLogLevel) {
+			s = append(s, fmt.Sprintf("%s: log: %s\n", out.Name, entry.Render()))
 		}
 
 		if len(out.MapOutput.Value) != 0 {
@@ -50,8 +51,8 @@ func (ui *TUI) decoratedBlockScopedData(
 		if _, ok := ui.msgTypes[out.Name]; !ok {
 			continue
 		}
-		for _, log := range out.DebugInfo.Logs {
-			s = append(s, fmt.Sprintf("%s: log: %s\n", out.Name, log))
+		for _, entry := range out.DebugInfo.LogEntries(ui.minLogLevel) {
+			s = append(s, fmt.Sprintf("%s: log: %s\n", out.Name, entry.Render()))
 		}
 
 		if len(out.DebugStoreDeltas) != 0 {
@@ -121,6 +122,35 @@ func (ui *TUI) printJSONBlockDeltas(modName string, blockNum uint64, deltas []*p
 	return nil
 }
 
+// printJSONLogs only prints when a minimum log level was requested, so
+// the JSON output of existing consumers is unchanged by default.
+func (ui *TUI) printJSONLogs(modName string, blockNum uint64, debugInfo *pbsubstreamsrpc.OutputDebugInfo) error {
+	if ui.minLogLevel == pbsubstreamsrpc.LogLevel_LOG_LEVEL_UNSPECIFIED {
+		return nil
+	}
+
+	for _, entry := range debugInfo.LogEntries(ui.minLogLevel) {
+		wrap := LogWrap{
+			Module:   modName,
+			BlockNum: blockNum,
+			Level:    entry.Level.ShortName(),
+			Message:  entry.Message,
+		}
+		if len(entry.Fields) != 0 {
+			wrap.Fields = make(map[string]string, len(entry.Fields))
+			for _, field := range entry.Fields {
+				wrap.Fields[field.Key] = field.Value
+			}
+		}
+		cnt, err := json.Marshal(wrap)
+		if err != nil {
+			return fmt.Errorf("marshal wrap: %w", err)
+		}
+		fmt.Println(string(ui.prettyFormat(cnt, false)))
+	}
+	return nil
+}
+
 func indent(in []byte) []byte {
 	return bytes.Replace(in, []byte("\n"), []byte("\n    "), -1)
 }
@@ -133,9 +163,15 @@ func (ui *TUI) jsonBlockScopedData(
 ) error {
 
 	for _, out := range append([]*pbsubstreamsrpc.MapModuleOutput{output}, debugMapOutputs...) {
+		if out == nil {
+			continue
+		}
 		if _, ok := ui.msgTypes[out.Name]; !ok {
 			continue
 		}
+		if err := ui.printJSONLogs(out.Name, clock.Number, out.DebugInfo); err != nil {
+			return fmt.Errorf("print json logs: %w", err)
+		}
 
 		if len(out.MapOutput.Value) != 0 {
 			msgDesc := ui.msgDescs[out.Name]
@@ -153,6 +189,9 @@ func (ui *TUI) jsonBlockScopedData(
 		if _, ok := ui.msgTypes[out.Name]; !ok {
 			continue
 		}
+		if err := ui.printJSONLogs(out.Name, clock.Number, out.DebugInfo); err != nil {
+			return fmt.Errorf("print json logs: %w", err)
+		}
 		if len(out.DebugStoreDeltas) != 0 {
 			if out.DebugInfo != nil && out.DebugInfo.Cached {
 				fmt.Println(cachedValues(out.Name))
@@ -332,6 +371,14 @@ type DeltaWrap struct {
 	NewValue  json.RawMessage `json:"new"`
 }
 
+type LogWrap struct {
+	Module   string            `json:"@module"`
+	BlockNum uint64            `json:"@block"`
+	Level    string            `json:"@level"`
+	Message  string            `json:"@log"`
+	Fields   map[string]string `json:"@fields,omitempty"`
+}
+
 type UnknownWrap struct {
 	Module      string `json:"@module"`
 	UnknownType string `json:"@unknown"`
@@ -360,14 +407,41 @@ func printClock(block *pbsubstreamsrpc.BlockScopedData) {
 	fmt.Printf("----------- BLOCK #%s (%s) ---------------\n", humanize.Comma(int64(block.Clock.Number)), block.Clock.Id)
 }
 
-func printUndo(lastGoodClock *pbsubstreams.BlockRef, cursor string) {
+func printUndo(lastGoodClock *pbsubstreams.BlockRef, cursor string, reverted *pbsubstreamsrpc.RevertedBlock) {
 	fmt.Printf("----------- BLOCK UNDO UP TO #%s (0x%s) ---------------\n", humanize.Comma(int64(lastGoodClock.Number)), lastGoodClock.Id)
+	if reverted != nil {
+		fmt.Printf("\nReverted block: #%s (0x%s), %d output(s)\n", humanize.Comma(int64(reverted.Clock.Number)), reverted.Clock.Id, revertedOutputsCount(reverted))
+	}
 	fmt.Printf("\nNext cursor: %s\n", cursor)
 }
-func printUndoJSON(lastGoodClock *pbsubstreams.BlockRef, cursor string) {
-	fmt.Printf(formatUndoJSON(lastGoodClock, cursor) + "\n")
+func printUndoJSON(lastGoodClock *pbsubstreams.BlockRef, cursor string, reverted *pbsubstreamsrpc.RevertedBlock) {
+	fmt.Printf(formatUndoJSON(lastGoodClock, cursor, reverted) + "\n")
+}
+
+func revertedOutputsCount(reverted *pbsubstreamsrpc.RevertedBlock) int {
+	count := len(reverted.AdditionalOutputs)
+	if reverted.Output != nil {
+		count++
+	}
+	return count
 }
 
-func formatUndoJSON(lastGoodClock *pbsubstreams.BlockRef, cursor string) string {
+func printHeartbeat(heartbeat *pbsubstreamsrpc.Heartbeat) {
+	fmt.Printf("----------- HEARTBEAT #%s (%s) ---------------\n", humanize.Comma(int64(heartbeat.Clock.Number)), heartbeat.Clock.Id)
+	fmt.Printf("\nNext cursor: %s\n", heartbeat.Cursor)
+}
+
+func printHeartbeatJSON(heartbeat *pbsubstreamsrpc.Heartbeat) {
+	fmt.Println(formatHeartbeatJSON(heartbeat))
+}
+
+func formatHeartbeatJSON(heartbeat *pbsubstreamsrpc.Heartbeat) string {
+	return fmt.Sprintf("{\"heartbeat\":{\"num\":%d,\"id\":\"%s\",\"next_cursor\":\"%s\"}}", heartbeat.Clock.Number, heartbeat.Clock.Id, heartbeat.Cursor)
+}
+
+func formatUndoJSON(lastGoodClock *pbsubstreams.BlockRef, cursor string, reverted *pbsubstreamsrpc.RevertedBlock) string {
+	if reverted != nil {
+		return fmt.Sprintf("{\"undo_until\":{\"num\":%d,\"id\":\"%s\",\"next_cursor\":\"%s\",\"reverted\":{\"num\":%d,\"id\":\"%s\",\"outputs\":%d}}}", lastGoodClock.Number, lastGoodClock.Id, cursor, reverted.Clock.Number, reverted.Clock.Id, revertedOutputsCount(reverted))
+	}
 	return fmt.Sprintf("{\"undo_until\":{\"num\":%d,\"id\":\"%s\",\"next_cursor\":\"%s\"}}", lastGoodClock.Number, lastGoodClock.Id, cursor)
 }
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -12,6 +13,27 @@ func Test_printUndoJSON(t *testing.T) {
 		formatUndoJSON(&pbsubstreams.BlockRef{
 			Id:     "1",
 			Number: 1,
-		}, "aa"),
+		}, "aa", nil),
+	)
+	assert.Equal(t,
+		`{"undo_until":{"num":1,"id":"1","next_cursor":"aa","reverted":{"num":2,"id":"2b","outputs":2}}}`,
+		formatUndoJSON(&pbsubstreams.BlockRef{
+			Id:     "1",
+			Number: 1,
+		}, "aa", &pbsubstreamsrpc.RevertedBlock{
+			Clock:             &pbsubstreams.Clock{Id: "2b", Number: 2},
+			Output:            &pbsubstreamsrpc.MapModuleOutput{Name: "map_a"},
+			AdditionalOutputs: []*pbsubstreamsrpc.MapModuleOutput{{Name: "map_b"}},
+		}),
+	)
+}
+
+func Test_printHeartbeatJSON(t *testing.T) {
+	assert.Equal(t,
+		`{"heartbeat":{"num":1,"id":"1","next_cursor":"aa"}}`,
+		formatHeartbeatJSON(&pbsubstreamsrpc.Heartbeat{
+			Clock:  &pbsubstreams.Clock{Id: "1", Number: 1},
+			Cursor: "aa",
+		}),
 	)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -33,6 +34,7 @@ type TUI struct {
 	isTerminal        bool
 	outputMode        OutputMode
 	prettyPrintOutput bool
+	minLogLevel       pbsubstreamsrpc.LogLevel
 
 	prog           *tea.Program
 	seenFirstData  bool
@@ -99,6 +101,12 @@ func (ui *TUI) Init(outputMode string) error {
 	return nil
 }
 
+// SetMinLogLevel filters out module logs below `level`. In JSON output modes,
+// module logs are only printed once a minimum level has been set.
+func (ui *TUI) SetMinLogLevel(level pbsubstreamsrpc.LogLevel) {
+	ui.minLogLevel = level
+}
+
 func (ui *TUI) configureOutputMode(outputMode string) error {
 	ui.isTerminal = isatty.IsTerminal(os.Stdout.Fd())
 
@@ -145,32 +153,33 @@ func (ui *TUI) IncomingMessage(ctx context.Context, resp *pbsubstreamsrpc.Respon
 	switch m := resp.Message.(type) {
 	case *pbsubstreamsrpc.Response_BlockUndoSignal:
 		if ui.outputMode == OutputModeTUI {
-			printUndo(m.BlockUndoSignal.LastValidBlock, m.BlockUndoSignal.LastValidCursor)
+			printUndo(m.BlockUndoSignal.LastValidBlock, m.BlockUndoSignal.LastValidCursor, m.BlockUndoSignal.RevertedBlock)
 			ui.ensureTerminalUnlocked()
 		} else {
-			printUndoJSON(m.BlockUndoSignal.LastValidBlock, m.BlockUndoSignal.LastValidCursor)
-		}
-
-	case *pbsubstreamsrpc.Response_BlockScopedData:
-		if testRunner != nil {
-			if err := testRunner.Test(ctx, m.BlockScopedData.Output, m.BlockScopedData.DebugMapOutputs, m.BlockScopedData.DebugStoreOutputs, m.BlockScopedData.Clock); err != nil {
-				fmt.Errorf("test runner failed: %w", err)
-			}
+			printUndoJSON(m.BlockUndoSignal.LastValidBlock, m.BlockUndoSignal.LastValidCursor, m.BlockUndoSignal.RevertedBlock)
 		}
 
+	case *pbsubstreamsrpc.Response_Heartbeat:
 		if ui.outputMode == OutputModeTUI {
-			printClock(m.BlockScopedData)
-		}
-		if m.BlockScopedData == nil {
-			return nil
-		}
-		ui.seenFirstData = true
-		if ui.outputMode == OutputModeTUI {
+			printHeartbeat(m.Heartbeat)
 			ui.ensureTerminalUnlocked()
-			return ui.decoratedBlockScopedData(m.BlockScopedData.Output, m.BlockScopedData.DebugMapOutputs, m.BlockScopedData.DebugStoreOutputs, m.BlockScopedData.Clock)
 		} else {
-			return ui.jsonBlockScopedData(m.BlockScopedData.Output, m.BlockScopedData.DebugMapOutputs, m.BlockScopedData.DebugStoreOutputs, m.BlockScopedData.Clock)
+			printHeartbeatJSON(m.Heartbeat)
 		}
+
+	case *pbsubstreamsrpc.Response_BlockScopedData:
+		return ui.blockScopedData(ctx, m.BlockScopedData, testRunner)
+
+	case *pbsubstreamsrpc.Response_BlockScopedDatas:
+		for _, data := range m.BlockScopedDatas.Items {
+			if err := ui.blockScopedData(ctx, data, testRunner); err != nil {
+				return err
+			}
+		}
+
+	case *pbsubstreamsrpc.Response_OutputDictionary:
+		// consumed by the client decoding the outputs, nothing to print
+
 	case *pbsubstreamsrpc.Response_Progress:
 		if m.Progress.ProcessedBytes != nil {
 			ui.TotalReadBytes = m.Progress.ProcessedBytes.TotalBytesRead
@@ -195,6 +204,9 @@ func (ui *TUI) IncomingMessage(ctx context.Context, resp *pbsubstreamsrpc.Respon
 			fmt.Println("Snapshot data dump complete")
 		}
 
+	case *pbsubstreamsrpc.Response_DebugModuleProfile:
+		return ui.writeModuleProfile(m.DebugModuleProfile)
+
 	case *pbsubstreamsrpc.Response_Session:
 		if ui.outputMode == OutputModeTUI {
 			ui.ensureTerminalLocked()
@@ -209,6 +221,59 @@ func (ui *TUI) IncomingMessage(ctx context.Context, resp *pbsubstreamsrpc.Respon
 	return nil
 }
 
+func (ui *TUI) blockScopedData(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData, testRunner *test.Runner) error {
+	if testRunner != nil {
+		if err := testRunner.Test(ctx, data.Output, data.DebugMapOutputs, data.DebugStoreOutputs, data.Clock); err != nil {
+			fmt.Errorf("test runner failed: %w", err)
+		}
+	}
+
+	if ui.outputMode == OutputModeTUI {
+		printClock(data)
+	}
+	if data == nil {
+		return nil
+	}
+	ui.seenFirstData = true
+
+	// a store output module's deltas are printed like the debug store outputs
+	output, storeOutputs := data.Output, data.DebugStoreOutputs
+	if output.IsStoreDeltas() {
+		storeOutput, err := output.ToStoreModuleOutput()
+		if err != nil {
+			return fmt.Errorf("decoding output: %w", err)
+		}
+		output, storeOutputs = nil, append([]*pbsubstreamsrpc.StoreModuleOutput{storeOutput}, storeOutputs...)
+	}
+
+	if ui.outputMode == OutputModeTUI {
+		ui.ensureTerminalUnlocked()
+		return ui.decoratedBlockScopedData(output, append(data.AdditionalOutputs, data.DebugMapOutputs...), storeOutputs, data.Clock)
+	} else {
+		return ui.jsonBlockScopedData(output, append(data.AdditionalOutputs, data.DebugMapOutputs...), storeOutputs, data.Clock)
+	}
+}
+
+// writeModuleProfile saves the profile of a module to `<module>.pprof` in the current directory.
+// The module name comes from the server, names that would escape the current directory
+// are rejected.
+func (ui *TUI) writeModuleProfile(profile *pbsubstreamsrpc.ModuleProfile) error {
+	name := profile.ModuleName
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") || filepath.Base(name) != name {
+		return fmt.Errorf("invalid profiled module name %q", name)
+	}
+	filename := filepath.Base(name) + ".pprof"
+	if err := os.WriteFile(filename, profile.Pprof, 0644); err != nil {
+		return fmt.Errorf("writing profile of module %q: %w", profile.ModuleName, err)
+	}
+
+	if ui.outputMode == OutputModeTUI {
+		ui.ensureTerminalUnlocked()
+	}
+	fmt.Printf("Profile of module %q written to %s, inspect it with 'go tool pprof %s'\n", profile.ModuleName, filename, filename)
+	return nil
+}
+
 func (ui *TUI) ensureTerminalUnlocked() {
 	if ui.prog == nil {
 		return
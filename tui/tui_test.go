@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+)
+
+func TestTUI_writeModuleProfile(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	ui := &TUI{outputMode: OutputModeJSON}
+	for _, name := range []string{"", "..", "../map_a", "/tmp/map_a", `sub\map_a`, "sub/map_a"} {
+		assert.Error(t, ui.writeModuleProfile(&pbsubstreamsrpc.ModuleProfile{ModuleName: name, Pprof: []byte("pprof")}), name)
+	}
+
+	require.NoError(t, ui.writeModuleProfile(&pbsubstreamsrpc.ModuleProfile{ModuleName: "map_a", Pprof: []byte("pprof")}))
+	content, err := os.ReadFile("map_a.pprof")
+	require.NoError(t, err)
+	assert.Equal(t, "pprof", string(content))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -13,6 +13,7 @@ var UpDown = key.NewBinding(key.WithHelp("↑/↓/k/j", "up/down"), k)
 var LeftRight = key.NewBinding(key.WithHelp("←/→/h/l", "left/right"), k)
 var UpDownPage = key.NewBinding(key.WithHelp("pgup/pgdn", "up/down page"), k)
 var ToggleLogs = key.NewBinding(key.WithHelp("L", "toggle logs"), k)
+var CycleLogLevel = key.NewBinding(key.WithHelp("V", "cycle min. log level"), k)
 var ToggleBytesFormat = key.NewBinding(key.WithHelp("F", "bytes format"), k)
 var Help = key.NewBinding(key.WithHelp("?", "toggle help"), k)
 var PrevNextSearchResult = key.NewBinding(key.WithHelp("n/N", "prev/next search match"), k)
@@ -51,6 +51,7 @@ type Output struct {
 	//moduleSearchView
 	outputModule string
 	logsEnabled  bool
+	minLogLevel  pbsubstreamsrpc.LogLevel
 
 	searchEnabled                   bool
 	searchCtx                       *search.Search
@@ -89,6 +90,7 @@ func New(c common.Common, config *request.Config) (*Output, error) {
 		moduleSearchView:    modsearch.New(c),
 		outputModule:        config.OutputModule,
 		logsEnabled:         true,
+		minLogLevel:         config.MinLogLevel,
 		moduleNavigator:     nav,
 		firstBlockSeen:      true,
 	}
@@ -225,6 +227,9 @@ func (o *Output) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "L":
 			o.logsEnabled = !o.logsEnabled
 			o.setOutputViewContent(true)
+		case "V":
+			o.minLogLevel = (o.minLogLevel + 1) % (pbsubstreamsrpc.LogLevel_LOG_LEVEL_ERROR + 1)
+			o.setOutputViewContent(true)
 		case "m":
 			o.moduleSearchEnabled = true
 			o.setOutputViewContent(true)
@@ -285,6 +290,7 @@ func (o *Output) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 type displayContext struct {
 	blockCtx          request.BlockContext
 	logsEnabled       bool
+	minLogLevel       pbsubstreamsrpc.LogLevel
 	searchViewEnabled bool
 	searchQuery       string
 	payload           *pbsubstreamsrpc.AnyModuleOutput
@@ -295,6 +301,7 @@ type displayContext struct {
 func (o *Output) setOutputViewContent(forcedRender bool) {
 	displayCtx := &displayContext{
 		logsEnabled:       o.logsEnabled,
+		minLogLevel:       o.minLogLevel,
 		blockCtx:          o.active,
 		searchViewEnabled: o.searchEnabled,
 		searchQuery:       o.searchCtx.Current.Query,
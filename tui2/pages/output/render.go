@@ -78,7 +78,9 @@ func (o *Output) renderedOutput(in *pbsubstreamsrpc.AnyModuleOutput, withStyle b
 	if o.logsEnabled {
 		if debugInfo := in.DebugInfo(); debugInfo != nil {
 			var plainLogs []string
-			for _, log := range debugInfo.Logs {
+			entries := debugInfo.LogEntries(o.minLogLevel)
+			for _, entry := range entries {
+				log := entry.Render()
 				plainLogs = append(plainLogs, fmt.Sprintf("log: %s", log))
 				if withStyle {
 					out.styledLogs.WriteString(o.Styles.Output.LogLabel.Render("log: "))
@@ -86,7 +88,7 @@ func (o *Output) renderedOutput(in *pbsubstreamsrpc.AnyModuleOutput, withStyle b
 					out.styledLogs.WriteString("\n")
 				}
 			}
-			if withStyle && len(debugInfo.Logs) != 0 {
+			if withStyle && len(entries) != 0 {
 				out.styledLogs.WriteString("\n")
 			}
 			out.plainLogs = strings.Join(plainLogs, "\n")
@@ -46,6 +46,7 @@ type Config struct {
 	Cursor                      string
 	Params                      map[string]string
 	ReaderOptions               []manifest.Option
+	MinLogLevel                 pbsubstreamsrpc.LogLevel
 }
 
 type Instance struct {
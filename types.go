@@ -18,6 +18,18 @@ func NewBlockScopedDataResponse(in *pbsubstreamsrpc.BlockScopedData) *pbsubstrea
 	}
 }
 
+func NewBlockScopedDatasResponse(in *pbsubstreamsrpc.BlockScopedDatas) *pbsubstreamsrpc.Response {
+	return &pbsubstreamsrpc.Response{
+		Message: &pbsubstreamsrpc.Response_BlockScopedDatas{BlockScopedDatas: in},
+	}
+}
+
+func NewHeartbeatResponse(in *pbsubstreamsrpc.Heartbeat) *pbsubstreamsrpc.Response {
+	return &pbsubstreamsrpc.Response{
+		Message: &pbsubstreamsrpc.Response_Heartbeat{Heartbeat: in},
+	}
+}
+
 func NewSnapshotData(in *pbsubstreamsrpc.InitialSnapshotData) *pbsubstreamsrpc.Response {
 	return &pbsubstreamsrpc.Response{
 		Message: &pbsubstreamsrpc.Response_DebugSnapshotData{DebugSnapshotData: in},
@@ -25,6 +25,7 @@ func BenchmarkExecution(b *testing.B) {
 		name                string
 		code                []byte
 		shouldReUseInstance bool
+		withSnapshots       bool
 	}
 
 	type testCase struct {
@@ -45,18 +46,24 @@ func BenchmarkExecution(b *testing.B) {
 	} {
 		var reuseInstance = true
 		var freshInstanceEachRun = false
+		var withSnapshots = true
 
 		wasmCode := readCode(b, "substreams_wasm/substreams.wasm")
 
 		stats := metrics.NewReqStats(&metrics.Config{}, zap.NewNop())
 		for _, config := range []*runtime{
-			{"wasmtime", wasmCode, reuseInstance},
-			{"wasmtime", wasmCode, freshInstanceEachRun},
+			{"wasmtime", wasmCode, reuseInstance, false},
+			{"wasmtime", wasmCode, reuseInstance, withSnapshots},
+			{"wasmtime", wasmCode, freshInstanceEachRun, false},
 
-			{"wazero", wasmCode, reuseInstance},
-			{"wazero", wasmCode, freshInstanceEachRun},
+			{"wazero", wasmCode, reuseInstance, false},
+			{"wazero", wasmCode, reuseInstance, withSnapshots},
+			{"wazero", wasmCode, freshInstanceEachRun, false},
 		} {
 			instanceKey := "reused"
+			if config.withSnapshots {
+				instanceKey = "snapshot"
+			}
 			if !config.shouldReUseInstance {
 				instanceKey = "fresh"
 			}
@@ -65,6 +72,9 @@ func BenchmarkExecution(b *testing.B) {
 				ctx := context.Background()
 
 				wasmRuntime := wasm.NewRegistryWithRuntime(config.name, nil, 0)
+				if config.withSnapshots {
+					wasmRuntime.EnableInstanceSnapshots()
+				}
 
 				module, err := wasmRuntime.NewModule(ctx, config.code)
 				require.NoError(b, err)
@@ -85,10 +95,13 @@ func BenchmarkExecution(b *testing.B) {
 						require.NoError(b, err)
 					}
 
-					_, err := module.ExecuteNewCall(ctx, call, instance, testCase.arguments)
+					executed, err := module.ExecuteNewCall(ctx, call, instance, testCase.arguments)
 					if err != nil {
 						require.NoError(b, err)
 					}
+					if config.withSnapshots {
+						require.NoError(b, executed.Cleanup(ctx))
+					}
 
 					require.Contains(b, testCase.acceptedByteCount, len(call.Output()), "invalid byte count got %d expected one of %v", len(call.Output()), testCase.acceptedByteCount)
 				}
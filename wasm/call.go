@@ -24,10 +24,11 @@ type Call struct {
 
 	valueType string
 
-	returnValue []byte
-	panicError  *PanicError
+	returnValue  []byte
+	namedOutputs map[string][]byte
+	panicError   *PanicError
 
-	Logs           []string
+	Logs           []*LogEntry
 	LogsByteCount  uint64
 	ExecutionStack []string
 	stats          *metrics.Stats
@@ -90,19 +91,46 @@ func (c *Call) SetReturnValue(msg []byte) {
 	copy(c.returnValue, msg)
 }
 
+// NamedOutputs returns the values set through `output_named`, keyed by output name.
+func (c *Call) NamedOutputs() map[string][]byte {
+	return c.namedOutputs
+}
+
+// SetNamedReturnValue records the value of one of the named outputs of a
+// multi-output map module. Setting the same name twice keeps the last value.
+func (c *Call) SetNamedReturnValue(name string, msg []byte) {
+	if c.namedOutputs == nil {
+		c.namedOutputs = make(map[string][]byte)
+	}
+	value := make([]byte, len(msg))
+	copy(value, msg)
+	c.namedOutputs[name] = value
+}
+
 func (c *Call) SetPanicError(message string, filename string, lineNo int, colNo int) {
 	c.panicError = NewPanicError(message, filename, lineNo, colNo)
 }
 
 func (c *Call) AppendLog(message string) {
+	c.AppendLogEntry(LogLevelInfo, message, nil)
+}
+
+func (c *Call) AppendLogEntry(level LogLevel, message string, fields []LogField) {
+	if !level.IsValid() {
+		panic(fmt.Errorf("invalid log level %d", int32(level)))
+	}
+
+	entry := &LogEntry{Level: level, Message: message, Fields: fields}
+
 	// len(<string>) in Go count number of bytes and not characters, so we are good here
-	if len(message) > MaxLogByteCount {
-		panic(fmt.Errorf("message to log is too big, size is %s, max is %s", humanize.IBytes(uint64(len(message))), humanize.IBytes(uint64(MaxLogByteCount))))
+	size := entry.byteCount()
+	if size > MaxLogByteCount {
+		panic(fmt.Errorf("message to log is too big, size is %s, max is %s", humanize.IBytes(size), humanize.IBytes(uint64(MaxLogByteCount))))
 	}
-	c.LogsByteCount += uint64(len(message))
+	c.LogsByteCount += size
 	if !c.ReachedLogsMaxByteCount() {
-		c.Logs = append(c.Logs, message)
-		c.ExecutionStack = append(c.ExecutionStack, fmt.Sprintf("log: %s", message))
+		c.Logs = append(c.Logs, entry)
+		c.ExecutionStack = append(c.ExecutionStack, fmt.Sprintf("log: %s", entry.Message))
 	}
 }
 
@@ -0,0 +1,319 @@
+// Package conformance holds the behavior every wasm runtime must have to
+// execute Substreams modules. A runtime registered through
+// `wasm.RegisterModuleFactory` runs the suite from its own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, "my_runtime", "../bench")
+//	}
+package conformance
+
+import (
+	"context"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/metrics"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/storage/store"
+	"github.com/streamingfast/substreams/wasm"
+)
+
+//go:generate go run ./wat2wasm testdata/conformance.wat testdata/conformance.wasm
+
+//go:embed testdata/conformance.wasm
+var conformanceCode []byte
+
+var extensions = map[string]map[string]wasm.WASMExtension{
+	"test": {
+		"echo": func(ctx context.Context, requestID string, clock *pbsubstreams.Clock, in []byte) ([]byte, error) {
+			if string(in) == "fail" {
+				return nil, errors.New("upstream unavailable")
+			}
+			return append([]byte(fmt.Sprintf("%d:", clock.Number)), in...), nil
+		},
+	},
+}
+
+// Run executes the conformance suite against the runtime registered as
+// `runtimeName`. The host functions are exercised through a dedicated module,
+// while `benchDir`, the path to the `wasm/bench` folder, provides real
+// Substreams modules. Bench cases are skipped when `benchDir` is empty.
+func Run(t *testing.T, runtimeName string, benchDir string) {
+	t.Helper()
+	require.NoError(t, wasm.ValidateRuntime(runtimeName))
+
+	for _, mode := range []instanceMode{freshInstance, reusedInstance, snapshotInstance} {
+		t.Run(fmt.Sprintf("instance=%s", mode), func(t *testing.T) {
+			r := &runner{runtimeName: runtimeName, mode: mode}
+
+			t.Run("output", r.testOutput)
+			t.Run("isolation", r.testIsolation)
+			t.Run("globals_isolation", r.testGlobalsIsolation)
+			t.Run("memory_grow_isolation", r.testMemoryGrowIsolation)
+			t.Run("named_outputs", r.testNamedOutputs)
+			t.Run("state_set", r.testStateSet)
+			t.Run("state_add", r.testStateAdd)
+			t.Run("state_delete_prefix", r.testStateDeletePrefix)
+			t.Run("state_read", r.testStateRead)
+			t.Run("state_wrong_policy", r.testStateWrongPolicy)
+			t.Run("logs", r.testLogs)
+			t.Run("panic", r.testPanic)
+			t.Run("trap", r.testTrap)
+			t.Run("extension", r.testExtension)
+			t.Run("extension_failure", r.testExtensionFailure)
+
+			if benchDir != "" {
+				t.Run("bench", func(t *testing.T) { r.testBench(t, benchDir) })
+			}
+		})
+	}
+}
+
+type instanceMode string
+
+const (
+	freshInstance    instanceMode = "fresh"
+	reusedInstance   instanceMode = "reused"
+	snapshotInstance instanceMode = "snapshot"
+)
+
+type runner struct {
+	runtimeName string
+	mode        instanceMode
+}
+
+// execute runs `entrypoint` of `code` twice in a row, to catch state leaking
+// between calls when instances are reused, and returns the second call.
+func (r *runner) execute(t *testing.T, code []byte, entrypoint string, arguments ...wasm.Argument) (*wasm.Call, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	stats := metrics.NewReqStats(&metrics.Config{}, zap.NewNop())
+	ctx = reqctx.WithReqStats(ctx, stats)
+	ctx = reqctx.WithRequest(ctx, &reqctx.RequestDetails{UniqueID: 1})
+
+	registry := wasm.NewRegistryWithRuntime(r.runtimeName, extensions, 0)
+	if r.mode == snapshotInstance {
+		registry.EnableInstanceSnapshots()
+	}
+	module, err := registry.NewModule(ctx, code)
+	require.NoError(t, err)
+	defer module.Close(ctx)
+
+	var instance wasm.Instance
+	if r.mode != freshInstance {
+		instance, err = module.NewInstance(ctx)
+		require.NoError(t, err)
+		defer instance.Close(ctx)
+	}
+
+	var call *wasm.Call
+	for i := 0; i < 2; i++ {
+		call = wasm.NewCall(&pbsubstreams.Clock{Number: 42}, "conformance", entrypoint, stats, arguments)
+		executed, err := module.ExecuteNewCall(ctx, call, instance, arguments)
+		if err == nil {
+			err = call.Err()
+		}
+		if err != nil {
+			return call, err
+		}
+
+		if r.mode != freshInstance {
+			require.NoError(t, executed.Cleanup(ctx))
+		} else {
+			require.NoError(t, executed.Close(ctx))
+		}
+	}
+	return call, nil
+}
+
+func (r *runner) testOutput(t *testing.T) {
+	call, err := r.execute(t, conformanceCode, "map_echo", params("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), call.Output())
+
+	call, err = r.execute(t, conformanceCode, "map_echo", params(""))
+	require.NoError(t, err)
+	assert.Empty(t, call.Output())
+}
+
+func (r *runner) testIsolation(t *testing.T) {
+	call, err := r.execute(t, conformanceCode, "map_counter", params(""))
+	require.NoError(t, err)
+
+	if r.mode == reusedInstance {
+		// Plain instance reuse leaks memory across calls, which is why it is not deterministic.
+		assert.Equal(t, []byte{2, 0, 0, 0}, call.Output())
+		return
+	}
+	assert.Equal(t, []byte{1, 0, 0, 0}, call.Output())
+}
+
+func (r *runner) testGlobalsIsolation(t *testing.T) {
+	call, err := r.execute(t, conformanceCode, "map_globals", params("abcd"))
+	require.NoError(t, err)
+
+	stackPointer, heap := binary.LittleEndian.Uint32(call.Output()), binary.LittleEndian.Uint32(call.Output()[4:])
+	if r.mode == reusedInstance {
+		assert.Equal(t, uint32(65536-16), stackPointer, "plain instance reuse leaks the stack pointer")
+		assert.Equal(t, uint32(4096+4+4), heap, "plain instance reuse leaks the heap pointer")
+		return
+	}
+	assert.Equal(t, uint32(65536), stackPointer)
+	assert.Equal(t, uint32(4096+4), heap, "the heap only holds the input of the call")
+}
+
+func (r *runner) testMemoryGrowIsolation(t *testing.T) {
+	call, err := r.execute(t, conformanceCode, "map_grow", params(""))
+	require.NoError(t, err)
+
+	if r.mode == reusedInstance {
+		assert.Equal(t, []byte{3, 0, 0, 0}, call.Output(), "plain instance reuse keeps the grown memory")
+		return
+	}
+	assert.Equal(t, []byte{2, 0, 0, 0}, call.Output())
+}
+
+func (r *runner) testNamedOutputs(t *testing.T) {
+	call, err := r.execute(t, conformanceCode, "map_named", params("value"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"first": []byte("value"), "second": []byte("found")}, call.NamedOutputs())
+	assert.Nil(t, call.Output())
+}
+
+func (r *runner) testStateSet(t *testing.T) {
+	s := newStore(t, pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string")
+	_, err := r.execute(t, conformanceCode, "store_set", params("value"), writer(s, pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string"))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Flush())
+	value, found := s.GetLast("key")
+	require.True(t, found)
+	assert.Equal(t, []byte("value"), value)
+}
+
+func (r *runner) testStateAdd(t *testing.T) {
+	s := newStore(t, pbsubstreams.Module_KindStore_UPDATE_POLICY_ADD, "int64")
+	_, err := r.execute(t, conformanceCode, "store_add", params(""), writer(s, pbsubstreams.Module_KindStore_UPDATE_POLICY_ADD, "int64"))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Flush())
+	value, found := s.GetLast("counter")
+	require.True(t, found)
+	assert.Equal(t, "10", string(value), "store is reset between calls, so only the last call should count")
+}
+
+func (r *runner) testStateDeletePrefix(t *testing.T) {
+	s := newStore(t, pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string")
+	_, err := r.execute(t, conformanceCode, "store_delete_prefix", params("value"), writer(s, pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string"))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Flush())
+	deltas := s.GetDeltas()
+	require.Len(t, deltas, 2)
+	assert.Equal(t, pbsubstreams.StoreDelta_CREATE, deltas[0].Operation)
+	assert.Equal(t, pbsubstreams.StoreDelta_DELETE, deltas[1].Operation)
+	assert.False(t, s.HasLast("key"))
+}
+
+func (r *runner) testStateRead(t *testing.T) {
+	s := newStore(t, pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string")
+	call, err := r.execute(t, conformanceCode, "store_get_last", wasm.NewStoreReaderInput("store", s), params(""))
+	require.NoError(t, err)
+	assert.Empty(t, call.Output())
+
+	s.SetBytes(1, "key", []byte("stored"))
+	require.NoError(t, s.Flush())
+
+	call, err = r.execute(t, conformanceCode, "store_get_last", wasm.NewStoreReaderInput("store", s), params(""))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("stored"), call.Output())
+}
+
+func (r *runner) testStateWrongPolicy(t *testing.T) {
+	s := newStore(t, pbsubstreams.Module_KindStore_UPDATE_POLICY_ADD, "int64")
+	_, err := r.execute(t, conformanceCode, "store_set", params("value"), writer(s, pbsubstreams.Module_KindStore_UPDATE_POLICY_ADD, "int64"))
+	require.Error(t, err)
+}
+
+func (r *runner) testLogs(t *testing.T) {
+	call, err := r.execute(t, conformanceCode, "log_entries", params(""))
+	require.NoError(t, err)
+
+	require.Len(t, call.Logs, 2, "logs are not kept across calls")
+	assert.Equal(t, &wasm.LogEntry{Level: wasm.LogLevelInfo, Message: "hello"}, call.Logs[0])
+	assert.Equal(t, &wasm.LogEntry{Level: wasm.LogLevelWarn, Message: "slow", Fields: []wasm.LogField{{Key: "k", Value: "v"}}}, call.Logs[1])
+}
+
+func (r *runner) testPanic(t *testing.T) {
+	call, err := r.execute(t, conformanceCode, "panics", params(""))
+	require.Error(t, err)
+
+	var panicErr *wasm.PanicError
+	require.ErrorAs(t, call.Err(), &panicErr)
+	assert.Equal(t, "panic in the wasm: \"boom\" at lib.rs:12:7", panicErr.Error())
+}
+
+func (r *runner) testTrap(t *testing.T) {
+	call, err := r.execute(t, conformanceCode, "traps", params(""))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, wasm.ErrTrap)
+	assert.NoError(t, call.Err(), "no panic was registered")
+}
+
+func (r *runner) testExtension(t *testing.T) {
+	call, err := r.execute(t, conformanceCode, "call_extension", params("ping"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("42:ping"), call.Output())
+}
+
+func (r *runner) testExtensionFailure(t *testing.T) {
+	call, err := r.execute(t, conformanceCode, "call_extension", params("fail"))
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "upstream unavailable")
+	assert.NotErrorIs(t, err, wasm.ErrTrap, "extension failures are not the module's")
+	assert.NoError(t, call.Err())
+}
+
+func (r *runner) testBench(t *testing.T, benchDir string) {
+	code, err := os.ReadFile(filepath.Join(benchDir, "substreams_wasm", "substreams.wasm"))
+	require.NoError(t, err)
+
+	call, err := r.execute(t, code, "map_noop", params(""))
+	require.NoError(t, err)
+	assert.Empty(t, call.Output())
+
+	block, err := os.ReadFile(filepath.Join(benchDir, "testdata", "ethereum_mainnet_block_16021772.binpb"))
+	require.NoError(t, err)
+	source := wasm.NewSourceInput("sf.ethereum.type.v2.Block")
+	source.SetValue(block)
+
+	call, err = r.execute(t, code, "map_block", source)
+	require.NoError(t, err)
+	assert.Contains(t, []int{44957, 45081}, len(call.Output()))
+}
+
+func params(value string) wasm.Argument {
+	return wasm.NewParamsInput(value)
+}
+
+func writer(s store.Store, updatePolicy pbsubstreams.Module_KindStore_UpdatePolicy, valueType string) wasm.Argument {
+	return wasm.NewStoreWriterOutput("store", s, updatePolicy, valueType)
+}
+
+func newStore(t *testing.T, updatePolicy pbsubstreams.Module_KindStore_UpdatePolicy, valueType string) store.Store {
+	config, err := store.NewConfig("store", 0, "", updatePolicy, valueType, dstore.NewMockStore(nil))
+	require.NoError(t, err)
+	return config.NewFullKV(zap.NewNop())
+}
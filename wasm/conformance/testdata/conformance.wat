@@ -0,0 +1,117 @@
+;; Module used by the runtime conformance suite. Each exported entrypoint
+;; exercises one group of host functions. Regenerate the binary with
+;; `go generate ./wasm/conformance` after modifying this file.
+(module
+  (import "env" "output" (func $output (param i32 i32)))
+  (import "env" "output_named" (func $output_named (param i32 i32 i32 i32)))
+  (import "env" "register_panic" (func $register_panic (param i32 i32 i32 i32 i32 i32)))
+  (import "logger" "println" (func $println (param i32 i32)))
+  (import "logger" "log" (func $log (param i32 i32 i32 i32 i32)))
+  (import "state" "set" (func $set (param i64 i32 i32 i32 i32)))
+  (import "state" "add_int64" (func $add_int64 (param i64 i32 i32 i64)))
+  (import "state" "delete_prefix" (func $delete_prefix (param i64 i32 i32)))
+  (import "state" "get_last" (func $get_last (param i32 i32 i32 i32) (result i32)))
+  (import "state" "has_last" (func $has_last (param i32 i32 i32) (result i32)))
+  (import "test" "echo" (func $echo (param i32 i32 i32)))
+
+  (memory (export "memory") 2)
+
+  ;; Scratch space where host functions write (ptr, len) pairs.
+  (global $scratch i32 (i32.const 256))
+  (global $heap (mut i32) (i32.const 4096))
+  ;; Not exported, like the stack pointer of Rust modules.
+  (global $stack_pointer (mut i32) (i32.const 65536))
+
+  (data (i32.const 1024) "key")
+  (data (i32.const 1032) "counter")
+  (data (i32.const 1040) "hello")
+  (data (i32.const 1048) "slow")
+  (data (i32.const 1056) "\01\00\00\00k\01\00\00\00v")
+  (data (i32.const 1072) "boom")
+  (data (i32.const 1080) "lib.rs")
+  (data (i32.const 1088) "first")
+  (data (i32.const 1096) "second")
+  (data (i32.const 1104) "found")
+
+  (func (export "alloc") (param $size i32) (result i32)
+    (local $ptr i32)
+    (local.set $ptr (global.get $heap))
+    (global.set $heap (i32.add (global.get $heap) (local.get $size)))
+    (local.get $ptr))
+
+  (func (export "dealloc") (param i32 i32))
+
+  (func $output_scratch
+    (call $output
+      (i32.load (global.get $scratch))
+      (i32.load (i32.add (global.get $scratch) (i32.const 4)))))
+
+  ;; Increments the counter kept in memory and outputs it, a little-endian u32.
+  (func (export "map_counter") (param $ptr i32) (param $len i32)
+    (i32.store (i32.const 512) (i32.add (i32.load (i32.const 512)) (i32.const 1)))
+    (call $output (i32.const 512) (i32.const 4)))
+
+  ;; Outputs the stack and heap pointers, little-endian u32s, then moves the stack
+  ;; pointer down like a function leaking its frame would.
+  (func (export "map_globals") (param $ptr i32) (param $len i32)
+    (i32.store (i32.const 528) (global.get $stack_pointer))
+    (i32.store (i32.const 532) (global.get $heap))
+    (global.set $stack_pointer (i32.sub (global.get $stack_pointer) (i32.const 16)))
+    (call $output (i32.const 528) (i32.const 8)))
+
+  ;; Outputs the memory size in pages, a little-endian u32, then grows it by one page.
+  (func (export "map_grow") (param $ptr i32) (param $len i32)
+    (i32.store (i32.const 536) (memory.size))
+    (drop (memory.grow (i32.const 1)))
+    (call $output (i32.const 536) (i32.const 4)))
+
+  ;; Returns its input as output.
+  (func (export "map_echo") (param $ptr i32) (param $len i32)
+    (call $output (local.get $ptr) (local.get $len)))
+
+  ;; Sets its input as the `first` named output and `found` as the `second` one.
+  (func (export "map_named") (param $ptr i32) (param $len i32)
+    (call $output_named (i32.const 1088) (i32.const 5) (local.get $ptr) (local.get $len))
+    (call $output_named (i32.const 1096) (i32.const 6) (i32.const 1104) (i32.const 5)))
+
+  ;; Sets its input at `key`, writer store uses the `set` policy.
+  (func (export "store_set") (param $ptr i32) (param $len i32)
+    (call $set (i64.const 1) (i32.const 1024) (i32.const 3) (local.get $ptr) (local.get $len)))
+
+  ;; Adds 5 to `counter` twice, writer store uses the `add` policy.
+  (func (export "store_add") (param $ptr i32) (param $len i32)
+    (call $add_int64 (i64.const 1) (i32.const 1032) (i32.const 7) (i64.const 5))
+    (call $add_int64 (i64.const 2) (i32.const 1032) (i32.const 7) (i64.const 5)))
+
+  ;; Sets its input at `key` then deletes every key starting with `k`, writer
+  ;; store uses the `set` policy.
+  (func (export "store_delete_prefix") (param $ptr i32) (param $len i32)
+    (call $set (i64.const 1) (i32.const 1024) (i32.const 3) (local.get $ptr) (local.get $len))
+    (call $delete_prefix (i64.const 2) (i32.const 1024) (i32.const 1)))
+
+  ;; Outputs the value of `key` from the reader store, nothing when missing.
+  (func (export "store_get_last") (param $store i32) (param $ptr i32) (param $len i32)
+    (if (call $has_last (local.get $store) (i32.const 1024) (i32.const 3))
+      (then
+        (drop (call $get_last (local.get $store) (i32.const 1024) (i32.const 3) (global.get $scratch)))
+        (call $output_scratch))))
+
+  ;; Emits one plain log line and one structured entry at warn level with a field.
+  (func (export "log_entries") (param $ptr i32) (param $len i32)
+    (call $println (i32.const 1040) (i32.const 5))
+    (call $log (i32.const 4) (i32.const 1048) (i32.const 4) (i32.const 1056) (i32.const 10)))
+
+  ;; Registers a panic and aborts like the Rust panic handler does.
+  (func (export "panics") (param $ptr i32) (param $len i32)
+    (call $register_panic (i32.const 1072) (i32.const 4) (i32.const 1080) (i32.const 6) (i32.const 12) (i32.const 7))
+    unreachable)
+
+  ;; Aborts without registering a panic.
+  (func (export "traps") (param $ptr i32) (param $len i32)
+    unreachable)
+
+  ;; Calls the `test.echo` extension with its input and outputs the result.
+  (func (export "call_extension") (param $ptr i32) (param $len i32)
+    (call $echo (local.get $ptr) (local.get $len) (global.get $scratch))
+    (call $output_scratch))
+)
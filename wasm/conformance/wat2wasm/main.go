@@ -0,0 +1,27 @@
+// Command wat2wasm compiles the conformance suite module from its text format.
+//
+// usage: go run ./wat2wasm <input.wat> <output.wasm>
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bytecodealliance/wasmtime-go/v4"
+	"github.com/streamingfast/cli"
+)
+
+func main() {
+	cli.Ensure(len(os.Args) == 3, "usage: wat2wasm <input.wat> <output.wasm>")
+
+	wat, err := os.ReadFile(os.Args[1])
+	cli.NoError(err, "unable to read %q", os.Args[1])
+
+	code, err := wasmtime.Wat2Wasm(string(wat))
+	cli.NoError(err, "unable to compile %q", os.Args[1])
+
+	err = os.WriteFile(os.Args[2], code, 0644)
+	cli.NoError(err, "unable to write %q", os.Args[2])
+
+	fmt.Println("Wrote", os.Args[2])
+}
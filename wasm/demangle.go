@@ -0,0 +1,104 @@
+package wasm
+
+import (
+	"strconv"
+	"strings"
+)
+
+var rustEscapes = map[string]string{
+	"SP": "@",
+	"BP": "*",
+	"RF": "&",
+	"LT": "<",
+	"GT": ">",
+	"LP": "(",
+	"RP": ")",
+	"C":  ",",
+}
+
+// demangleRust turns a symbol using the legacy Rust mangling scheme (`_ZN...E`),
+// used in the name section of modules compiled by rustc, into its readable form,
+// without the trailing hash. Other symbols are returned as-is.
+func demangleRust(symbol string) string {
+	if !strings.HasPrefix(symbol, "_ZN") || !strings.HasSuffix(symbol, "E") {
+		return symbol
+	}
+
+	rest := symbol[3 : len(symbol)-1]
+	var components []string
+	for len(rest) > 0 {
+		digits := 0
+		for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+			digits++
+		}
+		length, err := strconv.Atoi(rest[:digits])
+		if err != nil || length == 0 || digits+length > len(rest) {
+			return symbol
+		}
+		components = append(components, rest[digits:digits+length])
+		rest = rest[digits+length:]
+	}
+
+	if last := components[len(components)-1]; len(components) > 1 && isRustHash(last) {
+		components = components[:len(components)-1]
+	}
+
+	for i, component := range components {
+		demangled, ok := unescapeRustComponent(component)
+		if !ok {
+			return symbol
+		}
+		components[i] = demangled
+	}
+	return strings.Join(components, "::")
+}
+
+// isRustHash matches the `h` followed by 16 hex digits component ending legacy symbols.
+func isRustHash(component string) bool {
+	if len(component) != 17 || component[0] != 'h' {
+		return false
+	}
+	_, err := strconv.ParseUint(component[1:], 16, 64)
+	return err == nil
+}
+
+func unescapeRustComponent(component string) (string, bool) {
+	if strings.HasPrefix(component, "_$") {
+		component = component[1:]
+	}
+
+	var out strings.Builder
+	for len(component) > 0 {
+		switch {
+		case strings.HasPrefix(component, ".."):
+			out.WriteString("::")
+			component = component[2:]
+
+		case component[0] == '$':
+			end := strings.IndexByte(component[1:], '$')
+			if end == -1 {
+				return "", false
+			}
+			escape := component[1 : end+1]
+			component = component[end+2:]
+
+			if replacement, found := rustEscapes[escape]; found {
+				out.WriteString(replacement)
+				continue
+			}
+			if !strings.HasPrefix(escape, "u") {
+				return "", false
+			}
+			code, err := strconv.ParseUint(escape[1:], 16, 32)
+			if err != nil {
+				return "", false
+			}
+			out.WriteRune(rune(code))
+
+		default:
+			out.WriteByte(component[0])
+			component = component[1:]
+		}
+	}
+	return out.String(), true
+}
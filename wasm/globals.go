@@ -0,0 +1,360 @@
+package wasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// ValueType is the type of a wasm global, as encoded in the binary format.
+type ValueType byte
+
+const (
+	ValueTypeI32 ValueType = 0x7f
+	ValueTypeI64 ValueType = 0x7e
+	ValueTypeF32 ValueType = 0x7d
+	ValueTypeF64 ValueType = 0x7c
+)
+
+// MutableGlobal is a mutable global defined by a module, exported under `Name` by
+// ExportMutableGlobals so runtimes can save and restore its value.
+type MutableGlobal struct {
+	Name string
+	Type ValueType
+}
+
+const (
+	sectionCustom = 0
+	sectionImport = 2
+	sectionGlobal = 6
+	sectionExport = 7
+
+	externKindGlobal = 0x03
+)
+
+// ExportMutableGlobals rewrites `code` so every mutable global it defines is exported,
+// which is the only way for runtimes to read and set the globals a module keeps to
+// itself, like the `__stack_pointer` of Rust modules. The exports are named after the
+// global index, rewriting an already rewritten module returns it unchanged.
+//
+// Only numeric globals are supported, an error is returned for mutable `v128` or
+// reference globals.
+func ExportMutableGlobals(code []byte) (out []byte, globals []MutableGlobal, err error) {
+	if len(code) < 8 || !bytes.Equal(code[:4], []byte("\x00asm")) {
+		return nil, nil, fmt.Errorf("not a wasm binary")
+	}
+
+	var importedGlobals uint32
+	var indexed []indexedGlobal
+	var exportSection *wasmSection
+	insertAt := 8 // the export section goes right after the sections that precede it
+	for r := (&wasmReader{in: code, pos: 8}); !r.done(); {
+		section, err := r.section()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch section.id {
+		case sectionImport:
+			if importedGlobals, err = countImportedGlobals(section.content); err != nil {
+				return nil, nil, fmt.Errorf("import section: %w", err)
+			}
+		case sectionGlobal:
+			if indexed, err = mutableGlobals(section.content, importedGlobals); err != nil {
+				return nil, nil, fmt.Errorf("global section: %w", err)
+			}
+		case sectionExport:
+			exportSection = section
+		}
+		if section.id != sectionCustom && section.id < sectionExport {
+			insertAt = section.end
+		}
+	}
+	if len(indexed) == 0 {
+		return code, nil, nil
+	}
+	globals = toMutableGlobals(indexed)
+
+	var count uint32
+	var entries []byte
+	existing := map[string]bool{}
+	if exportSection != nil {
+		r := &wasmReader{in: exportSection.content}
+		if count, err = r.u32(); err != nil {
+			return nil, nil, fmt.Errorf("export section: %w", err)
+		}
+		entriesStart := r.pos
+		for i := uint32(0); i < count; i++ {
+			name, err := r.name()
+			if err != nil {
+				return nil, nil, fmt.Errorf("export section: %w", err)
+			}
+			if _, err := r.byte(); err != nil {
+				return nil, nil, fmt.Errorf("export section: %w", err)
+			}
+			if _, err := r.u32(); err != nil {
+				return nil, nil, fmt.Errorf("export section: %w", err)
+			}
+			existing[name] = true
+		}
+		entries = append(entries, exportSection.content[entriesStart:]...)
+	}
+
+	added := false
+	for _, global := range indexed {
+		if existing[global.Name] {
+			continue
+		}
+		added = true
+		count++
+		entries = appendU32(entries, uint32(len(global.Name)))
+		entries = append(entries, global.Name...)
+		entries = append(entries, externKindGlobal)
+		entries = appendU32(entries, global.index)
+	}
+	if !added {
+		return code, globals, nil
+	}
+
+	content := appendU32(nil, count)
+	content = append(content, entries...)
+	section := append([]byte{sectionExport}, appendU32(nil, uint32(len(content)))...)
+	section = append(section, content...)
+
+	if exportSection != nil {
+		out = append(out, code[:exportSection.start]...)
+		out = append(out, section...)
+		out = append(out, code[exportSection.end:]...)
+	} else {
+		out = append(out, code[:insertAt]...)
+		out = append(out, section...)
+		out = append(out, code[insertAt:]...)
+	}
+	return out, globals, nil
+}
+
+type indexedGlobal struct {
+	MutableGlobal
+	index uint32
+}
+
+func toMutableGlobals(in []indexedGlobal) (out []MutableGlobal) {
+	for _, global := range in {
+		out = append(out, global.MutableGlobal)
+	}
+	return
+}
+
+func countImportedGlobals(content []byte) (count uint32, err error) {
+	r := &wasmReader{in: content}
+	imports, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	for i := uint32(0); i < imports; i++ {
+		if _, err := r.name(); err != nil {
+			return 0, err
+		}
+		if _, err := r.name(); err != nil {
+			return 0, err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		switch kind {
+		case 0x00: // function: type index
+			_, err = r.u32()
+		case 0x01: // table: reference type and limits
+			if _, err = r.byte(); err == nil {
+				err = r.limits()
+			}
+		case 0x02: // memory: limits
+			err = r.limits()
+		case externKindGlobal: // global: value type and mutability
+			count++
+			_, err = r.bytes(2)
+		default:
+			err = fmt.Errorf("unknown import kind 0x%02x", kind)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+func mutableGlobals(content []byte, importedGlobals uint32) (out []indexedGlobal, err error) {
+	r := &wasmReader{in: content}
+	count, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < count; i++ {
+		header, err := r.bytes(2)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.skipConstExpr(); err != nil {
+			return nil, fmt.Errorf("global %d: %w", i, err)
+		}
+
+		valueType, mutable := ValueType(header[0]), header[1] == 1
+		if !mutable {
+			continue
+		}
+		switch valueType {
+		case ValueTypeI32, ValueTypeI64, ValueTypeF32, ValueTypeF64:
+		default:
+			return nil, fmt.Errorf("global %d: unsupported mutable global of type 0x%02x", i, byte(valueType))
+		}
+
+		index := importedGlobals + i
+		out = append(out, indexedGlobal{
+			MutableGlobal: MutableGlobal{Name: fmt.Sprintf("substreams_global_%d", index), Type: valueType},
+			index:         index,
+		})
+	}
+	return out, nil
+}
+
+type wasmSection struct {
+	id         byte
+	start, end int // bounds of the whole section, id and size included
+	content    []byte
+}
+
+type wasmReader struct {
+	in  []byte
+	pos int
+}
+
+func (r *wasmReader) done() bool {
+	return r.pos >= len(r.in)
+}
+
+func (r *wasmReader) section() (*wasmSection, error) {
+	start := r.pos
+	id, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	size, err := r.u32()
+	if err != nil {
+		return nil, fmt.Errorf("section %d: %w", id, err)
+	}
+	content, err := r.bytes(int(size))
+	if err != nil {
+		return nil, fmt.Errorf("section %d: %w", id, err)
+	}
+	return &wasmSection{id: id, start: start, end: r.pos, content: content}, nil
+}
+
+func (r *wasmReader) byte() (byte, error) {
+	if r.done() {
+		return 0, fmt.Errorf("unexpected end of binary")
+	}
+	r.pos++
+	return r.in[r.pos-1], nil
+}
+
+func (r *wasmReader) bytes(n int) ([]byte, error) {
+	if n < 0 || len(r.in)-r.pos < n {
+		return nil, fmt.Errorf("unexpected end of binary")
+	}
+	r.pos += n
+	return r.in[r.pos-n : r.pos], nil
+}
+
+func (r *wasmReader) u32() (uint32, error) {
+	value, err := r.leb128()
+	if err != nil {
+		return 0, err
+	}
+	if value > 0xffffffff {
+		return 0, fmt.Errorf("u32 overflow")
+	}
+	return uint32(value), nil
+}
+
+func (r *wasmReader) leb128() (uint64, error) {
+	value, n := binary.Uvarint(r.in[r.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid leb128 integer")
+	}
+	r.pos += n
+	return value, nil
+}
+
+// skipLEB128 skips an integer, signed or not.
+func (r *wasmReader) skipLEB128() error {
+	for i := 0; i < binary.MaxVarintLen64; i++ {
+		b, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if b&0x80 == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid leb128 integer")
+}
+
+func (r *wasmReader) name() (string, error) {
+	length, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	name, err := r.bytes(int(length))
+	return string(name), err
+}
+
+func (r *wasmReader) limits() error {
+	flags, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if _, err := r.leb128(); err != nil {
+		return err
+	}
+	if flags&0x01 != 0 {
+		_, err = r.leb128()
+	}
+	return err
+}
+
+// skipConstExpr skips the constant expression initializing a global, up to its `end`.
+func (r *wasmReader) skipConstExpr() error {
+	for {
+		opcode, err := r.byte()
+		if err != nil {
+			return err
+		}
+		switch opcode {
+		case 0x0b: // end
+			return nil
+		case 0x41, 0x42, 0x23, 0xd2: // i32.const, i64.const, global.get, ref.func
+			err = r.skipLEB128()
+		case 0x43: // f32.const
+			_, err = r.bytes(4)
+		case 0x44: // f64.const
+			_, err = r.bytes(8)
+		case 0xd0: // ref.null
+			_, err = r.byte()
+		case 0xfd: // v128.const
+			if _, err = r.u32(); err == nil {
+				_, err = r.bytes(16)
+			}
+		case 0x6a, 0x6b, 0x6c, 0x7c, 0x7d, 0x7e: // extended constant arithmetic
+		default:
+			return fmt.Errorf("unsupported opcode 0x%02x in constant expression", opcode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func appendU32(out []byte, value uint32) []byte {
+	return binary.AppendUvarint(out, uint64(value))
+}
@@ -0,0 +1,30 @@
+package wasm
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportMutableGlobals(t *testing.T) {
+	code, err := os.ReadFile("conformance/testdata/conformance.wasm")
+	require.NoError(t, err)
+
+	rewritten, globals, err := ExportMutableGlobals(code)
+	require.NoError(t, err)
+	assert.Equal(t, []MutableGlobal{
+		{Name: "substreams_global_1", Type: ValueTypeI32},
+		{Name: "substreams_global_2", Type: ValueTypeI32},
+	}, globals, "the immutable `$scratch` global is not exported")
+	assert.Greater(t, len(rewritten), len(code))
+
+	again, globalsAgain, err := ExportMutableGlobals(rewritten)
+	require.NoError(t, err)
+	assert.Equal(t, rewritten, again, "rewriting is idempotent")
+	assert.Equal(t, globals, globalsAgain)
+
+	_, _, err = ExportMutableGlobals(code[:len(code)-3])
+	assert.Error(t, err)
+}
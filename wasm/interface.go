@@ -2,6 +2,7 @@ package wasm
 
 import (
 	"context"
+	"errors"
 
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 )
@@ -46,12 +47,18 @@ type Module interface {
 	// as the `cachedInstance` argument upon the next call. In which case, the runtime
 	// would benefit from using it back. It is the runtime's responsibility to determine
 	// whether this caching entails risks to determinism (leaking of global state for instance).
+	// The errors of calls trapped by the module code itself wrap ErrTrap, the failures of
+	// host functions and wasm extensions do not.
 	ExecuteNewCall(ctx context.Context, call *Call, cachedInstance Instance, arguments []Argument) (instance Instance, err error)
 
 	// Close gets called when the module can be unloaded at the end of a user's request.
 	Close(ctx context.Context) error
 }
 
+// ErrTrap marks the calls trapped by the code of the module itself, which executing the
+// call again reproduces.
+var ErrTrap = errors.New("wasm module trapped")
+
 // An Instance lives for the duration of an execution (with instance caching disabled)
 // // or a series of execution (when instance caching is enabled).
 type Instance interface {
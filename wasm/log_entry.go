@@ -0,0 +1,89 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// LogLevel is the severity of a log entry emitted by a module. The numeric values
+// are part of the `logger.log` host function ABI and must not be changed.
+type LogLevel int32
+
+const (
+	LogLevelTrace LogLevel = 1
+	LogLevelDebug LogLevel = 2
+	LogLevelInfo  LogLevel = 3
+	LogLevelWarn  LogLevel = 4
+	LogLevelError LogLevel = 5
+)
+
+var logLevelNames = map[LogLevel]string{
+	LogLevelTrace: "trace",
+	LogLevelDebug: "debug",
+	LogLevelInfo:  "info",
+	LogLevelWarn:  "warn",
+	LogLevelError: "error",
+}
+
+func (l LogLevel) IsValid() bool {
+	_, found := logLevelNames[l]
+	return found
+}
+
+func (l LogLevel) String() string {
+	if name, found := logLevelNames[l]; found {
+		return name
+	}
+	return fmt.Sprintf("LogLevel(%d)", int32(l))
+}
+
+type LogField struct {
+	Key   string
+	Value string
+}
+
+type LogEntry struct {
+	Level   LogLevel
+	Message string
+	Fields  []LogField
+}
+
+func (e *LogEntry) byteCount() uint64 {
+	count := uint64(len(e.Message))
+	for _, field := range e.Fields {
+		count += uint64(len(field.Key) + len(field.Value))
+	}
+	return count
+}
+
+// DecodeLogFields decodes the fields buffer received by the `logger.log` host
+// function. The buffer is a sequence of key and value strings, alternating,
+// each prefixed by its byte length as a little-endian uint32.
+func DecodeLogFields(in []byte) ([]LogField, error) {
+	var fields []LogField
+	for len(in) != 0 {
+		key, rest, err := readLengthPrefixed(in)
+		if err != nil {
+			return nil, fmt.Errorf("field %d key: %w", len(fields), err)
+		}
+		value, rest, err := readLengthPrefixed(rest)
+		if err != nil {
+			return nil, fmt.Errorf("field %d value: %w", len(fields), err)
+		}
+		fields = append(fields, LogField{Key: key, Value: value})
+		in = rest
+	}
+	return fields, nil
+}
+
+func readLengthPrefixed(in []byte) (string, []byte, error) {
+	if len(in) < 4 {
+		return "", nil, fmt.Errorf("expected 4 bytes length prefix, got %d bytes", len(in))
+	}
+	length := binary.LittleEndian.Uint32(in)
+	in = in[4:]
+	if uint64(len(in)) < uint64(length) {
+		return "", nil, fmt.Errorf("expected %d bytes, got %d bytes", length, len(in))
+	}
+	return string(in[:length]), in[length:], nil
+}
@@ -0,0 +1,59 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeLogFields(kvs ...string) (out []byte) {
+	for _, s := range kvs {
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(s)))
+		out = append(out, s...)
+	}
+	return
+}
+
+func TestDecodeLogFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          []byte
+		expect      []LogField
+		expectError bool
+	}{
+		{"empty", nil, nil, false},
+		{"one field", encodeLogFields("key", "value"), []LogField{{"key", "value"}}, false},
+		{"empty value", encodeLogFields("key", ""), []LogField{{"key", ""}}, false},
+		{"order is kept", encodeLogFields("b", "1", "a", "2"), []LogField{{"b", "1"}, {"a", "2"}}, false},
+		{"missing value", encodeLogFields("key"), nil, true},
+		{"truncated length", []byte{1, 0}, nil, true},
+		{"truncated data", append(binary.LittleEndian.AppendUint32(nil, 10), "short"...), nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fields, err := DecodeLogFields(test.in)
+			if test.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expect, fields)
+		})
+	}
+}
+
+func TestCall_AppendLogEntry(t *testing.T) {
+	call := &Call{}
+	call.AppendLog("hello")
+	call.AppendLogEntry(LogLevelWarn, "slow", []LogField{{"block", "12"}})
+
+	require.Len(t, call.Logs, 2)
+	assert.Equal(t, &LogEntry{Level: LogLevelInfo, Message: "hello"}, call.Logs[0])
+	assert.Equal(t, &LogEntry{Level: LogLevelWarn, Message: "slow", Fields: []LogField{{"block", "12"}}}, call.Logs[1])
+	assert.Equal(t, uint64(len("hello")+len("slow")+len("block")+len("12")), call.LogsByteCount)
+
+	assert.Panics(t, func() { call.AppendLogEntry(LogLevel(42), "invalid", nil) })
+}
@@ -0,0 +1,261 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// DefaultProfilingPeriod is the wall time between two samples of a profiled module.
+const DefaultProfilingPeriod = 100 * time.Microsecond
+
+// Profiler samples the call stacks of the WASM functions executed by a set of modules.
+// Runtimes supporting profiling record each execution of a profiled module through a
+// ProfileRecorder, the sampled stacks of each module are then rendered as a pprof
+// profile.
+//
+// Runtimes only get control on function entries, so a sample is taken on the first
+// function entry once a period has elapsed since the previous sample: it holds the
+// stack of the caller, which ran during that time, weighted by the time elapsed. The
+// time spent by the module outside of any sample is carried over to its next execution
+// so short executions are still accounted for. Rust symbols are demangled when rendering
+// the profile, the raw symbol is kept as the function's system name.
+type Profiler struct {
+	period time.Duration
+
+	mu      sync.Mutex
+	modules map[string]*moduleProfile
+}
+
+type moduleProfile struct {
+	root *profileNode
+
+	// unsampled is the execution time since the last sample of the module.
+	unsampled time.Duration
+}
+
+func NewProfiler(moduleNames []string) *Profiler {
+	return NewProfilerWithPeriod(moduleNames, DefaultProfilingPeriod)
+}
+
+func NewProfilerWithPeriod(moduleNames []string, period time.Duration) *Profiler {
+	p := &Profiler{
+		period:  period,
+		modules: make(map[string]*moduleProfile, len(moduleNames)),
+	}
+	for _, name := range moduleNames {
+		p.modules[name] = &moduleProfile{root: newProfileNode("")}
+	}
+	return p
+}
+
+// Profiles returns true if executions of `moduleName` must be recorded.
+func (p *Profiler) Profiles(moduleName string) bool {
+	_, found := p.modules[moduleName]
+	return found
+}
+
+// ModuleNames returns the sorted names of the profiled modules.
+func (p *Profiler) ModuleNames() []string {
+	names := make([]string, 0, len(p.modules))
+	for name := range p.modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartRecording returns a recorder for a single execution of `moduleName`, which is
+// merged into the module's profile on End. It returns nil when the module is not profiled.
+func (p *Profiler) StartRecording(moduleName string) *ProfileRecorder {
+	if !p.Profiles(moduleName) {
+		return nil
+	}
+
+	p.mu.Lock()
+	unsampled := p.modules[moduleName].unsampled
+	p.mu.Unlock()
+
+	return &ProfileRecorder{
+		profiler:   p,
+		moduleName: moduleName,
+		root:       newProfileNode(""),
+		now:        time.Now,
+		lastSample: time.Now().Add(-unsampled),
+	}
+}
+
+func (p *Profiler) merge(moduleName string, root *profileNode, unsampled time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	module := p.modules[moduleName]
+	module.root.merge(root)
+	module.unsampled = unsampled
+}
+
+// Profile renders the profile of `moduleName`, gzipped in the pprof format.
+func (p *Profiler) Profile(moduleName string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	module, found := p.modules[moduleName]
+	if !found {
+		return nil, fmt.Errorf("module %q is not profiled", moduleName)
+	}
+
+	prof := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "samples", Unit: "count"},
+			{Type: "wall", Unit: "nanoseconds"},
+		},
+		DefaultSampleType: "wall",
+		PeriodType:        &profile.ValueType{Type: "wall", Unit: "nanoseconds"},
+		Period:            int64(p.period),
+	}
+
+	locations := map[string]*profile.Location{}
+	locationOf := func(function string) *profile.Location {
+		if loc, found := locations[function]; found {
+			return loc
+		}
+		fn := &profile.Function{
+			ID:         uint64(len(prof.Function) + 1),
+			Name:       demangleRust(function),
+			SystemName: function,
+			Filename:   moduleName,
+		}
+		loc := &profile.Location{
+			ID:   uint64(len(prof.Location) + 1),
+			Line: []profile.Line{{Function: fn}},
+		}
+		prof.Function = append(prof.Function, fn)
+		prof.Location = append(prof.Location, loc)
+		locations[function] = loc
+		return loc
+	}
+
+	var walk func(node *profileNode, stack []*profile.Location)
+	walk = func(node *profileNode, stack []*profile.Location) {
+		for _, child := range node.sortedChildren() {
+			// pprof stacks start with the innermost function
+			childStack := append([]*profile.Location{locationOf(child.function)}, stack...)
+			if child.samples > 0 {
+				prof.Sample = append(prof.Sample, &profile.Sample{
+					Location: childStack,
+					Value:    []int64{child.samples, child.wallTime},
+				})
+				prof.DurationNanos += child.wallTime
+			}
+			walk(child, childStack)
+		}
+	}
+	walk(module.root, nil)
+
+	if err := prof.CheckValid(); err != nil {
+		return nil, fmt.Errorf("invalid profile: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := prof.Write(buf); err != nil {
+		return nil, fmt.Errorf("writing profile: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ProfileRecorder records the samples of a single execution, it must not be used
+// concurrently.
+type ProfileRecorder struct {
+	profiler   *Profiler
+	moduleName string
+
+	root       *profileNode
+	now        func() time.Time
+	lastSample time.Time
+}
+
+// SampleDue returns true when a period has elapsed since the last sample, runtimes
+// call it on function entries and only walk the stack when it is due.
+func (r *ProfileRecorder) SampleDue() bool {
+	return r.now().Sub(r.lastSample) >= r.profiler.period
+}
+
+// Sample records `stack`, outermost function first, as the stack that ran since the
+// last sample.
+func (r *ProfileRecorder) Sample(stack []string) {
+	now := r.now()
+	node := r.root
+	for _, function := range stack {
+		node = node.child(function)
+	}
+	node.samples++
+	node.wallTime += int64(now.Sub(r.lastSample))
+	r.lastSample = now
+}
+
+// End merges the recorded samples into the module's profile, the time elapsed since
+// the last sample is carried over to the next execution of the module.
+func (r *ProfileRecorder) End() {
+	r.profiler.merge(r.moduleName, r.root, r.now().Sub(r.lastSample))
+}
+
+type profileNode struct {
+	function string
+	samples  int64
+	wallTime int64 // nanoseconds sampled with this function on top of the stack
+
+	children map[string]*profileNode
+}
+
+func newProfileNode(function string) *profileNode {
+	return &profileNode{function: function}
+}
+
+func (n *profileNode) child(function string) *profileNode {
+	if child, found := n.children[function]; found {
+		return child
+	}
+	if n.children == nil {
+		n.children = map[string]*profileNode{}
+	}
+	child := newProfileNode(function)
+	n.children[function] = child
+	return child
+}
+
+func (n *profileNode) merge(other *profileNode) {
+	n.samples += other.samples
+	n.wallTime += other.wallTime
+	for function, otherChild := range other.children {
+		n.child(function).merge(otherChild)
+	}
+}
+
+func (n *profileNode) sortedChildren() []*profileNode {
+	children := make([]*profileNode, 0, len(n.children))
+	for _, child := range n.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].function < children[j].function })
+	return children
+}
+
+type profilerCtxType string
+
+const profilerCtx = profilerCtxType("profiler")
+
+// WithProfiler makes the modules created with the returned context record their
+// executions into `profiler`.
+func WithProfiler(ctx context.Context, profiler *Profiler) context.Context {
+	return context.WithValue(ctx, profilerCtx, profiler)
+}
+
+// ProfilerFromContext returns the profiler set with WithProfiler, nil if there is none.
+func ProfilerFromContext(ctx context.Context) *Profiler {
+	profiler, _ := ctx.Value(profilerCtx).(*Profiler)
+	return profiler
+}
@@ -0,0 +1,108 @@
+package wasm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiler_Profile(t *testing.T) {
+	profiler := NewProfilerWithPeriod([]string{"map_a"}, 10*time.Millisecond)
+	assert.True(t, profiler.Profiles("map_a"))
+	assert.False(t, profiler.Profiles("map_b"))
+	assert.Nil(t, profiler.StartRecording("map_b"))
+
+	now := time.Unix(0, 0)
+	for i := 0; i < 2; i++ {
+		recorder := profiler.StartRecording("map_a")
+		recorder.now = func() time.Time { return now }
+		recorder.lastSample = now
+
+		now = now.Add(5 * time.Millisecond)
+		assert.False(t, recorder.SampleDue())
+
+		now = now.Add(5 * time.Millisecond)
+		require.True(t, recorder.SampleDue())
+		recorder.Sample([]string{"map_a", "_ZN10substreams5proto6decode17h504e78909a1b2a51E"})
+
+		now = now.Add(20 * time.Millisecond)
+		require.True(t, recorder.SampleDue())
+		recorder.Sample([]string{"map_a", "_ZN10substreams5proto6decode17h504e78909a1b2a51E", "memcpy"})
+
+		now = now.Add(30 * time.Millisecond)
+		recorder.Sample([]string{"map_a"})
+		recorder.End()
+	}
+
+	out, err := profiler.Profile("map_a")
+	require.NoError(t, err)
+	prof, err := profile.Parse(bytes.NewReader(out))
+	require.NoError(t, err)
+
+	samples := map[string][]int64{}
+	for _, sample := range prof.Sample {
+		var stack []string
+		for _, loc := range sample.Location {
+			stack = append([]string{loc.Line[0].Function.Name}, stack...)
+		}
+		samples[strings.Join(stack, ";")] = sample.Value
+	}
+	ms := int64(time.Millisecond)
+	assert.Equal(t, map[string][]int64{
+		"map_a":                                  {2, 60 * ms},
+		"map_a;substreams::proto::decode":        {2, 20 * ms},
+		"map_a;substreams::proto::decode;memcpy": {2, 40 * ms},
+	}, samples)
+	assert.Len(t, prof.Function, 3)
+	assert.Equal(t, "wall", prof.DefaultSampleType)
+	assert.Equal(t, 10*ms, prof.Period)
+	assert.Equal(t, 120*ms, prof.DurationNanos)
+
+	_, err = profiler.Profile("map_b")
+	require.Error(t, err)
+}
+
+func TestProfileRecorder_CarriesUnsampledTime(t *testing.T) {
+	profiler := NewProfilerWithPeriod([]string{"map_a"}, 10*time.Millisecond)
+
+	now := time.Unix(0, 0)
+	recorder := profiler.StartRecording("map_a")
+	recorder.now = func() time.Time { return now }
+	recorder.lastSample = now
+	now = now.Add(6 * time.Millisecond)
+	assert.False(t, recorder.SampleDue())
+	recorder.End()
+	assert.Equal(t, 6*time.Millisecond, profiler.modules["map_a"].unsampled)
+
+	recorder = profiler.StartRecording("map_a")
+	assert.GreaterOrEqual(t, time.Since(recorder.lastSample), 6*time.Millisecond, "time spent by the previous execution counts toward the period")
+}
+
+func Test_demangleRust(t *testing.T) {
+	tests := []struct {
+		symbol   string
+		expected string
+	}{
+		{"map_block", "map_block"},
+		{"__rust_realloc", "__rust_realloc"},
+		{"_ZN10substreams3hex16encode_lower_hex17hd95749c57f9200bdE", "substreams::hex::encode_lower_hex"},
+		{"_ZN5alloc3vec16Vec$LT$T$C$A$GT$17extend_from_slice17h5e80170d86bf64b2E", "alloc::vec::Vec<T,A>::extend_from_slice"},
+		{"_ZN4core3ptr85drop_in_place$LT$std..rt..lang_start$LT$$LP$$RP$$GT$..$u7b$$u7b$closure$u7d$$u7d$$GT$17h0123456789abcdefE", "core::ptr::drop_in_place<std::rt::lang_start<()>::{{closure}}>"},
+		{"_ZN4core3fmt5Write9write_fmt17h0123456789abcdefE", "core::fmt::Write::write_fmt"},
+		{"_ZN3foo3barE", "foo::bar"},
+		{"_ZN3fooE", "foo"},
+		{"_ZN99fooE", "_ZN99fooE"},
+		{"_ZN3foo$XX$E", "_ZN3foo$XX$E"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.symbol, func(t *testing.T) {
+			assert.Equal(t, test.expected, demangleRust(test.symbol))
+		})
+	}
+}
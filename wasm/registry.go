@@ -4,9 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
-	"go.uber.org/zap"
 	"golang.org/x/exp/maps"
 )
 
@@ -18,6 +18,8 @@ type Registry struct {
 	maxFuel              uint64
 	runtimeStack         ModuleFactory
 	instanceCacheEnabled bool
+
+	instanceSnapshotsEnabled bool
 }
 
 func (r *Registry) registerWASMExtension(namespace string, importName string, ext WASMExtension) {
@@ -42,29 +44,44 @@ func (r *Registry) registerWASMExtension(namespace string, importName string, ex
 	}
 	r.Extensions[namespace][importName] = ext
 }
-func (r *Registry) MaxFuel() uint64            { return r.maxFuel }
-func (r *Registry) InstanceCacheEnabled() bool { return r.instanceCacheEnabled }
+func (r *Registry) MaxFuel() uint64 { return r.maxFuel }
+
+// InstanceCacheEnabled is true when instances are reused across executions, either
+// as-is or restored to their initial memory when instance snapshots are enabled.
+func (r *Registry) InstanceCacheEnabled() bool {
+	return r.instanceCacheEnabled || r.instanceSnapshotsEnabled
+}
+
+// InstanceSnapshotsEnabled is true when runtimes must snapshot the memory and mutable
+// globals of new instances and restore them when cleaning them up, see InstanceSnapshot.
+func (r *Registry) InstanceSnapshotsEnabled() bool { return r.instanceSnapshotsEnabled }
+
+// EnableInstanceSnapshots turns on instance reuse with their memory restored between
+// executions. Unlike the plain instance cache, it keeps the execution deterministic.
+func (r *Registry) EnableInstanceSnapshots() {
+	r.instanceSnapshotsEnabled = true
+}
 
 func (r *Registry) NewModule(ctx context.Context, wasmCode []byte) (Module, error) {
 	return r.runtimeStack.NewModule(ctx, wasmCode, r)
 }
 
-func NewRegistry(extensions map[string]map[string]WASMExtension, maxFuel uint64) *Registry {
-	runtimeName := "wazero" // default
-
-	if selectRuntime := os.Getenv("SUBSTREAMS_WASM_RUNTIME"); selectRuntime != "" {
-		selectedRuntime := runtimes[selectRuntime]
-		if selectedRuntime == nil {
-			panic(fmt.Errorf("could not find wasm runtime specified by `SUBSTREAMS_WASM_RUNTIME` env var: %q", selectRuntime))
-		}
-	} else {
-		zlog.Info("using default wasm runtime", zap.String("runtime", runtimeName))
-	}
+// DefaultRuntime is the name of the runtime used when none is configured.
+const DefaultRuntime = "wazero"
 
-	return NewRegistryWithRuntime(runtimeName, extensions, maxFuel)
+// NewRegistry creates a registry using the default runtime, see NewRegistryWithRuntime
+// to select another one.
+func NewRegistry(extensions map[string]map[string]WASMExtension, maxFuel uint64) *Registry {
+	return NewRegistryWithRuntime(DefaultRuntime, extensions, maxFuel)
 }
 
+// NewRegistryWithRuntime creates a registry using the runtime registered under `runtimeName`,
+// or `DefaultRuntime` when it is empty. It panics if no such runtime is registered.
 func NewRegistryWithRuntime(runtimeName string, extensions map[string]map[string]WASMExtension, maxFuel uint64) *Registry {
+	if runtimeName == "" {
+		runtimeName = DefaultRuntime
+	}
+
 	r := &Registry{
 		maxFuel: maxFuel,
 	}
@@ -80,11 +97,26 @@ func NewRegistryWithRuntime(runtimeName string, extensions map[string]map[string
 		r.instanceCacheEnabled = true
 	}
 
-	var found bool
-	r.runtimeStack, found = runtimes[runtimeName]
-	if !found {
-		panic(fmt.Errorf("could not find wasm runtime %q (valid values are %q)", runtimeName, strings.Join(maps.Keys(runtimes), ", ")))
+	if err := ValidateRuntime(runtimeName); err != nil {
+		panic(err)
 	}
+	r.runtimeStack = runtimes[runtimeName]
 
 	return r
 }
+
+// RuntimeNames returns the sorted names of the runtimes registered through RegisterModuleFactory.
+func RuntimeNames() []string {
+	names := maps.Keys(runtimes)
+	sort.Strings(names)
+	return names
+}
+
+// ValidateRuntime returns an error if no runtime was registered under `runtimeName`.
+// Runtimes register themselves when their package is imported.
+func ValidateRuntime(runtimeName string) error {
+	if _, found := runtimes[runtimeName]; !found {
+		return fmt.Errorf("could not find wasm runtime %q (valid values are %q)", runtimeName, strings.Join(RuntimeNames(), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package wasm
+
+import "bytes"
+
+const WASMPageSize = 64 * 1024
+
+var zeroPage = make([]byte, WASMPageSize)
+
+// InstanceSnapshot is the state of an instance taken right after instantiation,
+// before any call: its linear memory and the values of its mutable globals, see
+// ExportMutableGlobals. Restoring it between calls gives a reused instance the state
+// of a fresh one, so leftovers of a previous execution (allocator state, statics,
+// stack pointer) can't affect the next one.
+//
+// A linear memory cannot shrink: an instance whose memory grew since the snapshot
+// cannot be restored, runtimes replace it by a new instance.
+type InstanceSnapshot struct {
+	memory  []byte
+	globals []uint64
+}
+
+// NewInstanceSnapshot copies `memory`, `globals` are the raw bits of the mutable
+// globals, in the order returned by ExportMutableGlobals.
+func NewInstanceSnapshot(memory []byte, globals []uint64) *InstanceSnapshot {
+	return &InstanceSnapshot{memory: bytes.Clone(memory), globals: globals}
+}
+
+// MemorySize returns the size in bytes of the memory when the snapshot was taken.
+func (s *InstanceSnapshot) MemorySize() int {
+	return len(s.memory)
+}
+
+// Memory returns the memory when the snapshot was taken, it must not be modified.
+func (s *InstanceSnapshot) Memory() []byte {
+	return s.memory
+}
+
+// Globals returns the raw bits of the mutable globals when the snapshot was taken.
+func (s *InstanceSnapshot) Globals() []uint64 {
+	return s.globals
+}
+
+// RestoreMemory brings `memory`, of the size of the snapshot, back to its content at
+// the time of the snapshot by comparing it page by page, and returns the number of
+// pages that were written. Runtimes able to track the pages written during a call
+// restore those only instead.
+func (s *InstanceSnapshot) RestoreMemory(memory []byte) (restoredPages int) {
+	for offset := 0; offset < len(memory); offset += WASMPageSize {
+		end := min(offset+WASMPageSize, len(memory))
+		page := memory[offset:end]
+
+		original := zeroPage[:len(page)]
+		if offset < len(s.memory) {
+			original = s.memory[offset:min(end, len(s.memory))]
+			if len(original) < len(page) {
+				// Only happens with a snapshot size that is not a multiple of the page size.
+				original = append(bytes.Clone(original), zeroPage[:len(page)-len(original)]...)
+			}
+		}
+
+		if !bytes.Equal(page, original) {
+			copy(page, original)
+			restoredPages++
+		}
+	}
+	return
+}
@@ -0,0 +1,22 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceSnapshot_RestoreMemory(t *testing.T) {
+	memory := make([]byte, 3*WASMPageSize)
+	memory[10] = 1
+	memory[WASMPageSize+10] = 2
+	snapshot := NewInstanceSnapshot(memory, []uint64{42})
+
+	assert.Equal(t, 0, snapshot.RestoreMemory(memory), "untouched memory restores nothing")
+
+	memory[20] = 3
+	memory[2*WASMPageSize] = 4
+	assert.Equal(t, 2, snapshot.RestoreMemory(memory))
+	assert.Equal(t, snapshot.Memory(), memory)
+	assert.Equal(t, []uint64{42}, snapshot.Globals())
+}
@@ -0,0 +1,11 @@
+package wasmtime
+
+import (
+	"testing"
+
+	"github.com/streamingfast/substreams/wasm/conformance"
+)
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, "wasmtime", "../bench")
+}
@@ -3,6 +3,7 @@ package wasmtime
 import (
 	"context"
 	"fmt"
+	"math"
 
 	wasmtime "github.com/bytecodealliance/wasmtime-go/v4"
 
@@ -20,6 +21,9 @@ type instance struct {
 	wasmLinker   *wasmtime.Linker
 	Heap         *Heap
 	isClosed     bool
+
+	module   *Module
+	snapshot *wasm.InstanceSnapshot
 }
 
 func (i *instance) Close(ctx context.Context) error {
@@ -31,6 +35,12 @@ func (i *instance) Close(ctx context.Context) error {
 }
 
 func (i *instance) Cleanup(ctx context.Context) error {
+	if i.snapshot != nil {
+		// Restoring the memory also releases the allocations made for the call.
+		i.Heap.allocations = nil
+		return i.restore(ctx)
+	}
+
 	err := i.Heap.Clear()
 	if err != nil {
 		return fmt.Errorf("clearing heap: %w", err)
@@ -39,6 +49,64 @@ func (i *instance) Cleanup(ctx context.Context) error {
 	return nil
 }
 
+// restore brings the instance back to the state of the snapshot, or replaces it by a
+// new instance when its memory grew since. wasmtime gives no access to the pages
+// written by a call, the memory is compared with the snapshot.
+func (i *instance) restore(ctx context.Context) error {
+	memory := i.Heap.memory.UnsafeData(i.wasmStore)
+	if len(memory) != i.snapshot.MemorySize() {
+		if err := i.Close(ctx); err != nil {
+			return fmt.Errorf("closing grown instance: %w", err)
+		}
+		if err := i.module.instantiate(ctx, i); err != nil {
+			return fmt.Errorf("replacing grown instance: %w", err)
+		}
+		return nil
+	}
+
+	i.snapshot.RestoreMemory(memory)
+	for idx, global := range i.module.globals {
+		if err := i.global(global.Name).Set(i.wasmStore, globalValue(global.Type, i.snapshot.Globals()[idx])); err != nil {
+			return fmt.Errorf("restoring global %q: %w", global.Name, err)
+		}
+	}
+	i.wasmStore.GC()
+	return nil
+}
+
+func (i *instance) global(name string) *wasmtime.Global {
+	return i.wasmInstance.GetExport(i.wasmStore, name).Global()
+}
+
+// globalBits returns the raw bits of a numeric global value, see wasm.InstanceSnapshot.
+func globalBits(value wasmtime.Val) uint64 {
+	switch value.Kind() {
+	case wasmtime.KindI32:
+		return uint64(uint32(value.I32()))
+	case wasmtime.KindI64:
+		return uint64(value.I64())
+	case wasmtime.KindF32:
+		return uint64(math.Float32bits(value.F32()))
+	case wasmtime.KindF64:
+		return math.Float64bits(value.F64())
+	}
+	panic(fmt.Errorf("unsupported global kind %s", value.Kind()))
+}
+
+func globalValue(valueType wasm.ValueType, bits uint64) wasmtime.Val {
+	switch valueType {
+	case wasm.ValueTypeI32:
+		return wasmtime.ValI32(int32(uint32(bits)))
+	case wasm.ValueTypeI64:
+		return wasmtime.ValI64(int64(bits))
+	case wasm.ValueTypeF32:
+		return wasmtime.ValF32(math.Float32frombits(uint32(bits)))
+	case wasm.ValueTypeF64:
+		return wasmtime.ValF64(math.Float64frombits(bits))
+	}
+	panic(fmt.Errorf("unsupported global type 0x%02x", byte(valueType)))
+}
+
 func (i *instance) newExtensionFunction(ctx context.Context, namespace, name string, f wasm.WASMExtension) interface{} {
 	return func(ptr, length, outputPtr int32) {
 		data := i.Heap.ReadBytes(ptr, length)
@@ -96,6 +164,16 @@ func (i *instance) newImports() error {
 		return fmt.Errorf("registering output import: %w", err)
 	}
 
+	if err = linker.FuncWrap("env", "output_named",
+		func(namePtr, nameLength, ptr, length int32) {
+			name := i.Heap.ReadString(namePtr, nameLength)
+			message := i.Heap.ReadBytes(ptr, length)
+			i.CurrentCall.SetNamedReturnValue(name, message)
+		},
+	); err != nil {
+		return fmt.Errorf("registering output_named import: %w", err)
+	}
+
 	return nil
 }
 
@@ -108,6 +186,18 @@ func (i *instance) registerLoggerImports(linker *wasmtime.Linker) error {
 	); err != nil {
 		return fmt.Errorf("registering println import: %w", err)
 	}
+	if err := linker.FuncWrap("logger", "log",
+		func(level int32, messagePtr, messageLength int32, fieldsPtr, fieldsLength int32) {
+			message := i.Heap.ReadString(messagePtr, messageLength)
+			fields, err := wasm.DecodeLogFields(i.Heap.ReadBytes(fieldsPtr, fieldsLength))
+			if err != nil {
+				panic(fmt.Errorf("decoding log fields: %w", err))
+			}
+			i.CurrentCall.AppendLogEntry(wasm.LogLevel(level), message, fields)
+		},
+	); err != nil {
+		return fmt.Errorf("registering log import: %w", err)
+	}
 	return nil
 }
 
@@ -2,7 +2,9 @@ package wasmtime
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 
 	wasmtime "github.com/bytecodealliance/wasmtime-go/v4"
 
@@ -13,6 +15,10 @@ type Module struct {
 	module   *wasmtime.Module
 	engine   *wasmtime.Engine
 	registry *wasm.Registry
+
+	globals      []wasm.MutableGlobal
+	snapshotLock sync.Mutex
+	snapshot     *wasm.InstanceSnapshot
 }
 
 func init() {
@@ -20,12 +26,24 @@ func init() {
 }
 
 func newModule(ctx context.Context, wasmCode []byte, registry *wasm.Registry) (wasm.Module, error) {
+	if wasm.ProfilerFromContext(ctx) != nil {
+		return nil, fmt.Errorf("module profiling is not supported by the wasmtime runtime")
+	}
+
 	cfg := wasmtime.NewConfig()
 	if registry.MaxFuel() != 0 {
 		cfg.SetConsumeFuel(true)
 	}
 	engine := wasmtime.NewEngineWithConfig(cfg)
 
+	var globals []wasm.MutableGlobal
+	if registry.InstanceSnapshotsEnabled() {
+		var err error
+		if wasmCode, globals, err = wasm.ExportMutableGlobals(wasmCode); err != nil {
+			return nil, fmt.Errorf("exporting globals for instance snapshots: %w", err)
+		}
+	}
+
 	module, err := wasmtime.NewModule(engine, wasmCode)
 	if err != nil {
 		return nil, fmt.Errorf("creating new module: %w", err)
@@ -38,6 +56,7 @@ func newModule(ctx context.Context, wasmCode []byte, registry *wasm.Registry) (w
 		module:   module,
 		engine:   engine,
 		registry: registry,
+		globals:  globals,
 	}, nil
 }
 
@@ -109,38 +128,63 @@ func (m *Module) ExecuteNewCall(ctx context.Context, call *wasm.Call, cachedInst
 	}
 
 	inst.CurrentCall = call
-	_, err = entrypoint.Call(inst.wasmStore, args...)
-	if err != nil {
+	if err = callEntrypoint(inst, entrypoint, args); err != nil {
+		var trap *wasmtime.Trap
+		if errors.As(err, &trap) {
+			return inst, fmt.Errorf("call: %w: %w", wasm.ErrTrap, err)
+		}
 		return inst, fmt.Errorf("call: %w", err)
 	}
 
 	return inst, nil
 }
 
+// callEntrypoint turns the panics raised by host functions, which wasmtime
+// propagates through the call, into errors like the other runtimes do.
+func callEntrypoint(inst *instance, entrypoint *wasmtime.Func, args []interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("host function panicked: %v", r)
+		}
+	}()
+
+	_, err = entrypoint.Call(inst.wasmStore, args...)
+	return err
+}
+
 func (m *Module) newInstance(ctx context.Context) (*instance, error) {
+	i := &instance{module: m}
+	if err := m.instantiate(ctx, i); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// instantiate creates the wasmtime instance backing `i`. Imports are bound to `i`, which
+// lets a grown instance be replaced in place when instance snapshots are enabled.
+func (m *Module) instantiate(ctx context.Context, i *instance) error {
 	linker := wasmtime.NewLinker(m.engine)
 	store := wasmtime.NewStore(m.engine)
 
-	i := &instance{
-		wasmEngine: m.engine,
-		wasmLinker: linker,
-		wasmStore:  store,
-		wasmModule: m.module,
-	}
+	i.wasmEngine = m.engine
+	i.wasmLinker = linker
+	i.wasmStore = store
+	i.wasmModule = m.module
+	i.isClosed = false
 	if err := i.newImports(); err != nil {
-		return nil, fmt.Errorf("instantiating imports: %w", err)
+		return fmt.Errorf("instantiating imports: %w", err)
 	}
 	for namespace, imports := range m.registry.Extensions {
 		for importName, f := range imports {
 			f := i.newExtensionFunction(ctx, namespace, importName, f)
 			if err := linker.FuncWrap(namespace, importName, f); err != nil {
-				return nil, fmt.Errorf("instantiating %q extension import: %w", namespace, err)
+				return fmt.Errorf("instantiating %q extension import: %w", namespace, err)
 			}
 		}
 	}
 	instance, err := i.wasmLinker.Instantiate(i.wasmStore, i.wasmModule)
 	if err != nil {
-		return nil, fmt.Errorf("creating new instance: %w", err)
+		return fmt.Errorf("creating new instance: %w", err)
 	}
 	memory := instance.GetExport(i.wasmStore, "memory").Memory()
 	alloc := instance.GetExport(i.wasmStore, "alloc").Func()
@@ -152,5 +196,20 @@ func (m *Module) newInstance(ctx context.Context) (*instance, error) {
 	heap := NewHeap(memory, alloc, dealloc, i.wasmStore)
 	i.Heap = heap
 	i.wasmInstance = instance
-	return i, nil
+
+	if m.registry.InstanceSnapshotsEnabled() {
+		// All instances share the snapshot taken from the first one since they
+		// are instantiated from the same code.
+		m.snapshotLock.Lock()
+		if m.snapshot == nil {
+			globals := make([]uint64, len(m.globals))
+			for idx, global := range m.globals {
+				globals[idx] = globalBits(i.global(global.Name).Get(i.wasmStore))
+			}
+			m.snapshot = wasm.NewInstanceSnapshot(memory.UnsafeData(i.wasmStore), globals)
+		}
+		i.snapshot = m.snapshot
+		m.snapshotLock.Unlock()
+	}
+	return nil
 }
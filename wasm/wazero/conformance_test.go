@@ -0,0 +1,19 @@
+package wazero
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/streamingfast/substreams/wasm"
+	"github.com/streamingfast/substreams/wasm/conformance"
+)
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, "wazero", "../bench")
+}
+
+func TestNewRegistryWithRuntime_Default(t *testing.T) {
+	assert.NotPanics(t, func() { wasm.NewRegistryWithRuntime("", nil, 0) }, "empty runtime name selects the default runtime")
+	assert.Panics(t, func() { wasm.NewRegistryWithRuntime("unknown", nil, 0) })
+}
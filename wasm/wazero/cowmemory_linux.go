@@ -0,0 +1,137 @@
+//go:build linux
+
+package wazero
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"github.com/tetratelabs/wazero/experimental"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// memoryImage holds the memory of an instance snapshot in an anonymous file, mapped
+// copy-on-write at the start of the memory of every instance. The kernel then tracks
+// the pages an instance writes: restoring its memory drops those pages only, the
+// others were never copied.
+type memoryImage struct {
+	fd   int
+	size int
+}
+
+func newMemoryImage(memory []byte) (*memoryImage, error) {
+	fd, err := unix.MemfdCreate("substreams-wasm-snapshot", unix.MFD_CLOEXEC)
+	if err != nil {
+		return nil, fmt.Errorf("creating memory file: %w", err)
+	}
+	image := &memoryImage{fd: fd, size: len(memory)}
+	runtime.SetFinalizer(image, func(image *memoryImage) { unix.Close(image.fd) })
+
+	if err := unix.Ftruncate(fd, int64(len(memory))); err != nil {
+		return nil, fmt.Errorf("sizing memory file: %w", err)
+	}
+	for written := 0; written < len(memory); {
+		n, err := unix.Pwrite(fd, memory[written:], int64(written))
+		if err != nil {
+			return nil, fmt.Errorf("writing memory file: %w", err)
+		}
+		written += n
+	}
+	return image, nil
+}
+
+// cowMemory is a linear memory reserved at its maximum size, so it never moves when
+// it grows and the snapshot image can be mapped over it.
+type cowMemory struct {
+	reserved []byte
+	image    *memoryImage
+}
+
+// memoryAllocator returns an allocator reserving the memory of the next instantiated
+// module as a cowMemory, set in `out`. The default allocation is kept when the memory
+// cannot be reserved, `out` is then left nil.
+func memoryAllocator(out **cowMemory) experimental.MemoryAllocator {
+	return experimental.MemoryAllocatorFunc(func(cap, max uint64) experimental.LinearMemory {
+		if max == 0 {
+			return &sliceMemory{}
+		}
+		reserved, err := unix.Mmap(-1, 0, int(max), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS|unix.MAP_NORESERVE)
+		if err != nil {
+			zlog.Warn("cannot reserve wasm memory, restoring instance snapshots will compare the whole memory", zap.Uint64("max", max), zap.Error(err))
+			return &sliceMemory{}
+		}
+		*out = &cowMemory{reserved: reserved}
+		return *out
+	})
+}
+
+func (m *cowMemory) Reallocate(size uint64) []byte {
+	if size > uint64(len(m.reserved)) {
+		panic(fmt.Errorf("wasm memory of %d bytes exceeds its maximum of %d bytes", size, len(m.reserved)))
+	}
+	return m.reserved[:size]
+}
+
+func (m *cowMemory) Free() {
+	if err := unix.Munmap(m.reserved); err != nil {
+		zlog.Warn("cannot release wasm memory", zap.Error(err))
+	}
+}
+
+// mapImage replaces the start of the memory by a private mapping of `image`, whose
+// content is the one the memory has right after instantiation.
+func (m *cowMemory) mapImage(image *memoryImage) error {
+	if image.size > len(m.reserved) {
+		return fmt.Errorf("snapshot of %d bytes exceeds the memory maximum of %d bytes", image.size, len(m.reserved))
+	}
+	if image.size == 0 {
+		m.image = image
+		return nil
+	}
+
+	_, _, errno := unix.Syscall6(unix.SYS_MMAP,
+		uintptr(unsafe.Pointer(&m.reserved[0])),
+		uintptr(image.size),
+		uintptr(unix.PROT_READ|unix.PROT_WRITE),
+		uintptr(unix.MAP_PRIVATE|unix.MAP_FIXED),
+		uintptr(image.fd),
+		0,
+	)
+	if errno != 0 {
+		return fmt.Errorf("mapping snapshot: %w", errno)
+	}
+	m.image = image
+	return nil
+}
+
+// restore drops the pages written since the image was mapped, they are read back from
+// the image on their next access.
+func (m *cowMemory) restore() error {
+	if m.image == nil {
+		return fmt.Errorf("no snapshot mapped")
+	}
+	if m.image.size == 0 {
+		return nil
+	}
+	return unix.Madvise(m.reserved[:m.image.size], unix.MADV_DONTNEED)
+}
+
+// sliceMemory grows like the default wazero memories, it is used when a memory cannot
+// be reserved.
+type sliceMemory struct {
+	buf []byte
+}
+
+func (m *sliceMemory) Reallocate(size uint64) []byte {
+	if size > uint64(cap(m.buf)) {
+		grown := make([]byte, size)
+		copy(grown, m.buf)
+		m.buf = grown
+	}
+	m.buf = m.buf[:size]
+	return m.buf
+}
+
+func (m *sliceMemory) Free() {}
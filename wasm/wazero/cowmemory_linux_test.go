@@ -0,0 +1,40 @@
+package wazero
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/streamingfast/substreams/wasm"
+)
+
+func TestInstanceSnapshot_CopyOnWriteMemory(t *testing.T) {
+	code, err := os.ReadFile("../conformance/testdata/conformance.wasm")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	extensions := map[string]map[string]wasm.WASMExtension{"test": {"echo": nil}} // imported by the conformance module
+	registry := wasm.NewRegistryWithRuntime("wazero", extensions, 0)
+	registry.EnableInstanceSnapshots()
+	module, err := registry.NewModule(ctx, code)
+	require.NoError(t, err)
+	defer module.Close(ctx)
+
+	created, err := module.NewInstance(ctx)
+	require.NoError(t, err)
+	defer created.Close(ctx)
+	inst := created.(*instance)
+	require.NotNil(t, inst.memory, "the memory is mapped from the snapshot image")
+
+	require.True(t, inst.Memory().WriteByte(600, 7))
+	require.True(t, inst.Memory().WriteByte(1024, 'K'))
+	require.NoError(t, inst.Cleanup(ctx))
+
+	value, _ := inst.Memory().ReadByte(600)
+	assert.Equal(t, byte(0), value)
+	value, _ = inst.Memory().ReadByte(1024)
+	assert.Equal(t, byte('k'), value, "data segments are part of the snapshot")
+}
@@ -0,0 +1,31 @@
+//go:build !linux
+
+package wazero
+
+import (
+	"fmt"
+
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+// memoryImage is only available on Linux, other platforms restore instance snapshots
+// by comparing the whole memory.
+type memoryImage struct{}
+
+func newMemoryImage(memory []byte) (*memoryImage, error) {
+	return nil, nil
+}
+
+type cowMemory struct{}
+
+func memoryAllocator(out **cowMemory) experimental.MemoryAllocator {
+	return nil
+}
+
+func (m *cowMemory) mapImage(image *memoryImage) error {
+	return fmt.Errorf("copy-on-write memory is not supported on this platform")
+}
+
+func (m *cowMemory) restore() error {
+	return fmt.Errorf("copy-on-write memory is not supported on this platform")
+}
@@ -36,4 +36,16 @@ var envFuncs = []funcs{
 			call.SetReturnValue(msg)
 		}),
 	},
+	{
+		"output_named",
+		[]parm{i32, i32, i32, i32},
+		[]parm{},
+		api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+			name := readStringFromStack(mod, stack[0:])
+			msg := readBytesFromStack(mod, stack[2:])
+			call := wasm.FromContext(ctx)
+
+			call.SetNamedReturnValue(name, msg)
+		}),
+	},
 }
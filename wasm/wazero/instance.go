@@ -2,13 +2,22 @@ package wazero
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/tetratelabs/wazero/api"
+
+	"github.com/streamingfast/substreams/wasm"
 )
 
 type instance struct {
 	api.Module
 	allocations []allocation
+	recorder    *wasm.ProfileRecorder
+
+	// set when instance snapshots are enabled
+	module   *Module
+	snapshot *wasm.InstanceSnapshot
+	memory   *cowMemory // nil when the memory is restored by comparing it with the snapshot
 }
 
 type allocation struct {
@@ -17,10 +26,49 @@ type allocation struct {
 }
 
 func (i *instance) Cleanup(ctx context.Context) error {
+	if i.snapshot != nil {
+		// Restoring the memory also releases the allocations made for the call.
+		i.allocations = nil
+		return i.restore(ctx)
+	}
+
 	deallocate(ctx, i)
 	return nil
 }
 
+// restore brings the instance back to the state of the snapshot, or replaces it by
+// a new instance when its memory grew since.
+func (i *instance) restore(ctx context.Context) error {
+	if i.Memory().Size() != uint32(i.snapshot.MemorySize()) {
+		if err := i.Module.Close(ctx); err != nil {
+			return fmt.Errorf("closing grown instance: %w", err)
+		}
+		mod, memory, err := i.module.instantiateModule(ctx)
+		if err != nil {
+			return fmt.Errorf("replacing grown instance: %w", err)
+		}
+		i.Module, i.memory = mod, memory
+		return nil
+	}
+
+	if i.memory != nil {
+		if err := i.memory.restore(); err != nil {
+			return fmt.Errorf("restoring memory: %w", err)
+		}
+	} else {
+		memory, ok := i.Memory().Read(0, i.Memory().Size())
+		if !ok {
+			return fmt.Errorf("could not read memory to restore it")
+		}
+		i.snapshot.RestoreMemory(memory)
+	}
+
+	for idx, global := range i.module.globals {
+		i.ExportedGlobal(global.Name).(api.MutableGlobal).Set(i.snapshot.Globals()[idx])
+	}
+	return nil
+}
+
 func (i *instance) Close(ctx context.Context) error {
 	return i.Module.Close(ctx)
 }
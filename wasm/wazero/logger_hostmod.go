@@ -27,7 +27,7 @@ var loggerFuncs = []funcs{
 			}
 
 			if length > wasm.MaxLogByteCount {
-				panic(fmt.Errorf("message to log is too big, max size is %s", humanize.IBytes(uint64(length))))
+				panic(fmt.Errorf("message to log is too big, max size is %s", humanize.IBytes(uint64(wasm.MaxLogByteCount))))
 			}
 
 			if tracer.Enabled() {
@@ -38,4 +38,34 @@ var loggerFuncs = []funcs{
 			return
 		}),
 	},
+	{
+		"log",
+		[]parm{i32, i32, i32, i32, i32}, // level, message ptr, message len, fields ptr, fields len
+		[]parm{},
+		api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+			level := wasm.LogLevel(int32(uint32(stack[0])))
+			call := wasm.FromContext(ctx)
+
+			if call.ReachedLogsMaxByteCount() {
+				// Early exit, we don't even need to collect the message as we would not store it anyway
+				return
+			}
+
+			if length := uint64(uint32(stack[2])) + uint64(uint32(stack[4])); length > wasm.MaxLogByteCount {
+				panic(fmt.Errorf("message to log is too big, max size is %s", humanize.IBytes(uint64(wasm.MaxLogByteCount))))
+			}
+
+			message := readStringFromStack(mod, stack[1:])
+			fields, err := wasm.DecodeLogFields(readBytesFromStack(mod, stack[3:]))
+			if err != nil {
+				panic(fmt.Errorf("decoding log fields: %w", err))
+			}
+
+			if tracer.Enabled() {
+				zlog.Debug(message, zap.String("module_name", call.ModuleName), zap.Stringer("level", level))
+			}
+
+			call.AppendLogEntry(level, message, fields)
+		}),
+	},
 }
@@ -3,10 +3,13 @@ package wazero
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"go.uber.org/zap"
 
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/wasm"
@@ -20,6 +23,13 @@ type Module struct {
 	wazModuleConfig wazero.ModuleConfig
 	hostModules     []wazero.CompiledModule
 	userModule      wazero.CompiledModule
+
+	snapshotsEnabled bool
+	globals          []wasm.MutableGlobal
+	snapshot         *wasm.InstanceSnapshot
+	memoryImage      *memoryImage
+
+	profiler *wasm.Profiler
 }
 
 func init() {
@@ -52,9 +62,23 @@ func newModule(ctx context.Context, wasmCode []byte, registry *wasm.Registry) (w
 	}
 	hostModules = append(hostModules, envModule, stateModule, loggerModule)
 
+	profiler := wasm.ProfilerFromContext(ctx)
+	compileCtx := ctx
+	if profiler != nil {
+		// Listeners are bound at compilation time, only profiled requests pay their cost.
+		compileCtx = experimental.WithFunctionListenerFactory(ctx, profilingListenerFactory{})
+	}
+
+	var globals []wasm.MutableGlobal
+	if registry.InstanceSnapshotsEnabled() {
+		if wasmCode, globals, err = wasm.ExportMutableGlobals(wasmCode); err != nil {
+			return nil, fmt.Errorf("exporting globals for instance snapshots: %w", err)
+		}
+	}
+
 	// TODO: where to `Close()` the `runtime` here?
 	// One runtime per request?
-	mod, err := runtime.CompileModule(ctx, wasmCode)
+	mod, err := runtime.CompileModule(compileCtx, wasmCode)
 	if err != nil {
 		return nil, fmt.Errorf("creating new module: %w", err)
 	}
@@ -72,6 +96,10 @@ func newModule(ctx context.Context, wasmCode []byte, registry *wasm.Registry) (w
 		wazRuntime:      runtime,
 		userModule:      mod,
 		hostModules:     hostModules,
+
+		snapshotsEnabled: registry.InstanceSnapshotsEnabled(),
+		globals:          globals,
+		profiler:         profiler,
 	}, nil
 }
 
@@ -92,25 +120,31 @@ func (m *Module) Close(ctx context.Context) error {
 }
 
 func (m *Module) NewInstance(ctx context.Context) (out wasm.Instance, err error) {
-	mod, err := m.instantiateModule(ctx)
+	mod, memory, err := m.instantiateModule(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not instantiate wasm module: %w", err)
 	}
 
-	return &instance{Module: mod}, nil
+	return m.newInstance(mod, memory), nil
 }
 
 func (m *Module) ExecuteNewCall(ctx context.Context, call *wasm.Call, cachedInstance wasm.Instance, arguments []wasm.Argument) (out wasm.Instance, err error) {
-	var mod api.Module
+	var inst *instance
 	if cachedInstance != nil {
-		mod = cachedInstance.(api.Module)
+		inst = cachedInstance.(*instance)
 	} else {
-		mod, err = m.instantiateModule(ctx)
+		mod, memory, err := m.instantiateModule(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("could not instantiate wasm module: %w", err)
 		}
+		inst = m.newInstance(mod, memory)
+	}
+	mod := inst.Module
+	if m.profiler != nil {
+		if inst.recorder = m.profiler.StartRecording(call.ModuleName); inst.recorder != nil {
+			defer inst.recorder.End()
+		}
 	}
-	inst := &instance{Module: mod}
 
 	f := mod.ExportedFunction(call.Entrypoint)
 	if f == nil {
@@ -140,13 +174,25 @@ func (m *Module) ExecuteNewCall(ctx context.Context, call *wasm.Call, cachedInst
 
 	_, err = f.Call(wasm.WithContext(withInstanceContext(ctx, inst), call), args...)
 	if err != nil {
+		if isTrap(err) {
+			return inst, fmt.Errorf("call: %w: %w", wasm.ErrTrap, err)
+		}
 		return inst, fmt.Errorf("call: %w", err)
 	}
 
 	return inst, nil
 }
 
-func (m *Module) instantiateModule(ctx context.Context) (api.Module, error) {
+// isTrap tells whether `err`, returned by a call, comes from the module code: wazero
+// reports its traps as "wasm error", the panics of host functions as recovered errors.
+func isTrap(err error) bool {
+	return strings.HasPrefix(err.Error(), "wasm error: ")
+}
+
+// instantiateModule instantiates the user module. With instance snapshots enabled, the
+// first instance is snapshotted and the memory of every instance is mapped copy-on-write
+// from the snapshot when the platform supports it, `memory` is nil otherwise.
+func (m *Module) instantiateModule(ctx context.Context) (mod api.Module, memory *cowMemory, err error) {
 	m.Lock()
 	defer m.Unlock()
 
@@ -156,11 +202,68 @@ func (m *Module) instantiateModule(ctx context.Context) (api.Module, error) {
 		}
 		_, err := m.wazRuntime.InstantiateModule(ctx, hostMod, m.wazModuleConfig.WithName(hostMod.Name()))
 		if err != nil {
-			return nil, fmt.Errorf("instantiating host module %q: %w", hostMod.Name(), err)
+			return nil, nil, fmt.Errorf("instantiating host module %q: %w", hostMod.Name(), err)
+		}
+	}
+
+	instantiateCtx := ctx
+	if m.snapshotsEnabled {
+		instantiateCtx = experimental.WithMemoryAllocator(ctx, memoryAllocator(&memory))
+	}
+	mod, err = m.wazRuntime.InstantiateModule(instantiateCtx, m.userModule, m.wazModuleConfig.WithName(""))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !m.snapshotsEnabled {
+		return mod, nil, nil
+	}
+
+	if m.snapshot == nil {
+		if err := m.takeSnapshot(mod); err != nil {
+			mod.Close(ctx)
+			return nil, nil, err
 		}
 	}
-	mod, err := m.wazRuntime.InstantiateModule(ctx, m.userModule, m.wazModuleConfig.WithName(""))
-	return mod, err
+	if memory == nil || m.memoryImage == nil {
+		return mod, nil, nil
+	}
+	if err := memory.mapImage(m.memoryImage); err != nil {
+		mod.Close(ctx)
+		return nil, nil, err
+	}
+	return mod, memory, nil
+}
+
+func (m *Module) takeSnapshot(mod api.Module) error {
+	memory, ok := mod.Memory().Read(0, mod.Memory().Size())
+	if !ok {
+		return fmt.Errorf("could not read memory to snapshot it")
+	}
+	globals := make([]uint64, len(m.globals))
+	for idx, global := range m.globals {
+		globals[idx] = mod.ExportedGlobal(global.Name).Get()
+	}
+	m.snapshot = wasm.NewInstanceSnapshot(memory, globals)
+
+	image, err := newMemoryImage(memory)
+	if err != nil {
+		zlog.Warn("cannot create snapshot memory image, restoring instance snapshots will compare the whole memory", zap.Error(err))
+		return nil
+	}
+	m.memoryImage = image
+	return nil
+}
+
+// newInstance wraps `mod`, all instances share the snapshot taken from the first one
+// since they are instantiated from the same code.
+func (m *Module) newInstance(mod api.Module, memory *cowMemory) *instance {
+	m.Lock()
+	defer m.Unlock()
+	inst := &instance{Module: mod}
+	if m.snapshot != nil {
+		inst.module, inst.snapshot, inst.memory = m, m.snapshot, memory
+	}
+	return inst
 }
 
 func addExtensionFunctions(ctx context.Context, runtime wazero.Runtime, registry *wasm.Registry) (out []wazero.CompiledModule, err error) {
@@ -0,0 +1,52 @@
+package wazero
+
+import (
+	"context"
+	"slices"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+// profilingListenerFactory samples the call stack of the user's module on function
+// entries, for the wasm.ProfileRecorder of the executing instance, if any.
+type profilingListenerFactory struct{}
+
+func (profilingListenerFactory) NewFunctionListener(api.FunctionDefinition) experimental.FunctionListener {
+	return profilingListener{}
+}
+
+type profilingListener struct{}
+
+func (profilingListener) Before(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64, stack experimental.StackIterator) {
+	inst, ok := ctx.Value("instance").(*instance)
+	if !ok || inst.recorder == nil || !inst.recorder.SampleDue() {
+		return
+	}
+
+	// The first frame is the function being entered, the time elapsed since the last
+	// sample was spent in its callers.
+	stack.Next()
+	var functions []string
+	for stack.Next() {
+		functions = append(functions, functionName(stack.Function().Definition()))
+	}
+	if len(functions) == 0 {
+		return
+	}
+	slices.Reverse(functions)
+	inst.recorder.Sample(functions)
+}
+
+func (profilingListener) After(context.Context, api.Module, api.FunctionDefinition, []uint64) {}
+
+func (profilingListener) Abort(context.Context, api.Module, api.FunctionDefinition, error) {}
+
+// functionName returns the name of `def` from the module's name section, falling back
+// to the function index when it was stripped.
+func functionName(def api.FunctionDefinition) string {
+	if name := def.Name(); name != "" {
+		return name
+	}
+	return def.DebugName()
+}
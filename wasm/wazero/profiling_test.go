@@ -0,0 +1,66 @@
+package wazero
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/metrics"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/wasm"
+)
+
+func TestModule_Profiling(t *testing.T) {
+	code, err := os.ReadFile("../bench/substreams_wasm/substreams.wasm")
+	require.NoError(t, err)
+	block, err := os.ReadFile("../bench/testdata/ethereum_mainnet_block_16021772.binpb")
+	require.NoError(t, err)
+
+	// A zero period samples on every function entry.
+	profiler := wasm.NewProfilerWithPeriod([]string{"profiled"}, 0)
+
+	ctx := context.Background()
+	stats := metrics.NewReqStats(&metrics.Config{}, zap.NewNop())
+	ctx = reqctx.WithReqStats(ctx, stats)
+	ctx = reqctx.WithRequest(ctx, &reqctx.RequestDetails{UniqueID: 1})
+	ctx = wasm.WithProfiler(ctx, profiler)
+
+	module, err := wasm.NewRegistryWithRuntime("wazero", nil, 0).NewModule(ctx, code)
+	require.NoError(t, err)
+	defer module.Close(ctx)
+
+	for _, moduleName := range []string{"profiled", "profiled", "not_profiled"} {
+		source := wasm.NewSourceInput("sf.ethereum.type.v2.Block")
+		source.SetValue(block)
+		arguments := []wasm.Argument{source}
+
+		call := wasm.NewCall(&pbsubstreams.Clock{Number: 42}, moduleName, "map_block", stats, arguments)
+		instance, err := module.ExecuteNewCall(ctx, call, nil, arguments)
+		require.NoError(t, err)
+		require.NoError(t, call.Err())
+		require.NoError(t, instance.Close(ctx))
+	}
+
+	_, err = profiler.Profile("not_profiled")
+	require.Error(t, err)
+
+	out, err := profiler.Profile("profiled")
+	require.NoError(t, err)
+	prof, err := profile.Parse(bytes.NewReader(out))
+	require.NoError(t, err)
+
+	require.NotEmpty(t, prof.Sample)
+	for _, sample := range prof.Sample {
+		outermost := sample.Location[len(sample.Location)-1]
+		assert.Equal(t, "map_block", outermost.Line[0].Function.Name)
+	}
+	assert.Greater(t, len(prof.Function), 1)
+	assert.Greater(t, prof.DurationNanos, int64(0))
+}